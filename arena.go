@@ -0,0 +1,45 @@
+package css
+
+// arena allocates the complex selectors of a single parse from shared slabs,
+// rather than individually, reducing the number of small allocations when
+// parsing many selectors, such as the rules of a stylesheet.
+//
+// Nodes allocated by an arena are referenced by the parsed AST, so a slab is
+// only released once every selector parsed into it is unreachable. Since a
+// parser owns its arena, unused capacity of the last slab is released along
+// with the Selector.
+type arena struct {
+	complex []complexSelector
+	// slabSize is the size of the next slab allocated. Slabs grow as more
+	// nodes are allocated, so short selectors don't waste space.
+	slabSize int
+}
+
+const (
+	minArenaSlab = 4
+	maxArenaSlab = 64
+)
+
+// newComplexSelector returns a zero complexSelector. A nil arena allocates
+// the node individually.
+func (a *arena) newComplexSelector() *complexSelector {
+	if a == nil {
+		return &complexSelector{}
+	}
+	if len(a.complex) == cap(a.complex) {
+		a.slabSize = min(max(a.slabSize*2, minArenaSlab), maxArenaSlab)
+		a.complex = make([]complexSelector, 0, a.slabSize)
+	}
+	a.complex = a.complex[:len(a.complex)+1]
+	return &a.complex[len(a.complex)-1]
+}
+
+// free returns n to the arena if it was the last node allocated, such as a
+// node allocated speculatively for a compound selector that wasn't found.
+func (a *arena) free(n *complexSelector) {
+	if a == nil || len(a.complex) == 0 || n != &a.complex[len(a.complex)-1] {
+		return
+	}
+	*n = complexSelector{}
+	a.complex = a.complex[:len(a.complex)-1]
+}
@@ -0,0 +1,70 @@
+package css
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestArena(t *testing.T) {
+	tests := []string{
+		"a",
+		"a b > c + d ~ e",
+		"a, b c, d > e > f > g",
+		"div.a#b[c=d]:first-child > span::part(x)",
+		"a /for/ b || c",
+		":is(a b, c > d) :has(> e f)",
+		strings.Repeat("a > ", 100) + "b",
+	}
+	for _, s := range tests {
+		p := newParser(s)
+		got, err := p.parse()
+		if err != nil {
+			t.Errorf("parse(%q) failed: %v", s, err)
+			continue
+		}
+		p = newParser(s)
+		p.arena = nil
+		want, err := p.parse()
+		if err != nil {
+			t.Errorf("parse(%q) without arena failed: %v", s, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("parse(%q) with arena returned a different AST than without", s)
+		}
+	}
+}
+
+func TestArenaAllocs(t *testing.T) {
+	s := strings.Repeat("a > b c + d, ", 50) + "e"
+	allocs := func(withArena bool) float64 {
+		return testing.AllocsPerRun(10, func() {
+			p := newParser(s)
+			if !withArena {
+				p.arena = nil
+			}
+			if _, err := p.parse(); err != nil {
+				t.Fatalf("parse() failed: %v", err)
+			}
+		})
+	}
+	if with, without := allocs(true), allocs(false); with >= without {
+		t.Errorf("parsing with an arena made %v allocations, want fewer than %v without", with, without)
+	}
+}
+
+func TestArenaFree(t *testing.T) {
+	a := &arena{}
+	n1 := a.newComplexSelector()
+	n2 := a.newComplexSelector()
+	n2.pos = 1
+	a.free(n1) // Not the last node, so it isn't freed.
+	a.free(n2)
+	if n3 := a.newComplexSelector(); n3 != n2 || n3.pos != 0 {
+		t.Errorf("newComplexSelector() after free() didn't return a zero value of the freed node")
+	}
+	if len(a.complex) != 2 {
+		t.Errorf("arena holds %d nodes, want 2", len(a.complex))
+	}
+}
@@ -0,0 +1,167 @@
+package css
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// role compiles the :role() extension, which matches elements by their ARIA
+// role.
+func (c *compiler) role(s *pseudoClassSelector) func(n *html.Node) bool {
+	p := newParserFromTokens(s.args)
+	p.skipWhitespace()
+	t, err := p.next()
+	if err != nil {
+		c.errorf(s.pos, "failed to parse :role() argument: %v", err)
+		return nil
+	}
+	if t.typ != tokenIdent {
+		c.errorf(s.pos, "expected role name, got %s", t)
+		return nil
+	}
+	if err := p.expectWhitespaceOrEOF(); err != nil {
+		c.errorf(s.pos, "failed to parse :role() argument: %v", err)
+		return nil
+	}
+	name := strings.ToLower(t.s)
+	return func(n *html.Node) bool {
+		return elementRole(n) == name
+	}
+}
+
+// elementRole returns the ARIA role of n. The first token of an explicit role
+// attribute is used if present, otherwise the element's implicit role.
+//
+// https://www.w3.org/TR/html-aria/#docconformance
+func elementRole(n *html.Node) string {
+	if val, ok := attr(n, "role"); ok {
+		if fields := strings.Fields(val); len(fields) > 0 {
+			return strings.ToLower(fields[0])
+		}
+	}
+	if n.Namespace != "" {
+		return ""
+	}
+	return implicitRole(n)
+}
+
+// implicitRoles maps elements to their implicit ARIA role, for elements whose
+// role doesn't depend on their attributes or context.
+var implicitRoles = map[atom.Atom]string{
+	atom.Article:  "article",
+	atom.Aside:    "complementary",
+	atom.Button:   "button",
+	atom.Datalist: "listbox",
+	atom.Dd:       "definition",
+	atom.Details:  "group",
+	atom.Dialog:   "dialog",
+	atom.Dt:       "term",
+	atom.Fieldset: "group",
+	atom.Figure:   "figure",
+	atom.Form:     "form",
+	atom.H1:       "heading",
+	atom.H2:       "heading",
+	atom.H3:       "heading",
+	atom.H4:       "heading",
+	atom.H5:       "heading",
+	atom.H6:       "heading",
+	atom.Hr:       "separator",
+	atom.Li:       "listitem",
+	atom.Main:     "main",
+	atom.Menu:     "list",
+	atom.Nav:      "navigation",
+	atom.Ol:       "list",
+	atom.Optgroup: "group",
+	atom.Option:   "option",
+	atom.Output:   "status",
+	atom.Progress: "progressbar",
+	atom.Table:    "table",
+	atom.Tbody:    "rowgroup",
+	atom.Td:       "cell",
+	atom.Textarea: "textbox",
+	atom.Tfoot:    "rowgroup",
+	atom.Th:       "columnheader",
+	atom.Thead:    "rowgroup",
+	atom.Tr:       "row",
+	atom.Ul:       "list",
+}
+
+// inputRoles maps the type attribute of input elements to their implicit
+// role.
+var inputRoles = map[string]string{
+	"button":   "button",
+	"checkbox": "checkbox",
+	"email":    "textbox",
+	"image":    "button",
+	"number":   "spinbutton",
+	"radio":    "radio",
+	"range":    "slider",
+	"reset":    "button",
+	"search":   "searchbox",
+	"submit":   "button",
+	"tel":      "textbox",
+	"text":     "textbox",
+	"url":      "textbox",
+}
+
+func implicitRole(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.A, atom.Area:
+		if _, ok := attr(n, "href"); ok {
+			return "link"
+		}
+		return ""
+	case atom.Footer, atom.Header:
+		// Headers and footers are only landmarks when they aren't scoped to
+		// sectioning content.
+		for p := n.Parent; p != nil; p = p.Parent {
+			switch p.DataAtom {
+			case atom.Article, atom.Aside, atom.Main, atom.Nav, atom.Section:
+				return ""
+			}
+		}
+		if n.DataAtom == atom.Header {
+			return "banner"
+		}
+		return "contentinfo"
+	case atom.Img:
+		if alt, ok := attr(n, "alt"); ok && alt == "" {
+			return "presentation"
+		}
+		return "img"
+	case atom.Input:
+		typ, ok := attr(n, "type")
+		if !ok {
+			typ = "text"
+		}
+		typ = strings.ToLower(typ)
+		if _, ok := attr(n, "list"); ok {
+			switch typ {
+			case "email", "search", "tel", "text", "url":
+				return "combobox"
+			}
+		}
+		return inputRoles[typ]
+	case atom.Section:
+		// Sections are only region landmarks when they have an accessible
+		// name.
+		if _, ok := attr(n, "aria-label"); ok {
+			return "region"
+		}
+		if _, ok := attr(n, "aria-labelledby"); ok {
+			return "region"
+		}
+		return ""
+	case atom.Select:
+		if _, ok := attr(n, "multiple"); ok {
+			return "listbox"
+		}
+		if size, ok := attr(n, "size"); ok && size != "" && size != "0" && size != "1" {
+			return "listbox"
+		}
+		return "combobox"
+	}
+	return implicitRoles[n.DataAtom]
+}
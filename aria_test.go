@@ -0,0 +1,73 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestRole(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<header id="banner"></header>
+<nav id="nav"><a id="link" href="/"></a><a id="anchor"></a></nav>
+<article><header id="scoped"></header></article>
+<div id="custom" role="Button navigation"></div>
+<button id="button"></button>
+<input id="text">
+<input id="check" type="checkbox">
+<input id="submit" type="SUBMIT">
+<input id="combo" list="options">
+<select id="select"></select>
+<select id="listbox" multiple></select>
+<img id="img" src="a.png"><img id="decorative" src="b.png" alt="">
+<section id="region" aria-label="Results"></section><section id="section"></section>
+<svg><a id="svg" href="/"></a></svg>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{":role(button)", []string{"custom", "button", "submit"}},
+		{":role( BUTTON )", []string{"custom", "button", "submit"}},
+		{":role(banner)", []string{"banner"}},
+		{":role(navigation)", []string{"nav"}},
+		{":role(link)", []string{"link"}},
+		{":role(textbox)", []string{"text"}},
+		{":role(checkbox)", []string{"check"}},
+		{":role(combobox)", []string{"combo", "select"}},
+		{":role(listbox)", []string{"listbox"}},
+		{":role(img)", []string{"img"}},
+		{":role(presentation)", []string{"decorative"}},
+		{":role(region)", []string{"region"}},
+		{"nav > :role(link)", []string{"link"}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel, WithExtensions())
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		var got []string
+		for _, n := range s.Select(root) {
+			id, _ := attr(n, "id")
+			got = append(got, id)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+
+	for _, sel := range []string{":role()", ":role(1)", ":role(a b)"} {
+		if _, err := Parse(sel, WithExtensions()); err == nil {
+			t.Errorf("Parse(%q) didn't return an error", sel)
+		}
+	}
+	if _, err := Parse(":role(button)"); err == nil {
+		t.Errorf("Parse() of :role() without WithExtensions() didn't return an error")
+	}
+}
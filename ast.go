@@ -0,0 +1,390 @@
+package css
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ComplexSelector is the parsed form of a single selector within a selector
+// list, such as "div > a.link". It's made up of one or more compound selectors
+// joined by combinators.
+//
+// The AST types in this package are intended for tools that want to inspect
+// selectors without compiling them, and support JSON encoding. Each node
+// records the span of source text it was parsed from, with Pos and End holding
+// the byte offsets of its first character and the character after its last.
+type ComplexSelector struct {
+	Pos       int                 `json:"pos"`
+	End       int                 `json:"end"`
+	Compounds []*CompoundSelector `json:"compounds"`
+}
+
+// CompoundSelector is a sequence of simple selectors that aren't separated by
+// a combinator, such as "a.link[href]".
+type CompoundSelector struct {
+	Pos int `json:"pos"`
+	End int `json:"end"`
+	// Combinator joins the compound selector to the previous one in its
+	// complex selector. It's one of " " (descendant), ">", "+", "~" or "||",
+	// the "^" extension, or a custom combinator such as "/for/". It's empty
+	// for the first compound selector.
+	Combinator     string                   `json:"combinator,omitempty"`
+	Type           *TypeSelector            `json:"type,omitempty"`
+	Subclasses     []*SubclassSelector      `json:"subclasses,omitempty"`
+	PseudoElements []*PseudoElementSelector `json:"pseudoElements,omitempty"`
+}
+
+// TypeSelector matches elements by name, such as "a", "svg|a", or "*".
+type TypeSelector struct {
+	Pos int `json:"pos"`
+	End int `json:"end"`
+	// HasNamespace indicates an explicit namespace prefix was provided. This
+	// distinguishes "|a" (elements without a namespace) from "a" (elements in
+	// any namespace).
+	HasNamespace bool   `json:"hasNamespace,omitempty"`
+	Namespace    string `json:"namespace,omitempty"`
+	Name         string `json:"name"`
+}
+
+// SubclassSelector is an ID, class, attribute, or pseudo-class selector.
+// Exactly one of ID, Class, Attribute, or PseudoClass is set.
+type SubclassSelector struct {
+	Pos         int                  `json:"pos"`
+	End         int                  `json:"end"`
+	ID          string               `json:"id,omitempty"`
+	Class       string               `json:"class,omitempty"`
+	Attribute   *AttributeSelector   `json:"attribute,omitempty"`
+	PseudoClass *PseudoClassSelector `json:"pseudoClass,omitempty"`
+}
+
+// AttributeSelector matches elements by attribute, such as "[href^=https]".
+type AttributeSelector struct {
+	Pos          int    `json:"pos"`
+	End          int    `json:"end"`
+	HasNamespace bool   `json:"hasNamespace,omitempty"`
+	Namespace    string `json:"namespace,omitempty"`
+	Name         string `json:"name"`
+	// Matcher is the comparison performed against the attribute value, one
+	// of "=", "~=", "|=", "^=", "$=", "*=", or the "%=" extension. It's empty
+	// if the selector only checks for the presence of the attribute. For
+	// named comparators, such as "[data-price:gt(100)]", Matcher is the name
+	// prefixed with a colon, ":gt", and Value holds the arguments as written.
+	Matcher         string `json:"matcher,omitempty"`
+	Value           string `json:"value,omitempty"`
+	CaseInsensitive bool   `json:"caseInsensitive,omitempty"`
+}
+
+// PseudoClassSelector is a pseudo-class such as ":first-child" or
+// ":nth-child(2n+1)".
+type PseudoClassSelector struct {
+	Pos  int    `json:"pos"`
+	End  int    `json:"end"`
+	Name string `json:"name"`
+	// Function is set for functional pseudo-classes, in which case Args holds
+	// the raw, unparsed text between the parentheses.
+	Function bool   `json:"function,omitempty"`
+	Args     string `json:"args,omitempty"`
+}
+
+// PseudoElementSelector is a pseudo-element such as "::before", along with
+// any pseudo-classes that follow it. Its span includes those pseudo-classes.
+type PseudoElementSelector struct {
+	Pos      int                    `json:"pos"`
+	End      int                    `json:"end"`
+	Name     string                 `json:"name"`
+	Function bool                   `json:"function,omitempty"`
+	Args     string                 `json:"args,omitempty"`
+	Classes  []*PseudoClassSelector `json:"classes,omitempty"`
+}
+
+// AST returns the parsed form of the selector list the Selector was compiled
+// from. The returned values are a copy and may be modified by the caller.
+func (s *Selector) AST() []*ComplexSelector {
+	var list []*ComplexSelector
+	for i := range s.list {
+		list = append(list, newComplexSelector(&s.list[i]))
+	}
+	return list
+}
+
+// MarshalJSON encodes the AST of the selector, including the positions of
+// each component.
+func (s *Selector) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.AST())
+}
+
+// UnmarshalJSON decodes and compiles a selector AST previously encoded by
+// MarshalJSON.
+func (s *Selector) UnmarshalJSON(b []byte) error {
+	var list []*ComplexSelector
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return errorf(0, "expected at least one selector")
+	}
+	var sels []complexSelector
+	for _, cs := range list {
+		sel, err := cs.complexSelector()
+		if err != nil {
+			return err
+		}
+		sels = append(sels, *sel)
+	}
+	sel, err := compile(sels)
+	if err != nil {
+		return err
+	}
+	*s = *sel
+	return nil
+}
+
+func newComplexSelector(s *complexSelector) *ComplexSelector {
+	cs := &ComplexSelector{Pos: s.pos, End: s.end}
+	comb := ""
+	for curr := s; curr != nil; curr = curr.next {
+		c := newCompoundSelector(&curr.sel)
+		c.Combinator = comb
+		cs.Compounds = append(cs.Compounds, c)
+
+		comb = curr.combinator
+		if comb == "" {
+			comb = " "
+		}
+	}
+	return cs
+}
+
+func newCompoundSelector(s *compoundSelector) *CompoundSelector {
+	cs := &CompoundSelector{Pos: s.pos, End: s.end}
+	if t := s.typeSelector; t != nil {
+		cs.Type = &TypeSelector{
+			Pos:          t.pos,
+			End:          t.end,
+			HasNamespace: t.hasPrefix,
+			Namespace:    t.prefix,
+			Name:         t.value,
+		}
+	}
+	for _, sc := range s.subClasses {
+		ss := &SubclassSelector{
+			Pos:   sc.pos,
+			End:   sc.end,
+			ID:    sc.idSelector,
+			Class: sc.classSelector,
+		}
+		if a := sc.attributeSelector; a != nil {
+			ss.Attribute = &AttributeSelector{
+				Pos:             a.pos,
+				End:             a.end,
+				HasNamespace:    a.wqName.hasPrefix,
+				Namespace:       a.wqName.prefix,
+				Name:            a.wqName.value,
+				Matcher:         a.matcher,
+				Value:           a.val,
+				CaseInsensitive: a.modifier,
+			}
+		}
+		if pc := sc.pseudoClassSelector; pc != nil {
+			ss.PseudoClass = newPseudoClassSelector(pc)
+		}
+		cs.Subclasses = append(cs.Subclasses, ss)
+	}
+	for _, ps := range s.pseudoSelectors {
+		pc := newPseudoClassSelector(&ps.element)
+		pe := &PseudoElementSelector{
+			Pos:      ps.pos,
+			End:      ps.end,
+			Name:     pc.Name,
+			Function: pc.Function,
+			Args:     pc.Args,
+		}
+		for i := range ps.classes {
+			pe.Classes = append(pe.Classes, newPseudoClassSelector(&ps.classes[i]))
+		}
+		cs.PseudoElements = append(cs.PseudoElements, pe)
+	}
+	return cs
+}
+
+func newPseudoClassSelector(s *pseudoClassSelector) *PseudoClassSelector {
+	if s.function == "" {
+		return &PseudoClassSelector{Pos: s.pos, End: s.end, Name: s.ident}
+	}
+	var args strings.Builder
+	for _, t := range s.args {
+		args.WriteString(t.raw)
+	}
+	return &PseudoClassSelector{
+		Pos:      s.pos,
+		End:      s.end,
+		Name:     strings.TrimSuffix(s.function, "("),
+		Function: true,
+		Args:     args.String(),
+	}
+}
+
+// complexSelector converts the public AST back to the internal representation
+// used by the compiler.
+func (s *ComplexSelector) complexSelector() (*complexSelector, error) {
+	if len(s.Compounds) == 0 {
+		return nil, errorf(s.Pos, "expected at least one compound selector")
+	}
+	var (
+		first *complexSelector
+		last  *complexSelector
+	)
+	for i, c := range s.Compounds {
+		if c == nil {
+			return nil, errorf(s.Pos, "unexpected null compound selector")
+		}
+		sel, err := c.compoundSelector()
+		if err != nil {
+			return nil, err
+		}
+		next := &complexSelector{pos: c.Pos, end: s.End, sel: *sel}
+		if i == 0 {
+			if c.Combinator != "" {
+				return nil, errorf(c.Pos, "unexpected combinator for first compound selector: %q", c.Combinator)
+			}
+			next.pos = s.Pos
+			first = next
+			last = next
+			continue
+		}
+		switch c.Combinator {
+		case " ":
+			last.combinator = ""
+		case ">", "+", "~", "||", "^":
+			last.combinator = c.Combinator
+		default:
+			if isCustomCombinator(c.Combinator) {
+				last.combinator = c.Combinator
+				break
+			}
+			return nil, errorf(c.Pos, "invalid combinator: %q", c.Combinator)
+		}
+		last.next = next
+		last = next
+	}
+	return first, nil
+}
+
+func (c *CompoundSelector) compoundSelector() (*compoundSelector, error) {
+	cs := &compoundSelector{pos: c.Pos, end: c.End}
+	if t := c.Type; t != nil {
+		cs.typeSelector = &typeSelector{
+			pos:       t.Pos,
+			end:       t.End,
+			hasPrefix: t.HasNamespace,
+			prefix:    t.Namespace,
+			value:     t.Name,
+		}
+	}
+	for _, sc := range c.Subclasses {
+		if sc == nil {
+			return nil, errorf(c.Pos, "unexpected null subclass selector")
+		}
+		ss := subclassSelector{
+			pos:           sc.Pos,
+			end:           sc.End,
+			idSelector:    sc.ID,
+			classSelector: sc.Class,
+		}
+		n := 0
+		if sc.ID != "" {
+			n++
+		}
+		if sc.Class != "" {
+			n++
+		}
+		if a := sc.Attribute; a != nil {
+			n++
+			ss.attributeSelector = &attributeSelector{
+				pos: a.Pos,
+				end: a.End,
+				wqName: &wqName{
+					hasPrefix: a.HasNamespace,
+					prefix:    a.Namespace,
+					value:     a.Name,
+				},
+				matcher:  a.Matcher,
+				val:      a.Value,
+				modifier: a.CaseInsensitive,
+			}
+		}
+		if pc := sc.PseudoClass; pc != nil {
+			n++
+			p, err := pc.pseudoClassSelector(len(":"))
+			if err != nil {
+				return nil, err
+			}
+			ss.pseudoClassSelector = p
+		}
+		if n != 1 {
+			return nil, errorf(sc.Pos, "subclass selector must have exactly one of id, class, attribute, or pseudo-class")
+		}
+		cs.subClasses = append(cs.subClasses, ss)
+	}
+	for _, pe := range c.PseudoElements {
+		if pe == nil {
+			return nil, errorf(c.Pos, "unexpected null pseudo-element selector")
+		}
+		pc := &PseudoClassSelector{
+			Pos:      pe.Pos,
+			Name:     pe.Name,
+			Function: pe.Function,
+			Args:     pe.Args,
+		}
+		ele, err := pc.pseudoClassSelector(len("::"))
+		if err != nil {
+			return nil, err
+		}
+		// The pseudo-element is parsed as a pseudo-class starting from the
+		// second ':'.
+		ele.pos++
+		ps := pseudoSelector{pos: pe.Pos, element: *ele, end: pe.End}
+		for _, class := range pe.Classes {
+			if class == nil {
+				return nil, errorf(pe.Pos, "unexpected null pseudo-class selector")
+			}
+			p, err := class.pseudoClassSelector(len(":"))
+			if err != nil {
+				return nil, err
+			}
+			ps.classes = append(ps.classes, *p)
+		}
+		cs.pseudoSelectors = append(cs.pseudoSelectors, ps)
+	}
+	return cs, nil
+}
+
+// pseudoClassSelector converts a pseudo-class, or the name and arguments of a
+// pseudo-element, written with the given number of leading colons.
+func (p *PseudoClassSelector) pseudoClassSelector(colons int) (*pseudoClassSelector, error) {
+	if !p.Function {
+		return &pseudoClassSelector{pos: p.Pos, end: p.End, ident: p.Name}, nil
+	}
+	// Arguments start after the leading colons and the function name,
+	// including the trailing '('.
+	offset := p.Pos + colons + len(p.Name) + len("(")
+	l := newLexer(p.Args)
+	var args []token
+	for {
+		t, err := l.next()
+		if err != nil {
+			var lerr *lexErr
+			if errors.As(err, &lerr) {
+				return nil, errorf(offset+lerr.last, "parsing arguments of %s: %s", p.Name, lerr.msg)
+			}
+			return nil, err
+		}
+		if t.typ == tokenEOF {
+			break
+		}
+		t.pos += offset
+		args = append(args, t)
+	}
+	return &pseudoClassSelector{pos: p.Pos, end: p.End, function: p.Name + "(", args: args}, nil
+}
@@ -0,0 +1,225 @@
+package css
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestAST(t *testing.T) {
+	s := MustParse("div > a.link[href^=https i], svg|*:nth-child(2n+1)")
+	want := []*ComplexSelector{
+		{
+			Pos: 0,
+			End: 27,
+			Compounds: []*CompoundSelector{
+				{Pos: 0, End: 3, Type: &TypeSelector{Pos: 0, End: 3, Name: "div"}},
+				{
+					Pos:        6,
+					End:        27,
+					Combinator: ">",
+					Type:       &TypeSelector{Pos: 6, End: 7, Name: "a"},
+					Subclasses: []*SubclassSelector{
+						{Pos: 7, End: 12, Class: "link"},
+						{Pos: 12, End: 27, Attribute: &AttributeSelector{
+							Pos:             12,
+							End:             27,
+							Name:            "href",
+							Matcher:         "^=",
+							Value:           "https",
+							CaseInsensitive: true,
+						}},
+					},
+				},
+			},
+		},
+		{
+			Pos: 29,
+			End: 50,
+			Compounds: []*CompoundSelector{
+				{
+					Pos:  29,
+					End:  50,
+					Type: &TypeSelector{Pos: 29, End: 34, HasNamespace: true, Namespace: "svg", Name: "*"},
+					Subclasses: []*SubclassSelector{
+						{Pos: 34, End: 50, PseudoClass: &PseudoClassSelector{
+							Pos:      34,
+							End:      50,
+							Name:     "nth-child",
+							Function: true,
+							Args:     "2n+1",
+						}},
+					},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, s.AST()); diff != "" {
+		t.Errorf("AST() returned diff (-want, +got): %s", diff)
+	}
+}
+
+func TestSpans(t *testing.T) {
+	src := ` div  >  a#b[ c = "d" ]:e( f )  ,  :host(.g) ::part(h):i  `
+	var got []string
+	span := func(pos, end int) {
+		got = append(got, src[pos:end])
+	}
+	// Parse without compiling, since the selector uses unsupported
+	// pseudo-classes.
+	list, err := parse(src)
+	if err != nil {
+		t.Fatalf("parse(%q) failed: %v", src, err)
+	}
+	for i := range list {
+		cs := newComplexSelector(&list[i])
+		span(cs.Pos, cs.End)
+		for _, c := range cs.Compounds {
+			span(c.Pos, c.End)
+			if c.Type != nil {
+				span(c.Type.Pos, c.Type.End)
+			}
+			for _, sc := range c.Subclasses {
+				span(sc.Pos, sc.End)
+			}
+			for _, pe := range c.PseudoElements {
+				span(pe.Pos, pe.End)
+				for _, pc := range pe.Classes {
+					span(pc.Pos, pc.End)
+				}
+			}
+		}
+	}
+	want := []string{
+		`div  >  a#b[ c = "d" ]:e( f )`,
+		`div`,
+		`div`,
+		`a#b[ c = "d" ]:e( f )`,
+		`a`,
+		`#b`,
+		`[ c = "d" ]`,
+		`:e( f )`,
+		`:host(.g) ::part(h):i`,
+		`:host(.g)`,
+		`:host(.g)`,
+		`::part(h):i`,
+		`::part(h):i`,
+		`:i`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("AST() returned spans with diff (-want, +got): %s", diff)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []string{
+		"a",
+		"ns|a",
+		"|a",
+		"*|*",
+		"div > a.link[href^=https i]",
+		"h1 a, h2 ~ b, h3 + p",
+		"li:nth-child(2n + 1)",
+		"li:nth-last-of-type( odd )",
+		":root:empty",
+	}
+	for _, test := range tests {
+		s := MustParse(test)
+		b, err := json.Marshal(s)
+		if err != nil {
+			t.Errorf("Marshaling %q: %v", test, err)
+			continue
+		}
+		var list []*ComplexSelector
+		if err := json.Unmarshal(b, &list); err != nil {
+			t.Errorf("Unmarshaling %q as AST: %v", test, err)
+			continue
+		}
+		if diff := cmp.Diff(s.AST(), list); diff != "" {
+			t.Errorf("Round trip of %q returned diff (-want, +got): %s", test, diff)
+		}
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	in := `<div><a href="https://foo"></a><a href="http://bar"></a><p><a href="https://spam"></a></p></div>`
+	root, err := html.Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("Parsing html: %v", err)
+	}
+	b, err := json.Marshal(MustParse("div > a[href^=https]"))
+	if err != nil {
+		t.Fatalf("Marshaling selector: %v", err)
+	}
+	var s Selector
+	if err := json.Unmarshal(b, &s); err != nil {
+		t.Fatalf("Unmarshaling selector: %v", err)
+	}
+	got := s.Select(root)
+	if len(got) != 1 || got[0].Attr[0].Val != "https://foo" {
+		t.Errorf("Unmarshaled selector returned unexpected matches: %v", got)
+	}
+
+	bad := []string{
+		`[]`,
+		`[{"pos":0,"compounds":[]}]`,
+		`[{"pos":0,"compounds":[{"pos":0,"combinator":">","type":{"name":"a"}}]}]`,
+		`[{"pos":0,"compounds":[{"pos":0,"type":{"name":"a"}},{"pos":2,"combinator":"?","type":{"name":"a"}}]}]`,
+		`[{"pos":0,"compounds":[{"pos":0,"subclasses":[{"pos":0,"id":"a","class":"b"}]}]}]`,
+		`[{"pos":0,"compounds":[{"pos":0,"subclasses":[{"pos":0,"pseudoClass":{"name":"nth-child","function":true,"args":"3+4n"}}]}]}]`,
+	}
+	for _, b := range bad {
+		var s Selector
+		if err := json.Unmarshal([]byte(b), &s); err == nil {
+			t.Errorf("Expected unmarshaling %s to fail", b)
+		}
+	}
+}
+
+func TestUnmarshalJSONErrorPos(t *testing.T) {
+	// Errors in arguments are reported at the same position as when parsing
+	// the selector, whether it has one colon or two.
+	tests := []struct {
+		sel  string
+		json string
+	}{
+		{
+			"a:not(!!)",
+			`[{"pos":0,"compounds":[{"pos":0,"type":{"name":"a"},"subclasses":[{"pos":1,"pseudoClass":{"pos":1,"name":"not","function":true,"args":"!!"}}]}]}]`,
+		},
+		{
+			"a::part(x, !!)",
+			`[{"pos":0,"compounds":[{"pos":0,"type":{"name":"a"},"pseudoElements":[{"pos":1,"name":"part","function":true,"args":"x, !!"}]}]}]`,
+		},
+		{
+			"a::slotted(b !!)",
+			`[{"pos":0,"compounds":[{"pos":0,"type":{"name":"a"},"pseudoElements":[{"pos":1,"name":"slotted","function":true,"args":"b !!"}]}]}]`,
+		},
+		{
+			"a::part(x):not(!!)",
+			`[{"pos":0,"compounds":[{"pos":0,"type":{"name":"a"},"pseudoElements":[{"pos":1,"name":"part","function":true,"args":"x","classes":[{"pos":10,"name":"not","function":true,"args":"!!"}]}]}]}]`,
+		},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.sel)
+		var want *ParseError
+		if !errors.As(err, &want) {
+			t.Errorf("Parse(%q) returned %v, want a ParseError", test.sel, err)
+			continue
+		}
+		var s Selector
+		err = json.Unmarshal([]byte(test.json), &s)
+		var got *ParseError
+		if !errors.As(err, &got) {
+			t.Errorf("Unmarshaling %s returned %v, want a ParseError", test.json, err)
+			continue
+		}
+		if got.Pos != want.Pos {
+			t.Errorf("Unmarshaling %s returned error at %d, want %d: %v", test.json, got.Pos, want.Pos, err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package css
+
+import (
+	"container/list"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// cacheSize is the number of selectors kept by the package level cache.
+const cacheSize = 128
+
+// cache is a bounded, least recently used cache of compiled selectors.
+type cache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key string
+	sel *Selector
+}
+
+func newCache(size int) *cache {
+	return &cache{size: size, ll: list.New(), items: map[string]*list.Element{}}
+}
+
+// parse returns the compiled selector for s, compiling it if it isn't in the
+// cache. Selectors that fail to compile aren't cached.
+func (c *cache) parse(s string) (*Selector, error) {
+	c.mu.Lock()
+	if e, ok := c.items[s]; ok {
+		c.ll.MoveToFront(e)
+		sel := e.Value.(*cacheEntry).sel
+		c.mu.Unlock()
+		return sel, nil
+	}
+	c.mu.Unlock()
+
+	sel, err := Parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[s]; ok {
+		// Compiled concurrently by another caller.
+		c.ll.MoveToFront(e)
+		return e.Value.(*cacheEntry).sel, nil
+	}
+	c.items[s] = c.ll.PushFront(&cacheEntry{s, sel})
+	if c.ll.Len() > c.size {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*cacheEntry).key)
+	}
+	return sel, nil
+}
+
+var selectorCache = newCache(cacheSize)
+
+// Select parses a selector and returns any matches from n. It's a convenience
+// for programs that don't want to manage Selector values.
+//
+// Compiled selectors are cached, so repeated calls with the same selector
+// string don't reparse it. Select is safe for concurrent use.
+//
+//	links, err := css.Select(doc, "a[href]")
+func Select(n *html.Node, selector string) ([]*html.Node, error) {
+	sel, err := selectorCache.parse(selector)
+	if err != nil {
+		return nil, err
+	}
+	return sel.Select(n), nil
+}
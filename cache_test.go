@@ -0,0 +1,59 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestSelect(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<p><a href="/">1</a><a>2</a></p>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		got, err := Select(root, "a[href]")
+		if err != nil {
+			t.Fatalf("Select() failed: %v", err)
+		}
+		want := []string{`<a href="/">1</a>`}
+		if diff := cmp.Diff(want, renderNodes(t, got)); diff != "" {
+			t.Errorf("Select() returned diff (-want, +got): %s", diff)
+		}
+	}
+	if _, err := Select(root, "a["); err == nil {
+		t.Errorf("Select() with invalid selector didn't return an error")
+	}
+}
+
+func TestCache(t *testing.T) {
+	c := newCache(2)
+	a1, err := c.parse("a")
+	if err != nil {
+		t.Fatalf("parse() failed: %v", err)
+	}
+	if _, err := c.parse("b"); err != nil {
+		t.Fatalf("parse() failed: %v", err)
+	}
+	if a2, _ := c.parse("a"); a2 != a1 {
+		t.Errorf("parse() didn't return cached selector")
+	}
+	// "b" is the least recently used, and should be evicted.
+	if _, err := c.parse("c"); err != nil {
+		t.Fatalf("parse() failed: %v", err)
+	}
+	if _, ok := c.items["b"]; ok {
+		t.Errorf("cache didn't evict least recently used selector")
+	}
+	if a3, _ := c.parse("a"); a3 != a1 {
+		t.Errorf("cache evicted recently used selector")
+	}
+	if _, err := c.parse("a["); err == nil {
+		t.Errorf("parse() with invalid selector didn't return an error")
+	}
+	if c.ll.Len() != 2 {
+		t.Errorf("cache holds %d entries, want 2", c.ll.Len())
+	}
+}
@@ -0,0 +1,136 @@
+// Command css-grep searches HTML files for elements matching a selector,
+// printing the location and start tag of each match. Files are scanned
+// without being parsed into a tree, so css-grep can search files much larger
+// than available memory:
+//
+//	$ css-grep 'a[href^="https://"]' crawl.html
+//	1042:88213: <a href="https://example.com">
+//
+// Each match is printed as line:offset: start tag, where offset is the byte
+// offset of the start tag within the file. When searching multiple files,
+// lines are prefixed by the file name. Only selectors supported by css.Grep
+// may be used.
+//
+// Like grep, css-grep exits with status 0 if an element matched, 1 if none
+// did, and 2 if an error occurred.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ericchiang/css"
+)
+
+const usage = `usage: css-grep [flags] <selector> [file ...]
+
+Searches HTML files, or standard input, for elements matching a selector.
+
+`
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	var (
+		count = flag.Bool("c", false, "Only print the number of matches in each file.")
+		width = flag.Int("width", 200, "Maximum width of printed start tags, or 0 for no limit.")
+	)
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	sel, err := css.Parse(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "css-grep: %v\n", err)
+		os.Exit(2)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	g := &grep{sel: sel, out: out, count: *count, width: *width}
+	files := flag.Args()[1:]
+	g.names = len(files) > 1
+
+	status := 1
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	for _, name := range files {
+		matched, err := g.file(name)
+		if err != nil {
+			out.Flush()
+			fmt.Fprintf(os.Stderr, "css-grep: %v\n", err)
+			status = 2
+			continue
+		}
+		if matched && status != 2 {
+			status = 0
+		}
+	}
+	if err := out.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "css-grep: %v\n", err)
+		status = 2
+	}
+	os.Exit(status)
+}
+
+type grep struct {
+	sel   *css.Selector
+	out   io.Writer
+	count bool
+	width int
+	// names causes output to be prefixed by file names.
+	names bool
+}
+
+// file searches the named file, or standard input for "-", reporting if any
+// element matched.
+func (g *grep) file(name string) (bool, error) {
+	if name == "-" {
+		return g.search("(standard input)", os.Stdin)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	return g.search(name, f)
+}
+
+func (g *grep) search(name string, r io.Reader) (bool, error) {
+	prefix := ""
+	if g.names {
+		prefix = name + ":"
+	}
+	n := 0
+	err := css.Grep(bufio.NewReader(r), g.sel, func(m *css.StreamMatch) bool {
+		n++
+		if !g.count {
+			fmt.Fprintf(g.out, "%s%d:%d: %s\n", prefix, m.Line, m.Offset, g.snippet(m.Raw))
+		}
+		return true
+	})
+	if err != nil {
+		return n > 0, fmt.Errorf("%s: %v", name, err)
+	}
+	if g.count {
+		fmt.Fprintf(g.out, "%s%d\n", prefix, n)
+	}
+	return n > 0, nil
+}
+
+// snippet formats a start tag on a single line, truncated to the configured
+// width.
+func (g *grep) snippet(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if rs := []rune(s); g.width > 3 && len(rs) > g.width {
+		s = string(rs[:g.width-3]) + "..."
+	}
+	return s
+}
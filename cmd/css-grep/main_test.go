@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ericchiang/css"
+)
+
+func TestGrep(t *testing.T) {
+	const doc = "<ul>\n<li class=a>one\n<li\n  class=a>two\n<li>three</ul>"
+	tests := []struct {
+		count bool
+		names bool
+		want  string
+	}{
+		{want: "2:5: <li class=a>\n3:21: <li class=a>\n"},
+		{names: true, want: "doc.html:2:5: <li class=a>\ndoc.html:3:21: <li class=a>\n"},
+		{count: true, want: "2\n"},
+		{count: true, names: true, want: "doc.html:2\n"},
+	}
+	for _, test := range tests {
+		var out strings.Builder
+		g := &grep{sel: css.MustParse("ul > .a"), out: &out, count: test.count, names: test.names, width: 80}
+		matched, err := g.search("doc.html", strings.NewReader(doc))
+		if err != nil {
+			t.Fatalf("search returned error: %v", err)
+		}
+		if !matched {
+			t.Errorf("search reported no matches")
+		}
+		if got := out.String(); got != test.want {
+			t.Errorf("search(count=%v, names=%v) returned\n%s\nwant\n%s", test.count, test.names, got, test.want)
+		}
+	}
+
+	g := &grep{sel: css.MustParse("li:first-child"), out: &strings.Builder{}}
+	if _, err := g.search("doc.html", strings.NewReader(doc)); err == nil {
+		t.Errorf("search with an unsupported selector succeeded")
+	}
+}
@@ -0,0 +1,210 @@
+// Command cssrepl loads an HTML document, then evaluates selectors typed
+// interactively against it, for developing selectors against a saved page:
+//
+//	$ cssrepl page.html
+//	> li.item
+//	2 matches
+//	[0] <li class="item">one</li>
+//	[1] <li class="item">two</li>
+//	> :ast li.item
+//	...
+//
+// Lines starting with a colon are commands. Type ":help" to list them.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ericchiang/css"
+	"golang.org/x/net/html"
+)
+
+const usage = `usage: cssrepl [flags] <file.html>
+
+Loads an HTML document, then reads selectors from standard input, printing the
+elements they match.
+
+`
+
+const help = `<selector>          print the number of matches and a snippet of each
+:explain <selector> trace how the selector matched each element
+:plan <selector>    describe how the selector is evaluated
+:ast <selector>     print the parsed selector as JSON
+:help               print this message
+:quit               exit
+`
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	var (
+		limit = flag.Int("limit", 10, "Maximum number of matches to print for each selector.")
+		width = flag.Int("width", 80, "Maximum width of printed snippets.")
+		ext   = flag.Bool("extensions", false, "Enable non-standard selector extensions, such as :role().")
+	)
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cssrepl: %v\n", err)
+		os.Exit(1)
+	}
+	root, err := html.Parse(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cssrepl: parsing %s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+
+	r := &repl{root: root, out: os.Stdout, limit: *limit, width: *width}
+	if *ext {
+		r.opts = append(r.opts, css.WithExtensions())
+	}
+	r.run(os.Stdin)
+}
+
+type repl struct {
+	root  *html.Node
+	out   io.Writer
+	limit int
+	width int
+	opts  []css.Option
+}
+
+// run reads lines from in until EOF or ":quit", evaluating each one.
+func (r *repl) run(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(r.out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(r.out)
+			return
+		}
+		if !r.eval(scanner.Text()) {
+			return
+		}
+	}
+}
+
+// eval evaluates a single line of input, reporting false if the REPL should
+// exit.
+func (r *repl) eval(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return true
+	}
+	if !strings.HasPrefix(line, ":") {
+		r.selectNodes(line)
+		return true
+	}
+
+	cmd, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch cmd {
+	case ":quit", ":q":
+		return false
+	case ":help", ":h":
+		fmt.Fprint(r.out, help)
+	case ":explain":
+		r.explain(arg)
+	case ":plan":
+		if sel := r.parse(arg); sel != nil {
+			fmt.Fprint(r.out, sel.Explain())
+		}
+	case ":ast":
+		if sel := r.parse(arg); sel != nil {
+			b, err := json.MarshalIndent(sel.AST(), "", "  ")
+			if err != nil {
+				fmt.Fprintf(r.out, "error: %v\n", err)
+				break
+			}
+			fmt.Fprintf(r.out, "%s\n", b)
+		}
+	default:
+		fmt.Fprintf(r.out, "unknown command %s, type :help for a list of commands\n", cmd)
+	}
+	return true
+}
+
+// parse compiles a selector, printing any error.
+func (r *repl) parse(s string) *css.Selector {
+	if s == "" {
+		fmt.Fprintln(r.out, "error: no selector provided")
+		return nil
+	}
+	sel, err := css.Parse(s, r.opts...)
+	if err != nil {
+		fmt.Fprintf(r.out, "error: %v\n", err)
+		return nil
+	}
+	for _, w := range sel.Warnings() {
+		fmt.Fprintf(r.out, "warning: %s at position %d\n", w.Msg, w.Pos)
+	}
+	return sel
+}
+
+func (r *repl) selectNodes(s string) {
+	sel := r.parse(s)
+	if sel == nil {
+		return
+	}
+	nodes := sel.Select(r.root)
+	switch len(nodes) {
+	case 1:
+		fmt.Fprintln(r.out, "1 match")
+	default:
+		fmt.Fprintf(r.out, "%d matches\n", len(nodes))
+	}
+	for i, n := range nodes {
+		if i == r.limit {
+			fmt.Fprintf(r.out, "... %d more\n", len(nodes)-r.limit)
+			break
+		}
+		fmt.Fprintf(r.out, "[%d] %s\n", i, r.snippet(n))
+	}
+}
+
+func (r *repl) explain(s string) {
+	sel := r.parse(s)
+	if sel == nil {
+		return
+	}
+	nodes := sel.Select(r.root)
+	if len(nodes) == 0 {
+		fmt.Fprintln(r.out, "0 matches")
+		return
+	}
+	for i, n := range nodes {
+		if i == r.limit {
+			fmt.Fprintf(r.out, "... %d more\n", len(nodes)-r.limit)
+			break
+		}
+		fmt.Fprintf(r.out, "[%d] %s\n", i, r.snippet(n))
+		fmt.Fprint(r.out, sel.ExplainMatch(n))
+	}
+}
+
+// snippet renders n on a single line, truncated to the configured width.
+func (r *repl) snippet(n *html.Node) string {
+	var b strings.Builder
+	if err := html.Render(&b, n); err != nil {
+		return fmt.Sprintf("<%s> (%v)", n.Data, err)
+	}
+	s := strings.Join(strings.Fields(b.String()), " ")
+	if rs := []rune(s); r.width > 3 && len(rs) > r.width {
+		s = string(rs[:r.width-3]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestREPL(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<ul><li class="item">one</li><li class="item">two</li><li>a much longer item</li></ul>`))
+	if err != nil {
+		t.Fatalf("parsing html: %v", err)
+	}
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"li.item\n", "> 2 matches\n[0] <li class=\"item\">one</li>\n[1] <li class=\"item\">two</li>\n> \n"},
+		{"ul\n", "> 1 match\n[0] <ul><li class=\"item\">one</li><li clas...\n> \n"},
+		{"li\n", "> 3 matches\n[0] <li class=\"item\">one</li>\n[1] <li class=\"item\">two</li>\n... 1 more\n> \n"},
+		{"p\n:quit\nli\n", "> 0 matches\n> "},
+		{"li[\n", "> error: "},
+		{":nope\n", "> unknown command :nope"},
+		{":plan ul > li\n", "> ul > li\n\tmatch: li\n"},
+		{":ast ul\n", "> [\n  {"},
+		{":explain ul > .item:first-child\n", "> [0] <li class=\"item\">one</li>\nul > .item:first-child: matched\n\t.item:first-child against <li>: matched\n\t> ul against <ul>: matched\n> \n"},
+	}
+	for _, test := range tests {
+		var out strings.Builder
+		r := &repl{root: root, out: &out, limit: 2, width: 40}
+		r.run(strings.NewReader(test.input))
+		if !strings.HasPrefix(out.String(), test.want) {
+			t.Errorf("input %q returned\n%s\nwant prefix\n%s", test.input, out.String(), test.want)
+		}
+	}
+}
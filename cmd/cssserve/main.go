@@ -0,0 +1,248 @@
+// Command cssserve exposes the selector engine as an HTTP JSON service, for
+// programs that can't link Go code.
+//
+// Clients POST a JSON request to /select holding a document and the selectors
+// to evaluate against it:
+//
+//	$ cssserve -addr localhost:8080 &
+//	$ curl -d '{"html": "<a href=/x>x</a>", "selectors": ["a[href]"]}' localhost:8080/select
+//	{"results":[{"selector":"a[href]","matches":[{"tag":"a","attrs":{"href":"/x"},"text":"x","html":"<a href=\"/x\">x</a>"}]}]}
+//
+// Instead of "html", requests may provide a "url" to fetch the document from.
+// Because this lets clients make the server issue arbitrary requests, URLs
+// are only accepted when the server is run with -allow-urls.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ericchiang/css"
+	"github.com/ericchiang/css/internal/nodetext"
+	"golang.org/x/net/html"
+)
+
+func main() {
+	var (
+		addr      = flag.String("addr", "localhost:8080", "Address to listen on.")
+		allowURLs = flag.Bool("allow-urls", false, "Allow requests to provide a URL to fetch the document from.")
+		maxBytes  = flag.Int64("max-bytes", 10<<20, "Maximum size of a request body or fetched document.")
+		ext       = flag.Bool("extensions", false, "Enable non-standard selector extensions, such as :role().")
+	)
+	flag.Parse()
+
+	s := &server{
+		allowURLs: *allowURLs,
+		maxBytes:  *maxBytes,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+	if *ext {
+		s.opts = append(s.opts, css.WithExtensions())
+	}
+	// Request bodies are limited to -max-bytes by ServeHTTP. The write
+	// timeout covers handling the request, so it must allow for fetching a
+	// document within the client's timeout.
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      2 * s.client.Timeout,
+		IdleTimeout:       2 * time.Minute,
+		MaxHeaderBytes:    64 << 10,
+	}
+	log.Printf("listening on %s", *addr)
+	log.Fatal(srv.ListenAndServe())
+}
+
+// request is the body of a POST to /select.
+type request struct {
+	HTML      string   `json:"html,omitempty"`
+	URL       string   `json:"url,omitempty"`
+	Selectors []string `json:"selectors"`
+}
+
+// response is returned for a successful request, holding a result for each
+// selector in the order they were requested.
+type response struct {
+	Results []result `json:"results"`
+}
+
+type result struct {
+	Selector string  `json:"selector"`
+	Matches  []match `json:"matches"`
+}
+
+type match struct {
+	Tag   string            `json:"tag"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+	Text  string            `json:"text"`
+	HTML  string            `json:"html"`
+}
+
+// errorResponse is returned for failed requests, along with a non-200 status
+// code.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// httpError is an error with an associated status code.
+type httpError struct {
+	code int
+	msg  string
+}
+
+func (e *httpError) Error() string {
+	return e.msg
+}
+
+func errorf(code int, format string, v ...any) error {
+	return &httpError{code, fmt.Sprintf(format, v...)}
+}
+
+type server struct {
+	allowURLs bool
+	maxBytes  int64
+	client    *http.Client
+	opts      []css.Option
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/select" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, &errorResponse{"method must be POST"})
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxBytes)
+	resp, err := s.handle(r)
+	if err != nil {
+		code := http.StatusInternalServerError
+		var he *httpError
+		if errors.As(err, &he) {
+			code = he.code
+		}
+		writeJSON(w, code, &errorResponse{err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *server) handle(r *http.Request) (*response, error) {
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid request: %v", err)
+	}
+	if len(req.Selectors) == 0 {
+		return nil, errorf(http.StatusBadRequest, "no selectors provided")
+	}
+
+	// Compile every selector before fetching the document, so invalid
+	// requests fail fast.
+	sels := make([]*css.Selector, len(req.Selectors))
+	for i, raw := range req.Selectors {
+		sel, err := css.Parse(raw, s.opts...)
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, "invalid selector %q: %v", raw, err)
+		}
+		sels[i] = sel
+	}
+
+	root, err := s.document(r, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &response{Results: make([]result, len(sels))}
+	for i, sel := range sels {
+		res := result{Selector: req.Selectors[i], Matches: []match{}}
+		for _, n := range sel.Select(root) {
+			m, err := newMatch(n)
+			if err != nil {
+				return nil, err
+			}
+			res.Matches = append(res.Matches, m)
+		}
+		resp.Results[i] = res
+	}
+	return resp, nil
+}
+
+// document parses the document provided by a request, fetching it if needed.
+func (s *server) document(r *http.Request, req *request) (*html.Node, error) {
+	switch {
+	case req.HTML != "" && req.URL != "":
+		return nil, errorf(http.StatusBadRequest, "only one of html and url may be provided")
+	case req.URL != "":
+		if !s.allowURLs {
+			return nil, errorf(http.StatusForbidden, "fetching urls is disabled")
+		}
+		return s.fetch(r, req.URL)
+	}
+	root, err := html.Parse(strings.NewReader(req.HTML))
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "parsing html: %v", err)
+	}
+	return root, nil
+}
+
+func (s *server) fetch(r *http.Request, url string) (*html.Node, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, errorf(http.StatusBadRequest, "url must be http or https: %q", url)
+	}
+	fetchReq, err := http.NewRequestWithContext(r.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid url: %v", err)
+	}
+	resp, err := s.client.Do(fetchReq)
+	if err != nil {
+		return nil, errorf(http.StatusBadGateway, "fetching url: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorf(http.StatusBadGateway, "fetching url: %s", resp.Status)
+	}
+	root, err := html.Parse(io.LimitReader(resp.Body, s.maxBytes))
+	if err != nil {
+		return nil, errorf(http.StatusBadGateway, "parsing html: %v", err)
+	}
+	return root, nil
+}
+
+func newMatch(n *html.Node) (match, error) {
+	m := match{Tag: n.Data, Text: nodetext.Text(n)}
+	if len(n.Attr) > 0 {
+		m.Attrs = map[string]string{}
+		for _, a := range n.Attr {
+			key := a.Key
+			if a.Namespace != "" {
+				key = a.Namespace + ":" + a.Key
+			}
+			m.Attrs[key] = a.Val
+		}
+	}
+	var b strings.Builder
+	if err := html.Render(&b, n); err != nil {
+		return match{}, fmt.Errorf("rendering element: %v", err)
+	}
+	m.HTML = b.String()
+	return m, nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("writing response: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestServer(t *testing.T) {
+	doc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<p class="a">fetched</p>`))
+	}))
+	defer doc.Close()
+
+	tests := []struct {
+		name      string
+		allowURLs bool
+		body      string
+		wantCode  int
+		want      any
+	}{
+		{
+			name:     "html",
+			body:     `{"html": "<ul><li id=a>one</li><li>two <b>2</b></li></ul>", "selectors": ["li", "p"]}`,
+			wantCode: http.StatusOK,
+			want: &response{Results: []result{
+				{Selector: "li", Matches: []match{
+					{Tag: "li", Attrs: map[string]string{"id": "a"}, Text: "one", HTML: `<li id="a">one</li>`},
+					{Tag: "li", Text: "two 2", HTML: `<li>two <b>2</b></li>`},
+				}},
+				{Selector: "p", Matches: []match{}},
+			}},
+		},
+		{
+			name:      "url",
+			allowURLs: true,
+			body:      `{"url": "` + doc.URL + `", "selectors": [".a"]}`,
+			wantCode:  http.StatusOK,
+			want: &response{Results: []result{
+				{Selector: ".a", Matches: []match{
+					{Tag: "p", Attrs: map[string]string{"class": "a"}, Text: "fetched", HTML: `<p class="a">fetched</p>`},
+				}},
+			}},
+		},
+		{
+			name:     "url disabled",
+			body:     `{"url": "` + doc.URL + `", "selectors": [".a"]}`,
+			wantCode: http.StatusForbidden,
+			want:     &errorResponse{"fetching urls is disabled"},
+		},
+		{
+			name:     "invalid selector",
+			body:     `{"html": "", "selectors": ["a["]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "no selectors",
+			body:     `{"html": "<p>"}`,
+			wantCode: http.StatusBadRequest,
+			want:     &errorResponse{"no selectors provided"},
+		},
+		{
+			name:     "invalid json",
+			body:     `{`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &server{allowURLs: test.allowURLs, maxBytes: 1 << 20, client: http.DefaultClient}
+			req := httptest.NewRequest(http.MethodPost, "/select", strings.NewReader(test.body))
+			rr := httptest.NewRecorder()
+			s.ServeHTTP(rr, req)
+			if rr.Code != test.wantCode {
+				t.Fatalf("status code = %d, want %d: %s", rr.Code, test.wantCode, rr.Body)
+			}
+			if test.want == nil {
+				return
+			}
+			var got any
+			switch test.want.(type) {
+			case *response:
+				got = &response{}
+			case *errorResponse:
+				got = &errorResponse{}
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), got); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("response returned diff (-want, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestServerMethod(t *testing.T) {
+	s := &server{maxBytes: 1 << 20}
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/select", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET returned status code %d, want %d", rr.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,22 @@
+// Command csswasm exposes the selector engine to JavaScript when compiled to
+// WebAssembly, for browser based tooling such as selector playgrounds:
+//
+//	$ GOOS=js GOARCH=wasm go build -o css.wasm github.com/ericchiang/css/cmd/csswasm
+//	$ cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" .
+//
+// After the module is instantiated with wasm_exec.js, it defines a global css
+// object:
+//
+//	const go = new Go();
+//	const {instance} = await WebAssembly.instantiateStreaming(fetch("css.wasm"), go.importObject);
+//	go.run(instance);
+//
+//	css.parse("a[href^=https]");
+//	// {selector: "a[href^=\"https\"]", ast: [...], error: null}
+//	css.select("li.item", "<ul><li class=item>one</li></ul>");
+//	// {matches: [{tag: "li", text: "one", html: "<li class=\"item\">one</li>", path: "..."}], error: null}
+//
+// Both functions accept an optional final argument of options, currently only
+// {extensions: true} to enable WithExtensions. Errors are reported through
+// the error property rather than thrown.
+package main
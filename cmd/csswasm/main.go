@@ -0,0 +1,87 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"syscall/js"
+
+	"github.com/ericchiang/css"
+	"github.com/ericchiang/css/internal/nodetext"
+	"golang.org/x/net/html"
+)
+
+func main() {
+	js.Global().Set("css", js.ValueOf(map[string]any{
+		"parse":  js.FuncOf(parseFunc),
+		"select": js.FuncOf(selectFunc),
+	}))
+	// Block forever so the functions remain callable.
+	select {}
+}
+
+// parseFunc implements css.parse(selector, [options]).
+func parseFunc(this js.Value, args []js.Value) any {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return errorResult("parse: expected a selector string")
+	}
+	sel, err := css.Parse(args[0].String(), options(args, 1)...)
+	if err != nil {
+		return errorResult(err.Error())
+	}
+	b, err := json.Marshal(sel.AST())
+	if err != nil {
+		return errorResult(err.Error())
+	}
+	return map[string]any{
+		"selector": sel.String(),
+		"ast":      js.Global().Get("JSON").Call("parse", string(b)),
+		"error":    nil,
+	}
+}
+
+// selectFunc implements css.select(selector, html, [options]).
+func selectFunc(this js.Value, args []js.Value) any {
+	if len(args) < 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		return errorResult("select: expected a selector and an HTML string")
+	}
+	sel, err := css.Parse(args[0].String(), options(args, 2)...)
+	if err != nil {
+		return errorResult(err.Error())
+	}
+	root, err := html.Parse(strings.NewReader(args[1].String()))
+	if err != nil {
+		return errorResult(err.Error())
+	}
+	matches := []any{}
+	for _, n := range sel.Select(root) {
+		var b strings.Builder
+		if err := html.Render(&b, n); err != nil {
+			return errorResult(err.Error())
+		}
+		matches = append(matches, map[string]any{
+			"tag":  n.Data,
+			"text": nodetext.Text(n),
+			"html": b.String(),
+			"path": css.Path(n),
+		})
+	}
+	return map[string]any{"matches": matches, "error": nil}
+}
+
+// options converts the optional options object at args[i] to parse options.
+func options(args []js.Value, i int) []css.Option {
+	if len(args) <= i || args[i].Type() != js.TypeObject {
+		return nil
+	}
+	var opts []css.Option
+	if args[i].Get("extensions").Truthy() {
+		opts = append(opts, css.WithExtensions())
+	}
+	return opts
+}
+
+func errorResult(msg string) map[string]any {
+	return map[string]any{"error": msg}
+}
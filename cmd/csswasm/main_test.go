@@ -0,0 +1,52 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"syscall/js"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	got := js.ValueOf(selectFunc(js.Undefined(), []js.Value{
+		js.ValueOf("li.item"),
+		js.ValueOf("<ul><li class=item>one</li><li>two</li></ul>"),
+	}))
+	if err := got.Get("error"); !err.IsNull() {
+		t.Fatalf("select returned error: %s", err)
+	}
+	matches := got.Get("matches")
+	if n := matches.Length(); n != 1 {
+		t.Fatalf("select returned %d matches, want 1", n)
+	}
+	m := matches.Index(0)
+	if got, want := m.Get("html").String(), `<li class="item">one</li>`; got != want {
+		t.Errorf("match html = %q, want %q", got, want)
+	}
+	if got, want := m.Get("path").String(), "html > body > ul > li.item"; got != want {
+		t.Errorf("match path = %q, want %q", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	got := js.ValueOf(parseFunc(js.Undefined(), []js.Value{js.ValueOf("a  >  b")}))
+	if err := got.Get("error"); !err.IsNull() {
+		t.Fatalf("parse returned error: %s", err)
+	}
+	if got, want := got.Get("selector").String(), "a > b"; got != want {
+		t.Errorf("parse selector = %q, want %q", got, want)
+	}
+	if n := got.Get("ast").Length(); n != 1 {
+		t.Errorf("parse ast has %d selectors, want 1", n)
+	}
+
+	got = js.ValueOf(parseFunc(js.Undefined(), []js.Value{js.ValueOf(":role(button)")}))
+	if err := got.Get("error"); err.Type() != js.TypeString {
+		t.Errorf("parse without extensions returned error %s, want string", err)
+	}
+	opts := js.ValueOf(map[string]any{"extensions": true})
+	got = js.ValueOf(parseFunc(js.Undefined(), []js.Value{js.ValueOf(":role(button)"), opts}))
+	if err := got.Get("error"); !err.IsNull() {
+		t.Errorf("parse with extensions returned error: %s", err)
+	}
+}
@@ -0,0 +1,141 @@
+package css
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Combinator relates an element to other elements, for use as a custom
+// combinator registered with WithCombinator. Matching is performed right to
+// left: n is an element matched by the compound selector to the right of the
+// combinator, and the combinator calls yield for each element that the
+// compound selector to its left should be tested against, stopping if yield
+// returns false.
+//
+// For example, the descendant combinator yields each ancestor of n.
+type Combinator func(n *html.Node, yield func(n *html.Node) bool)
+
+// WithCombinator registers a custom combinator, which can be used in
+// selectors as "a /name/ b". Names are ASCII case-insensitive. For example, a
+// combinator relating a <label> to the control it labels:
+//
+//	labelFor := func(n *html.Node, yield func(n *html.Node) bool) {
+//		// Yield <label> elements whose "for" attribute is n's id.
+//	}
+//	sel, err := css.Parse("label.required /for/ input", css.WithCombinator("for", labelFor))
+//
+// Custom combinators aren't scoped to the node being selected from, and may
+// yield any element.
+func WithCombinator(name string, c Combinator) Option {
+	return func(o *options) {
+		if o.combinators == nil {
+			o.combinators = map[string]Combinator{}
+		}
+		o.combinators[strings.ToLower(name)] = c
+	}
+}
+
+// customCombinator matches elements related by a registered Combinator.
+type customCombinator struct {
+	m    *compoundSelectorMatcher
+	name string
+	fn   Combinator
+}
+
+func (c *customCombinator) match(s *state, n *html.Node, next func(n *html.Node) bool) bool {
+	found := false
+	c.fn(n, func(p *html.Node) bool {
+		if p == nil || !isElement(p) {
+			return true
+		}
+		if s.matchCompound(c.m, c.name, p) && next(p) {
+			found = true
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// reverseCombinator is the non-standard "^" combinator, the reverse of the
+// descendant combinator. "a ^ div" matches div elements that contain an a
+// element.
+type reverseCombinator struct {
+	m *compoundSelectorMatcher
+}
+
+func (c *reverseCombinator) match(s *state, n *html.Node, next func(n *html.Node) bool) bool {
+	for d := firstElementChild(n); d != nil; d = nextElementSibling(d) {
+		if s.matchCompound(c.m, "^", d) && next(d) {
+			return true
+		}
+		if c.match(s, d, next) {
+			return true
+		}
+	}
+	return false
+}
+
+// combinator compiles a combinator string, as stored by the parser, for the
+// compound selector to its left.
+func (c *compiler) combinator(pos int, comb string, m *compoundSelectorMatcher) combinator {
+	switch comb {
+	case "":
+		return &descendantCombinator{m}
+	case ">":
+		return &childCombinator{m}
+	case "+":
+		return &adjacentCombinator{m}
+	case "~":
+		return &siblingCombinator{m}
+	case "^":
+		if !c.opts.extensions {
+			c.errorf(pos, "combinator ^ requires extensions to be enabled")
+			return nil
+		}
+		return &reverseCombinator{m}
+	}
+	if isCustomCombinator(comb) {
+		name := strings.ToLower(strings.Trim(comb, "/"))
+		fn, ok := c.opts.combinators[name]
+		if !ok {
+			if c.opts.report != nil {
+				c.gapf(pos, GapCombinator, comb, "unknown combinator never matches: %s", comb)
+				return neverCombinator{}
+			}
+			c.errorf(pos, "unknown combinator: %s", comb)
+			return nil
+		}
+		return &customCombinator{m, comb, fn}
+	}
+	if c.opts.report != nil {
+		c.gapf(pos, GapCombinator, comb, "unsupported combinator never matches: %s", comb)
+		return neverCombinator{}
+	}
+	c.errorf(pos, "unexpected combinator: %s", comb)
+	return nil
+}
+
+// isCustomCombinator reports if comb is a custom combinator, such as "/for/".
+func isCustomCombinator(comb string) bool {
+	return len(comb) > 2 && strings.HasPrefix(comb, "/") && strings.HasSuffix(comb, "/")
+}
+
+// unbounded reports if any selector in the list can relate an element to
+// elements other than its ancestors and previous siblings, such as through
+// :has(), :contains(), "^", or a custom combinator.
+func (s *Selector) unbounded() bool {
+	if s.relational {
+		return true
+	}
+	for _, sel := range s.s {
+		for _, c := range sel.combinators {
+			switch c.(type) {
+			case *reverseCombinator, *customCombinator:
+				return true
+			}
+		}
+	}
+	return false
+}
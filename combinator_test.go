@@ -0,0 +1,120 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestReverseCombinator(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<div id="a"><p><a href="#">1</a></p></div>
+<div id="b"><p>2</p></div>
+<div id="c"><div id="d"><a>3</a></div></div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{"a ^ div", []string{"a", "c", "d"}},
+		{"a[href] ^ div", []string{"a"}},
+		{"a ^ div > div", []string{"d"}},
+		{"a ^ p ^ div", []string{"a"}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel, WithExtensions())
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		var got []string
+		for _, n := range s.Select(root) {
+			id, _ := attr(n, "id")
+			got = append(got, id)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+		if got := s.String(); got != test.sel {
+			t.Errorf("Parse(%q).String() = %q", test.sel, got)
+		}
+	}
+
+	if _, err := Parse("a ^ div"); err == nil {
+		t.Errorf("Parse() without WithExtensions() accepted the ^ combinator")
+	}
+}
+
+func TestCustomCombinator(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<label for="name" class="required">Name</label><input id="name">
+<label for="email">Email</label><input id="email">
+<input id="phone">`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	labelFor := func(n *html.Node, yield func(n *html.Node) bool) {
+		id, ok := attr(n, "id")
+		if !ok {
+			return
+		}
+		for _, l := range MustParse("label").Select(treeRoot(n)) {
+			if v, _ := attr(l, "for"); v == id && !yield(l) {
+				return
+			}
+		}
+	}
+	opt := WithCombinator("For", labelFor)
+
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{"label /for/ input", []string{`<input id="name"/>`, `<input id="email"/>`}},
+		{"label.required /FOR/ input", []string{`<input id="name"/>`}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel, opt)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		got := renderNodes(t, s.Select(root))
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+		if _, err := Parse(s.String(), opt); err != nil {
+			t.Errorf("Parse(%q) failed to reparse serialized selector %q: %v", test.sel, s.String(), err)
+		}
+	}
+
+	for _, sel := range []string{"label /for/ input", "label /for input", "label // input"} {
+		if _, err := Parse(sel); err == nil {
+			t.Errorf("Parse(%q) without a registered combinator succeeded", sel)
+		}
+	}
+
+	s := MustParse("label.missing /for/ input", opt)
+	phone := MustParse("#phone").Select(root)[0]
+	if got := s.WhyNot(phone); got == "" {
+		t.Errorf("WhyNot() returned an empty string for an element that doesn't match")
+	}
+
+	features, err := Features("label /for/ input")
+	if err != nil {
+		t.Fatalf("Features() failed: %v", err)
+	}
+	want := []Feature{
+		{"type selector", SpecSelectors3, true},
+		{"/for/ combinator", SpecExtension, false},
+	}
+	if diff := cmp.Diff(want, features); diff != "" {
+		t.Errorf("Features() returned diff (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,62 @@
+package css
+
+import (
+	"strings"
+)
+
+// Comparator compiles the argument of a named attribute comparator into a
+// function that reports if an attribute value matches. For the selector
+// "[data-price:gt(100)]", a comparator registered as "gt" is called with the
+// argument "100".
+//
+// Arguments are passed as written in the selector, with surrounding
+// whitespace removed, so quoted strings keep their quotes. Comparators are
+// called once by Parse, and returning an error causes Parse to fail.
+type Comparator func(arg string) (func(val string) bool, error)
+
+// WithComparator registers a named attribute comparator, which can be used in
+// selectors as "[attr:name(arg)]". Comparators are a non-standard extension,
+// useful for domain specific matching that can't be expressed by the standard
+// attribute matchers:
+//
+//	gt := func(arg string) (func(val string) bool, error) {
+//		min, err := strconv.ParseFloat(arg, 64)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return func(val string) bool {
+//			f, err := strconv.ParseFloat(val, 64)
+//			return err == nil && f > min
+//		}, nil
+//	}
+//	sel, err := css.Parse("[data-price:gt(100)]", css.WithComparator("gt", gt))
+//
+// Names are ASCII case-insensitive. Comparators aren't called for elements
+// that don't have the attribute. With the "i" modifier, as in
+// "[data-name:prefix(ab) i]", attribute values are lowercased before being
+// passed to the comparator's function, while the argument is passed as
+// written.
+func WithComparator(name string, c Comparator) Option {
+	return func(o *options) {
+		if o.comparators == nil {
+			o.comparators = map[string]Comparator{}
+		}
+		o.comparators[strings.ToLower(name)] = c
+	}
+}
+
+// comparator compiles an attribute selector that uses a named comparator.
+func (c *compiler) comparator(s *attributeSelector) func(val string) bool {
+	name := strings.TrimPrefix(s.matcher, ":")
+	cmp, ok := c.opts.comparators[strings.ToLower(name)]
+	if !ok {
+		c.errorf(s.pos, "unknown attribute comparator: %s()", name)
+		return nil
+	}
+	fn, err := cmp(s.val)
+	if err != nil {
+		c.errorf(s.pos, "attribute comparator %s(%s) failed: %v", name, s.val, err)
+		return nil
+	}
+	return fn
+}
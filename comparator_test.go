@@ -0,0 +1,103 @@
+package css
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestComparator(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<li data-price="50">a</li>
+<li data-price="150">b</li>
+<li data-price="Unknown">c</li>
+<li>d</li>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	gt := func(arg string) (func(val string) bool, error) {
+		min, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, err
+		}
+		return func(val string) bool {
+			f, err := strconv.ParseFloat(val, 64)
+			return err == nil && f > min
+		}, nil
+	}
+	var args []string
+	record := func(arg string) (func(val string) bool, error) {
+		args = append(args, arg)
+		return func(val string) bool { return true }, nil
+	}
+	prefix := func(arg string) (func(val string) bool, error) {
+		return func(val string) bool { return strings.HasPrefix(val, arg) }, nil
+	}
+	opts := []Option{WithComparator("gt", gt), WithComparator("Record", record), WithComparator("prefix", prefix)}
+
+	tests := []struct {
+		sel  string
+		want []string
+		str  string
+	}{
+		{"li[data-price:gt(100)]", []string{`<li data-price="150">b</li>`}, `li[data-price:gt(100)]`},
+		{"li[data-price:GT( 10 )]", []string{`<li data-price="50">a</li>`, `<li data-price="150">b</li>`}, `li[data-price:GT(10)]`},
+		{`[data-price:record("a b", c)]`, []string{
+			`<li data-price="50">a</li>`,
+			`<li data-price="150">b</li>`,
+			`<li data-price="Unknown">c</li>`,
+		}, `[data-price:record("a b", c)]`},
+		{"li[data-price:prefix(unk)]", []string{}, `li[data-price:prefix(unk)]`},
+		// Attribute values are lowercased by the "i" modifier.
+		{"li[data-price:prefix(unk) i]", []string{`<li data-price="Unknown">c</li>`}, `li[data-price:prefix(unk) i]`},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel, opts...)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		got := renderNodes(t, s.Select(root))
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+		if got := s.String(); got != test.str {
+			t.Errorf("Parse(%q).String() = %q, want %q", test.sel, got, test.str)
+		}
+	}
+	if want := []string{`"a b", c`}; !cmp.Equal(args, want) {
+		t.Errorf("Comparator called with %q, want %q", args, want)
+	}
+
+	errTests := []struct {
+		sel  string
+		opts []Option
+	}{
+		{"[data-price:gt(100)]", nil},
+		{"[data-price:lt(100)]", opts},
+		{"[data-price:gt(abc)]", opts},
+		{"[data-price:gt(100) x]", opts},
+		{"[data-price:gt]", opts},
+	}
+	for _, test := range errTests {
+		_, err := Parse(test.sel, test.opts...)
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("Parse(%q) returned %v, want *ParseError", test.sel, err)
+		}
+	}
+
+	features, err := Features("[data-price:gt(100)]")
+	if err != nil {
+		t.Fatalf("Features() failed: %v", err)
+	}
+	want := []Feature{{"[attr:gt()]", SpecExtension, false}}
+	if diff := cmp.Diff(want, features); diff != "" {
+		t.Errorf("Features() returned diff (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,60 @@
+package css
+
+import (
+	"golang.org/x/net/html"
+)
+
+// Complement returns the elements in the tree rooted at n that don't match
+// the selector, in document order. If base is non-nil, only elements matched
+// by base are considered, answering questions such as "which images don't
+// have alt text" in a single pass:
+//
+//	imgs := css.MustParse("img")
+//	missing := css.MustParse("[alt]").Complement(root, imgs)
+//
+// Like Select, n itself is considered, and subtrees skipped by WithExclude
+// aren't, whether the option was passed to the selector or to base. If the
+// selector has pseudo-elements, elements are excluded if they're among the
+// elements Select would return.
+func (s *Selector) Complement(n *html.Node, base *Selector) []*html.Node {
+	complement := []*html.Node{}
+	if !validRoot(n) {
+		return complement
+	}
+	st := s.newState(n, &MatchContext{})
+	matches := func(e *html.Node) bool { return s.match(st, e) }
+	if s.pseudo {
+		selected := map[*html.Node]bool{}
+		for _, e := range s.Select(n) {
+			selected[e] = true
+		}
+		matches = func(e *html.Node) bool { return selected[e] }
+	}
+	if base != nil {
+		for _, e := range base.Select(n) {
+			if !st.excluded(e) && !matches(e) {
+				complement = append(complement, e)
+			}
+		}
+		return complement
+	}
+	st.walk(n, func(e *html.Node) {
+		if !matches(e) {
+			complement = append(complement, e)
+		}
+	})
+	return complement
+}
+
+// excluded reports if n is within a subtree pruned from selection.
+func (s *state) excluded(n *html.Node) bool {
+	for ; n != nil; n = n.Parent {
+		if s.pruned(n) {
+			return true
+		}
+		if n == s.root {
+			break
+		}
+	}
+	return false
+}
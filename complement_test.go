@@ -0,0 +1,87 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestComplement(t *testing.T) {
+	tests := []struct {
+		sel  string
+		base string
+		opts []Option
+		in   string
+		want []string
+	}{
+		{
+			sel:  "[alt]",
+			base: "img",
+			in:   `<img id="a" alt="A"><img id="b"><p><img id="c" alt=""><img id="d"></p>`,
+			want: []string{`<img id="b"/>`, `<img id="d"/>`},
+		},
+		{
+			sel:  "html, head, body, p",
+			in:   `<p>a</p><span>b</span><p><i>c</i></p>`,
+			want: []string{`<span>b</span>`, `<i>c</i>`},
+		},
+		{
+			sel:  "*",
+			base: "p",
+			in:   `<p>a</p>`,
+			want: []string{},
+		},
+		{
+			sel:  "img[alt]",
+			base: "img",
+			opts: []Option{WithExclude(MustParse("nav"))},
+			in:   `<nav><img id="a"></nav><img id="b">`,
+			want: []string{`<img id="b"/>`},
+		},
+		{
+			sel:  "p::before, html, head, body",
+			opts: []Option{WithUnknownPseudo(UnknownPseudoNeverMatch)},
+			in:   `<p>a</p>`,
+			want: []string{`<p>a</p>`},
+		},
+	}
+	for _, test := range tests {
+		root, err := html.Parse(strings.NewReader(test.in))
+		if err != nil {
+			t.Fatalf("html.Parse(%q) failed: %v", test.in, err)
+		}
+		sel, err := Parse(test.sel, test.opts...)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		var base *Selector
+		if test.base != "" {
+			base = MustParse(test.base)
+		}
+		got := renderNodes(t, sel.Complement(root, base))
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q).Complement(%q, %q) returned diff (-want, +got): %s", test.sel, test.in, test.base, diff)
+		}
+	}
+}
+
+func TestComplementPartitions(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<div><p class="x">a</p><p>b<span class="x">c</span></p></div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	for _, test := range selectorTests {
+		sel, err := Parse(test.sel)
+		if err != nil {
+			continue
+		}
+		all := MustParse("*").Select(root)
+		got := len(sel.Select(root)) + len(sel.Complement(root, nil))
+		if got != len(all) {
+			t.Errorf("Parse(%q): Select and Complement returned %d elements, want %d", test.sel, got, len(all))
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package css
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+// TestConcurrentSelect exercises a shared Selector from multiple goroutines.
+// Run with -race to detect shared state used during matching.
+func TestConcurrentSelect(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<ul class="a">
+	<li><a href="https://example.com">1</a></li>
+	<li class="b"><a href="/2" title="Two">2</a></li>
+	<li><a href="/3">3</a></li>
+</ul>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	var mu sync.Mutex
+	observed := 0
+	sel := MustParse(
+		`ul.a > li:nth-child(odd) a, li.b + li, [title="two" i], :role(link)`,
+		WithExtensions(),
+		WithObserver(ObserverFunc(func(*Stats) {
+			mu.Lock()
+			observed++
+			mu.Unlock()
+		})),
+	)
+	want := renderNodes(t, sel.Select(root))
+
+	const goroutines = 8
+	const iterations = 50
+	results := make([][]*html.Node, goroutines)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				results[i] = sel.Select(root)
+				sel.SelectDetailed(root)
+				sel.WhyNot(root.FirstChild)
+				sel.ExplainMatch(root.FirstChild)
+				_ = sel.String()
+				sel.Hash()
+				NewSession(root).Select(sel)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i, nodes := range results {
+		if diff := cmp.Diff(want, renderNodes(t, nodes)); diff != "" {
+			t.Errorf("goroutine %d returned diff (-want, +got): %s", i, diff)
+		}
+	}
+	if want := 1 + goroutines*iterations*2; observed != want {
+		t.Errorf("observer called %d times, want %d", observed, want)
+	}
+}
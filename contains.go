@@ -0,0 +1,38 @@
+package css
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// contains compiles the :contains() extension, which matches elements whose
+// text, including that of their descendants, contains the argument. The
+// argument is usually quoted, but may be an identifier. Matching is
+// case-sensitive.
+//
+// https://api.jquery.com/contains-selector/
+func (c *compiler) contains(s *pseudoClassSelector) func(n *html.Node) bool {
+	p := newParserFromTokens(s.args)
+	p.skipWhitespace()
+	t, err := p.next()
+	if err != nil {
+		c.errorf(s.pos, "failed to parse :contains() argument: %v", err)
+		return nil
+	}
+	if t.typ != tokenString && t.typ != tokenIdent {
+		c.errorf(s.pos, "expected text, got %s", t)
+		return nil
+	}
+	if err := p.expectWhitespaceOrEOF(); err != nil {
+		c.errorf(s.pos, "failed to parse :contains() argument: %v", err)
+		return nil
+	}
+	text := t.s
+	// Matches depend on the text of descendants, which a mutation anywhere in
+	// the subtree can change, so incremental updates can't be scoped.
+	c.relational = true
+	return func(n *html.Node) bool {
+		return strings.Contains(textContent(n), text)
+	}
+}
@@ -0,0 +1,54 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestContains(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<div id="card"><h2 id="title">Price</h2><p id="price">Only <b id="amount">$10</b> today</p></div>
+<p id="other">price on request</p>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{`p:contains("$10")`, []string{"price"}},
+		{`body :contains("Only $10 today")`, []string{"card", "price"}},
+		{`h2:contains(Price)`, []string{"title"}},
+		{`p:contains('price')`, []string{"other"}},
+		{`div:contains("Price") > p`, []string{"price"}},
+		{`b:contains("today")`, nil},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel, WithExtensions())
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		var got []string
+		for _, n := range s.Select(root) {
+			id, _ := attr(n, "id")
+			got = append(got, id)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+
+	for _, sel := range []string{":contains()", ":contains(1)", `:contains("a" "b")`} {
+		if _, err := Parse(sel, WithExtensions()); err == nil {
+			t.Errorf("Parse(%q) didn't return an error", sel)
+		}
+	}
+	if _, err := Parse(`:contains("a")`); err == nil {
+		t.Errorf("Parse() of :contains() without WithExtensions() didn't return an error")
+	}
+}
@@ -0,0 +1,182 @@
+package css
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// DocumentStats summarizes a document, allowing EstimateCost to predict how
+// selective each component of a selector is.
+type DocumentStats struct {
+	// Elements is the number of elements in the document.
+	Elements int
+	// Depth is the average number of ancestor elements of each element.
+	Depth float64
+	// Children is the average number of child elements of elements that
+	// have children.
+	Children float64
+	// Tags, Classes, and IDs count the elements with each tag name, class,
+	// and id.
+	Tags    map[string]int
+	Classes map[string]int
+	IDs     map[string]int
+}
+
+// NewDocumentStats computes statistics for the tree rooted at n. Computing
+// statistics visits every element, so they're intended to be computed once
+// for a representative document, and reused to estimate the cost of many
+// selectors.
+func NewDocumentStats(n *html.Node) *DocumentStats {
+	s := &DocumentStats{
+		Tags:    map[string]int{},
+		Classes: map[string]int{},
+		IDs:     map[string]int{},
+	}
+	if !validRoot(n) {
+		return s
+	}
+	depth, parents, children := 0, 0, 0
+	var walk func(n *html.Node, d int)
+	walk = func(n *html.Node, d int) {
+		if isElement(n) {
+			s.Elements++
+			depth += d
+			s.Tags[n.Data]++
+			for _, a := range n.Attr {
+				switch a.Key {
+				case "id":
+					s.IDs[a.Val]++
+				case "class":
+					for _, c := range strings.Fields(a.Val) {
+						s.Classes[c]++
+					}
+				}
+			}
+			d++
+		}
+		count := 0
+		for c := firstElementChild(n); c != nil; c = nextElementSibling(c) {
+			count++
+			walk(c, d)
+		}
+		if count > 0 {
+			parents++
+			children += count
+		}
+	}
+	walk(n, 0)
+	if s.Elements > 0 {
+		s.Depth = float64(depth) / float64(s.Elements)
+	}
+	if parents > 0 {
+		s.Children = float64(children) / float64(parents)
+	}
+	return s
+}
+
+// defaultStats are assumed by EstimateCost when no statistics are provided,
+// and describe a moderately sized page.
+var defaultStats = DocumentStats{
+	Elements: 1000,
+	Depth:    10,
+	Children: 4,
+}
+
+// Cost is a rough estimate of the work needed to evaluate a selector.
+// Estimates are only meaningful relative to each other, for example to order
+// or distribute many selectors.
+type Cost struct {
+	// Visits is the number of elements traversed during selection. Every
+	// element within the root is visited.
+	Visits int
+	// Candidates is the expected number of elements matching the subject
+	// compound selector of each selector in the list, which then have the
+	// rest of the selector matched through its combinators.
+	Candidates int
+	// Score combines the above into a single relative cost, measured in
+	// approximate element tests.
+	Score float64
+}
+
+// EstimateCost estimates the cost of selecting from a document described by
+// stats. If stats is nil, a typical document is assumed. If any of the tag,
+// class, or id counts are nil, those components are assumed to be moderately
+// selective.
+//
+// The estimate follows evaluation: each element is tested against the tests
+// of the subject in the order they're evaluated, stopping at the first that
+// fails, then elements matching the subject are related to other elements
+// through each combinator.
+func (s *Selector) EstimateCost(stats *DocumentStats) Cost {
+	if stats == nil {
+		stats = &defaultStats
+	}
+	c := Cost{Visits: stats.Elements}
+	for i, sel := range s.s {
+		candidates, tests := estimateCompound(sel.m, stats)
+		related := 0.0
+		for curr := &s.list[i]; curr.next != nil; curr = curr.next {
+			related += estimateRelated(curr.combinator, stats)
+		}
+		c.Candidates += int(candidates)
+		c.Score += float64(stats.Elements) + tests + candidates*related
+	}
+	return c
+}
+
+// estimateCompound returns the expected number of elements matching a compound
+// selector, and the number of tests evaluated to find them. Tests without
+// statistics, such as attribute selectors, are assumed to match every element
+// that reaches them.
+func estimateCompound(m *compoundSelectorMatcher, stats *DocumentStats) (matches, tests float64) {
+	matches = float64(stats.Elements)
+	test := func(n float64) {
+		tests += matches
+		matches = min(matches, n)
+	}
+	if m.m != nil && !m.m.allAtoms {
+		n := max(stats.Elements/20, 1)
+		if stats.Tags != nil {
+			n = stats.Tags[m.m.lower]
+		}
+		test(float64(n))
+	}
+	for _, scm := range m.scm {
+		switch {
+		case scm.idSelector != "":
+			n := min(stats.Elements, 1)
+			if stats.IDs != nil {
+				n = stats.IDs[scm.idSelector]
+			}
+			test(float64(n))
+		case scm.classSelector != "":
+			n := max(stats.Elements/50, 1)
+			if stats.Classes != nil {
+				n = stats.Classes[scm.classSelector]
+			}
+			test(float64(n))
+		default:
+			test(matches)
+		}
+	}
+	return matches, tests
+}
+
+// estimateRelated returns the expected number of elements a combinator
+// relates each element to.
+func estimateRelated(combinator string, stats *DocumentStats) float64 {
+	switch combinator {
+	case ">", "+":
+		return 1
+	case "~":
+		return stats.Children / 2
+	case "":
+		return stats.Depth
+	case "^":
+		return stats.Children * stats.Depth
+	default:
+		// Custom combinators may relate an element to any other.
+		return float64(stats.Elements)
+	}
+}
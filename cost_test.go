@@ -0,0 +1,72 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestNewDocumentStats(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<div id="main"><p class="a b">1</p><p class="a">2</p></div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	got := NewDocumentStats(root)
+	want := &DocumentStats{
+		// html, head, body, div, p, p
+		Elements: 6,
+		// 0 + 1 + 1 + 2 + 3 + 3
+		Depth: 10.0 / 6,
+		// document: 1, html: 2, body: 1, div: 2
+		Children: 6.0 / 4,
+		Tags:     map[string]int{"html": 1, "head": 1, "body": 1, "div": 1, "p": 2},
+		Classes:  map[string]int{"a": 2, "b": 1},
+		IDs:      map[string]int{"main": 1},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("NewDocumentStats() returned diff (-want, +got): %s", diff)
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	// Without statistics, selectors are ordered by the tests evaluated
+	// against each element, then by the elements related to each match.
+	order := []string{"*", "#main", "div > p", "div ~ p", "div p", "div *"}
+	var prev Cost
+	for i, sel := range order {
+		c := MustParse(sel).EstimateCost(nil)
+		if c.Visits != defaultStats.Elements {
+			t.Errorf("EstimateCost(%q).Visits = %d, want %d", sel, c.Visits, defaultStats.Elements)
+		}
+		if i > 0 && c.Score <= prev.Score {
+			t.Errorf("EstimateCost(%q).Score = %v, want more than %q with %v", sel, c.Score, order[i-1], prev.Score)
+		}
+		prev = c
+	}
+
+	root, err := html.Parse(strings.NewReader(strings.Repeat(`<div><span class="rare"></span><span></span><span></span></div>`, 10)))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	stats := NewDocumentStats(root)
+	tests := []struct {
+		sel  string
+		want Cost
+	}{
+		{"#missing", Cost{Visits: 43, Candidates: 0, Score: 43 + 43}},
+		{".rare", Cost{Visits: 43, Candidates: 10, Score: 43 + 43}},
+		{"span", Cost{Visits: 43, Candidates: 30, Score: 43 + 43}},
+		// The class is only tested against spans.
+		{"span.rare", Cost{Visits: 43, Candidates: 10, Score: 43 + 43 + 30}},
+		{"div > .rare", Cost{Visits: 43, Candidates: 10, Score: 43 + 43 + 10}},
+		{"#missing, [title]", Cost{Visits: 43, Candidates: 43, Score: 43 + 43 + 43 + 43}},
+	}
+	for _, test := range tests {
+		got := MustParse(test.sel).EstimateCost(stats)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("EstimateCost(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+}
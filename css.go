@@ -39,23 +39,47 @@
 //	foo > bar               // Child combinator
 //	foo ~ bar               // General sibling combinator
 //	foo + bar               // Adjacent sibling combinator
-//	:empty                  // Element with no children
+//	:active                 // Element being activated, see WithElementState
+//	:any-link               // Link with an href
+//	:checked                // Checked checkbox or radio, or selected option
+//	:defined                // Built-in or defined custom element, see MatchContext
+//	:disabled               // Disabled form control, also :enabled
+//	:empty                  // Element with no children or text
 //	:first-child            // First child of parent
 //	:first-of-type          // First child of its type of parent
+//	:focus                  // Focused element, see WithElementState
+//	:focus-visible          // Focused element with a focus indicator
+//	:focus-within           // Element containing the focused element
+//	:has(> sel, ...)        // Element with a descendant or sibling matching a relative selector
+//	:host                   // Shadow host, see MatchContext
+//	:host(sel)              // Shadow host matching a compound selector
+//	:hover                  // Element under the pointer, see WithElementState
+//	:is(sel, ...)           // Element matching any of the selectors
+//	:lang(en, ...)          // Element whose language matches a range
 //	:last-child             // Last child of parent
 //	:last-of-type           // Last child of its type of parent
+//	:link                   // Unvisited link, see MatchContext
+//	:not(sel, ...)          // Element matching none of the selectors
 //	:only-child             // Only child of parent
 //	:only-of-type           // Only child of its type parent
+//	:read-write             // Editable element, also :read-only
 //	:root                   // Root element
+//	:scope                  // Element selected from, see SelectFrom
+//	:target                 // Element identified by the URL fragment, see MatchContext
+//	:visited                // Visited link, see MatchContext
+//	:where(sel, ...)        // Like :is(), but with zero specificity
 //	:nth-child(An+B)        // Positional child matcher
 //	:nth-last-child(An+B)   // Reverse positional child matcher
 //	:nth-last-of-type(An+B) // Reverse positional child matcher of type
 //	:nth-of-type(An+B)      // Positional child matcher of type
+//	::part(name)            // Elements in a shadow tree exposed as a part
+//	::slotted(sel)          // Elements assigned to a <slot>, see MatchContext
 package css
 
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"golang.org/x/net/html"
@@ -74,41 +98,393 @@ func (p *ParseError) Error() string {
 	return fmt.Sprintf("css: %s at position %d", p.Msg, p.Pos)
 }
 
+// RootError is returned by SelectChecked when selection can't start from the
+// provided node. Only document and element nodes can have element
+// descendants, so other roots, including nil, never produce matches.
+type RootError struct {
+	// Node is the invalid root, and may be nil.
+	Node *html.Node
+}
+
+// Error returns a formatted version of the error.
+func (e *RootError) Error() string {
+	if e.Node == nil {
+		return "css: root is nil"
+	}
+	return fmt.Sprintf("css: root is a %s node, not a document or element", nodeTypeName(e.Node.Type))
+}
+
+// nodeTypeName returns a human readable name for a node type.
+func nodeTypeName(t html.NodeType) string {
+	switch t {
+	case html.ErrorNode:
+		return "error"
+	case html.TextNode:
+		return "text"
+	case html.DocumentNode:
+		return "document"
+	case html.ElementNode:
+		return "element"
+	case html.CommentNode:
+		return "comment"
+	case html.DoctypeNode:
+		return "doctype"
+	case html.RawNode:
+		return "raw"
+	}
+	return fmt.Sprintf("unknown (%d)", t)
+}
+
+// validRoot reports if selection can start from n.
+func validRoot(n *html.Node) bool {
+	return n != nil && (n.Type == html.DocumentNode || n.Type == html.ElementNode)
+}
+
+// Warning describes a problem with a selector that didn't prevent it from
+// being compiled, such as a component that was ignored.
+type Warning struct {
+	Pos int
+	Msg string
+}
+
+// String returns a formatted version of the warning.
+func (w Warning) String() string {
+	return fmt.Sprintf("css: %s at position %d", w.Msg, w.Pos)
+}
+
+// Warnings returns any non-fatal problems found while parsing the selector.
+func (s *Selector) Warnings() []Warning {
+	return append([]Warning(nil), s.warnings...)
+}
+
 func errorf(pos int, msg string, v ...interface{}) error {
 	return &ParseError{pos, fmt.Sprintf(msg, v...)}
 }
 
 // Selector is a compiled CSS selector.
+//
+// A Selector is immutable once compiled, and all of its methods are safe for
+// concurrent use by multiple goroutines. State used during matching is
+// allocated for each call, so a single Selector can be shared, for example as
+// a package level variable, by any number of concurrent selections. Values
+// that are bound to a document, such as Session, Document, and TreeWalker,
+// aren't safe for concurrent use.
 type Selector struct {
 	s []*selector
+	// list is the parsed selector list the Selector was compiled from.
+	list []complexSelector
+	// pseudo is set if any selector in the list has a pseudo-element, in which
+	// case selection may return elements other than the ones matched.
+	pseudo bool
+	// exclude, if non-nil, matches elements whose subtrees are skipped during
+	// selection.
+	exclude *Selector
+	// warnings hold non-fatal problems found while compiling the selector.
+	warnings []Warning
+	// observer, if non-nil, is notified of statistics for each selection.
+	observer Observer
+	// logger, if non-nil, logs a summary of each selection.
+	logger Logger
+	// order is the order of elements returned by selection.
+	order Order
+	// relational is set if any selector in the list uses a pseudo-class
+	// such as :has() or :contains(), whose matches depend on elements outside
+	// an element's ancestors and previous siblings.
+	relational bool
+}
+
+// MatchContext holds document state that selectors may depend on, but that
+// isn't represented by the html.Node tree.
+type MatchContext struct {
+	// ShadowRoots attaches shadow trees to host elements, mapping each host to
+	// the root of its shadow tree. Roots are typically html.DocumentNode values
+	// whose children are the contents of the shadow tree.
+	//
+	// When selecting from a shadow root, the root's host can be matched by
+	// :host and :host(), but no other selector. Pseudo-elements such as
+	// ::slotted() use ShadowRoots to determine slot assignment.
+	ShadowRoots map[*html.Node]*html.Node
+	// Flatten causes selection to descend into the shadow trees of any hosts
+	// it encounters, with combinators crossing shadow boundaries as if the
+	// contents of the shadow tree were children of the host.
+	Flatten bool
+	// Visited reports if the destination of a link has been visited, and is
+	// used to match :visited and :link. It's passed the link's href as
+	// written in the document, which may be a relative URL. If Visited is
+	// nil, no links have been visited.
+	Visited func(href string) bool
+	// Fragment is the fragment of the document's URL, without the leading
+	// "#", and determines the element matched by :target. If Fragment is
+	// empty, :target doesn't match any element.
+	Fragment string
+	// Defined reports if a custom element, such as "my-element", has been
+	// defined, and is used to match :defined. Built-in elements are always
+	// defined. If Defined is nil, no custom elements have been defined.
+	Defined func(name string) bool
 }
 
-// Select returns any matches from a parsed HTML document.
+// Select returns any matches from a parsed HTML document. Matches are
+// returned in document order, unless configured otherwise by WithOrder, and
+// include n itself if it matches.
+//
+// Selection is scoped to n. Combinators only consider elements within the
+// subtree rooted at n, while pseudo-classes such as :first-child consider the
+// full document.
+//
+// If n is nil, or isn't a document or element node, Select returns no
+// matches. Use SelectChecked to detect invalid roots.
 func (s *Selector) Select(n *html.Node) []*html.Node {
+	return s.SelectWithContext(n, MatchContext{})
+}
+
+// SelectChecked is like Select, but returns a *RootError if n is nil or isn't
+// a document or element node.
+func (s *Selector) SelectChecked(n *html.Node) ([]*html.Node, error) {
+	if !validRoot(n) {
+		return nil, &RootError{Node: n}
+	}
+	return s.Select(n), nil
+}
+
+// SelectWithContext is like Select, but evaluates the selector using the
+// additional document state held by ctx.
+func (s *Selector) SelectWithContext(n *html.Node, ctx MatchContext) []*html.Node {
+	return s.selectState(s.newState(n, &ctx), n)
+}
+
+// SelectFrom is like Select, but selects from root with :scope matching the
+// element scope, rather than root, so the selector can describe elements
+// relative to scope:
+//
+//	items := css.MustParse(":scope > li").SelectFrom(list, doc)
+//
+// Combinators aren't evaluated past root, so scope is typically root or one of
+// its descendants.
+func (s *Selector) SelectFrom(scope, root *html.Node) []*html.Node {
+	st := s.newState(root, &MatchContext{})
+	st.scope = scope
+	return s.selectState(st, root)
+}
+
+// selectState returns the elements in the tree rooted at n matched using st.
+func (s *Selector) selectState(st *state, n *html.Node) []*html.Node {
+	if (s.observer != nil || s.logger != nil) && st.stats == nil {
+		return s.observeSelect(st, n)
+	}
 	selected := []*html.Node{}
-	for _, sel := range s.s {
-		selected = append(selected, sel.find(n)...)
+	if !validRoot(n) {
+		return selected
+	}
+	if !s.pseudo {
+		st.walk(n, func(e *html.Node) {
+			if st.stats != nil {
+				st.stats.Visited++
+			}
+			if s.match(st, e) {
+				selected = append(selected, e)
+			}
+		})
+		if s.order == PostOrder {
+			st.sortNodesPostOrder(selected)
+		}
+		return selected
+	}
+
+	// Pseudo-elements may represent elements other than the one that was
+	// matched, so results must be deduplicated and reordered.
+	seen := map[*html.Node]bool{}
+	add := func(n *html.Node) {
+		if !seen[n] {
+			seen[n] = true
+			selected = append(selected, n)
+		}
+	}
+	st.walk(n, func(e *html.Node) {
+		if st.stats != nil {
+			st.stats.Visited++
+		}
+		for _, sel := range s.s {
+			if !sel.match(st, e) {
+				continue
+			}
+			if sel.pseudo == nil {
+				add(e)
+				continue
+			}
+			for _, n := range sel.pseudo(st, e) {
+				add(n)
+			}
+		}
+	})
+	switch s.order {
+	case DocumentOrder:
+		st.sortNodes(selected)
+	case PostOrder:
+		st.sortNodesPostOrder(selected)
 	}
 	return selected
 }
 
-func findAll(n *html.Node, fn func(n *html.Node) bool) []*html.Node {
-	var m []*html.Node
-	if fn(n) {
-		m = append(m, n)
+// match reports if the element n matches any of the selectors in the list.
+func (s *Selector) match(st *state, n *html.Node) bool {
+	for _, sel := range s.s {
+		if sel.match(st, n) {
+			return true
+		}
 	}
-	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		if c.Type != html.ElementNode {
-			continue
+	return false
+}
+
+// state holds values used by matchers during a single selection.
+type state struct {
+	// root is the node selection started from. Combinators aren't evaluated
+	// past the root.
+	root *html.Node
+	ctx  *MatchContext
+	// host is set when selecting from a shadow root, and holds the root's
+	// shadow host.
+	host *html.Node
+	// hosts maps shadow roots to their host elements.
+	hosts map[*html.Node]*html.Node
+	// trace, if non-nil, records each compound selector evaluated.
+	trace *[]TraceStep
+	// exclude, if non-nil, prunes matching elements from traversal.
+	exclude *Selector
+	// session, if non-nil, caches computed values about nodes across
+	// selections.
+	session *Session
+	// stats, if non-nil, collects statistics reported to an Observer, with
+	// stages mapping each compound selector to its entry in stats.Stages.
+	stats  *Stats
+	stages map[*compoundSelector]int
+	// anchor is the element the argument of a :has() pseudo-class is being
+	// evaluated against.
+	anchor *html.Node
+	// scope, if non-nil, is the element matched by :scope. See scopeElement.
+	scope *html.Node
+	// roots holds the roots passed to SelectAll when they're treated as
+	// siblings, with fragment mapping each root to its index. See
+	// prevElementSibling.
+	roots    []*html.Node
+	fragment map[*html.Node]int
+	// targets caches the element matched by :target within each tree.
+	targets map[*html.Node]*html.Node
+	// checkedRadios holds the checked radio buttons, for each tree in
+	// radiosScanned. See checkedRadio.
+	checkedRadios map[*html.Node]bool
+	radiosScanned map[*html.Node]bool
+	// focused holds the elements matched by :focus-within, for each tree in
+	// focusScanned. See focusWithin.
+	focused      map[*html.Node]bool
+	focusScanned map[*html.Node]bool
+	// subtrees caches whether the subtree of an element holds a match for a
+	// :has() argument, keyed by the argument. See relativeMatcher.contains.
+	subtrees map[*selector]map[*html.Node]bool
+}
+
+// resetCaches discards values cached about the tree during selection, such
+// as the element matched by :target, for use after the tree is modified.
+func (s *state) resetCaches() {
+	s.targets = nil
+	s.checkedRadios = nil
+	s.radiosScanned = nil
+	s.focused = nil
+	s.focusScanned = nil
+	s.subtrees = nil
+}
+
+func newState(root *html.Node, ctx *MatchContext) *state {
+	s := &state{root: root, ctx: ctx}
+	if len(ctx.ShadowRoots) > 0 {
+		s.hosts = make(map[*html.Node]*html.Node, len(ctx.ShadowRoots))
+		for host, root := range ctx.ShadowRoots {
+			s.hosts[root] = host
 		}
-		m = append(m, findAll(c, fn)...)
+		s.host = s.hosts[root]
 	}
-	return m
+	return s
+}
+
+// newState returns the state for a selection performed by the selector.
+func (s *Selector) newState(root *html.Node, ctx *MatchContext) *state {
+	st := newState(root, ctx)
+	st.exclude = s.exclude
+	return st
+}
+
+// pruned reports if n and its descendants should be skipped during selection.
+func (s *state) pruned(n *html.Node) bool {
+	return s.exclude != nil && isElement(n) && s.exclude.match(s, n)
+}
+
+// walk calls fn for every element in the tree rooted at n in document order.
+// When selecting from a shadow root, the root's host is visited first.
+func (s *state) walk(n *html.Node, fn func(n *html.Node)) {
+	if n == s.root && s.host != nil && !s.ctx.Flatten {
+		fn(s.host)
+	}
+	s.walkTree(n, fn)
+}
+
+func (s *state) walkTree(n *html.Node, fn func(n *html.Node)) {
+	if s.pruned(n) {
+		return
+	}
+	if isElement(n) {
+		fn(n)
+	}
+	if s.ctx.Flatten {
+		if root, ok := s.ctx.ShadowRoots[n]; ok && root != n {
+			for c := firstElementChild(root); c != nil; c = nextElementSibling(c) {
+				s.walkTree(c, fn)
+			}
+		}
+	}
+	for c := firstElementChild(n); c != nil; c = nextElementSibling(c) {
+		s.walkTree(c, fn)
+	}
+}
+
+// parent returns the parent element of n, or nil if n is the root of the
+// selection or has no parent element. The parent of an element at the top of
+// a shadow tree is the shadow host, if the selection allows crossing into it.
+func (s *state) parent(n *html.Node) *html.Node {
+	if n == s.root || n == s.host || n.Parent == nil {
+		return nil
+	}
+	p := n.Parent
+	if isElement(p) {
+		return p
+	}
+	if host, ok := s.hosts[p]; ok && (s.ctx.Flatten || p == s.root) {
+		return host
+	}
+	return nil
+}
+
+// prevSibling returns the previous sibling element of n, or nil if n is the
+// root of the selection or is the first element of its parent.
+func (s *state) prevSibling(n *html.Node) *html.Node {
+	if _, ok := s.fragment[n]; ok {
+		return s.prevElementSibling(n)
+	}
+	if n == s.root || n == s.host {
+		return nil
+	}
+	return prevElementSibling(n)
+}
+
+// featureless reports if n is the host of the shadow tree being selected
+// from. Such hosts can only be matched by :host.
+//
+// https://drafts.csswg.org/css-scoping-1/#host-element-in-tree
+func (s *state) featureless(n *html.Node) bool {
+	return n == s.host && !s.ctx.Flatten
 }
 
 // MustParse is like Parse but panics on errors.
-func MustParse(s string) *Selector {
-	sel, err := Parse(s)
+func MustParse(s string, opts ...Option) *Selector {
+	sel, err := Parse(s, opts...)
 	if err != nil {
 		panic(err)
 	}
@@ -121,41 +497,136 @@ func MustParse(s string) *Selector {
 // Multiple selectors are supported through comma separated values. For example
 // "h1, h2".
 //
-// Parse reports the first error hit when compiling.
-func Parse(s string) (*Selector, error) {
+// Parse reports the first error hit when compiling. Options may be provided
+// to change how the selector is compiled.
+func Parse(s string, opts ...Option) (*Selector, error) {
 	p := newParser(s)
+	p.interner = newOptions(opts).interner
 	list, err := p.parse()
 	if err != nil {
-		var perr *parseErr
-		if errors.As(err, &perr) {
-			return nil, &ParseError{perr.t.pos, perr.msg}
-		}
-		var lerr *lexErr
-		if errors.As(err, &lerr) {
-			return nil, &ParseError{lerr.last, lerr.msg}
+		return nil, parseError(err)
+	}
+	return compileSource(s, list, opts...)
+}
+
+// ParseMany compiles a batch of selectors, such as the rules of a stylesheet,
+// returning a Selector and error for each. For every index, exactly one of
+// the Selector or error is non-nil.
+//
+//	sels, errs := css.ParseMany([]string{"h1", "a[href]", "li["})
+//	// errs[2] reports the invalid selector.
+//
+// Compared to calling Parse for each string, ParseMany reuses the lexer and
+// parser between selectors, and interns identifiers and values shared by the
+// selectors, such as tag names, class names and attribute names, so
+// repeated names are stored once. Options apply to every selector. If
+// WithInterner is provided, names are interned using its Interner, rather
+// than one private to the batch.
+func ParseMany(selectors []string, opts ...Option) ([]*Selector, []error) {
+	sels := make([]*Selector, len(selectors))
+	errs := make([]error, len(selectors))
+	in := newOptions(opts).interner
+	if in == nil {
+		in = &Interner{}
+	}
+	l := newLexer("")
+	p := &parser{l: l, peekQueue: newQueue(2), arena: &arena{}, interner: in}
+	for i, s := range selectors {
+		l.reset(s)
+		p.reset()
+		list, err := p.parse()
+		if err != nil {
+			errs[i] = parseError(err)
+			continue
 		}
-		return nil, err
+		sels[i], errs[i] = compileSource(s, list, opts...)
 	}
-	sel := &Selector{}
+	return sels, errs
+}
 
-	c := compiler{maxErrs: 1}
-	for _, s := range list {
-		m := c.compile(&s)
+// parse parses a selector list without compiling it, converting errors to
+// ParseError values.
+func parse(s string) ([]complexSelector, error) {
+	p := newParser(s)
+	list, err := p.parse()
+	if err != nil {
+		return nil, parseError(err)
+	}
+	return list, nil
+}
+
+// parseError converts lexer and parser errors to a ParseError.
+func parseError(err error) error {
+	var perr *parseErr
+	if errors.As(err, &perr) {
+		return &ParseError{perr.t.pos, perr.msg}
+	}
+	var lerr *lexErr
+	if errors.As(err, &lerr) {
+		return &ParseError{lerr.last, lerr.msg}
+	}
+	return err
+}
+
+// compile turns a parsed selector list into a Selector, reporting the first
+// error hit.
+func compile(list []complexSelector, opts ...Option) (*Selector, error) {
+	return compileSource("", list, opts...)
+}
+
+// compileSource is like compile, but records the source of the selector list
+// in any gaps reported to a Report.
+func compileSource(src string, list []complexSelector, opts ...Option) (*Selector, error) {
+	sel := &Selector{list: list}
+
+	c := compiler{maxErrs: 1, opts: newOptions(opts), src: src}
+	sel.exclude = c.opts.exclude
+	sel.observer = c.opts.observer
+	sel.logger = c.opts.logger
+	sel.order = c.opts.order
+	for i := range list {
+		if c.opts.selectors3 {
+			c.selectors3(&list[i])
+		}
+		m := c.compile(&list[i])
 		if m == nil {
 			continue
 		}
+		if l := c.opts.logger; l != nil {
+			var b strings.Builder
+			writeComplexSelector(&b, &list[i])
+			l.Debug("css: compiled selector", "selector", b.String(), "match", evaluationOrder(m.m))
+		}
 		sel.s = append(sel.s, m)
+		if m.pseudo != nil {
+			sel.pseudo = true
+		}
+	}
+	if r := c.opts.report; r != nil {
+		r.add(c.gaps)
 	}
 	if err := c.err(); err != nil {
 		return nil, err
 	}
+	sel.warnings = c.warnings
+	sel.relational = c.relational
 	return sel, nil
 }
 
 type compiler struct {
-	sels    []complexSelector
-	maxErrs int
-	errs    []error
+	sels     []complexSelector
+	maxErrs  int
+	errs     []error
+	warnings []Warning
+	opts     options
+	// relational is set if a pseudo-class that depends on elements outside
+	// an element's ancestors and previous siblings, such as :has(), was
+	// compiled.
+	relational bool
+	// src is the selector list being compiled, and gaps holds the features
+	// recorded for WithReport.
+	src  string
+	gaps []Gap
 }
 
 func (c *compiler) err() error {
@@ -174,140 +645,158 @@ func (c *compiler) errorf(pos int, msg string, v ...interface{}) bool {
 	return false
 }
 
+// warnf records a non-fatal problem with the selector. If the strict option is
+// set, the problem is reported as an error instead.
+func (c *compiler) warnf(pos int, msg string, v ...interface{}) {
+	if c.opts.strict {
+		c.errorf(pos, msg, v...)
+		return
+	}
+	w := Warning{pos, fmt.Sprintf(msg, v...)}
+	if l := c.opts.logger; l != nil {
+		l.Warn("css: "+w.Msg, "pos", w.Pos)
+	}
+	c.warnings = append(c.warnings, w)
+}
+
+// combinator evaluates the relationship between an element and the compound
+// selector to its left. match calls next for each element related to n that
+// matches the combinator's compound selector, returning true if any call to
+// next does.
 type combinator interface {
-	find(n *html.Node) []*html.Node
+	match(s *state, n *html.Node, next func(n *html.Node) bool) bool
 }
 
+// selector is a compiled complex selector. Matching is performed right to
+// left, starting with the subject of the selector and working back through
+// each combinator.
 type selector struct {
+	// m matches the subject of the selector, the rightmost compound selector.
 	m *compoundSelectorMatcher
-
+	// combinators hold the rest of the complex selector in right to left
+	// order.
 	combinators []combinator
+	// pseudo, if non-nil, maps the subject to the elements represented by a
+	// pseudo-element.
+	pseudo pseudoElementMatcher
+	// text names the text pseudo-element, such as first-letter, the selector
+	// represents ranges of. See SelectRanges.
+	text string
 }
 
-func (s selector) find(n *html.Node) []*html.Node {
-	nodes := findAll(n, s.m.match)
-	for _, c := range s.combinators {
-		var ns []*html.Node
-		for _, n := range nodes {
-			ns = append(ns, c.find(n)...)
-		}
-		nodes = ns
+func (s *selector) match(st *state, n *html.Node) bool {
+	if !st.matchCompound(s.m, "", n) {
+		return false
+	}
+	return s.matchCombinators(st, n, 0)
+}
+
+// matchCompound matches a compound selector of a complex selector against n,
+// recording the evaluation if the selection is being traced or observed.
+// combinator is the combinator that led to n, or empty for the subject.
+func (s *state) matchCompound(m *compoundSelectorMatcher, combinator string, n *html.Node) bool {
+	if s.trace == nil && s.stats == nil {
+		return m.match(s, n)
+	}
+	return s.observeCompound(m, combinator, n)
+}
+
+func (s *selector) matchCombinators(st *state, n *html.Node, i int) bool {
+	if i == len(s.combinators) {
+		return true
 	}
-	return nodes
+	return s.combinators[i].match(st, n, func(n *html.Node) bool {
+		return s.matchCombinators(st, n, i+1)
+	})
 }
 
 type descendantCombinator struct {
 	m *compoundSelectorMatcher
 }
 
-func (c *descendantCombinator) find(n *html.Node) []*html.Node {
-	var nodes []*html.Node
-	for n := n.FirstChild; n != nil; n = n.NextSibling {
-		if n.Type != html.ElementNode {
-			continue
+func (c *descendantCombinator) match(s *state, n *html.Node, next func(n *html.Node) bool) bool {
+	for p := s.parent(n); p != nil; p = s.parent(p) {
+		ok := s.matchCompound(c.m, " ", p)
+		if ok && next(p) {
+			return true
 		}
-		nodes = append(nodes, findAll(n, c.m.match)...)
 	}
-	return nodes
+	return false
 }
 
 type childCombinator struct {
 	m *compoundSelectorMatcher
 }
 
-func (c *childCombinator) find(n *html.Node) []*html.Node {
-	var nodes []*html.Node
-	for n := n.FirstChild; n != nil; n = n.NextSibling {
-		if n.Type != html.ElementNode {
-			continue
-		}
-		if c.m.match(n) {
-			nodes = append(nodes, n)
-		}
+func (c *childCombinator) match(s *state, n *html.Node, next func(n *html.Node) bool) bool {
+	p := s.parent(n)
+	if p == nil {
+		return false
 	}
-	return nodes
+	ok := s.matchCompound(c.m, ">", p)
+	return ok && next(p)
 }
 
 type adjacentCombinator struct {
 	m *compoundSelectorMatcher
 }
 
-func (c *adjacentCombinator) find(n *html.Node) []*html.Node {
-	var (
-		nodes []*html.Node
-		prev  *html.Node
-		next  *html.Node
-	)
-	for prev = n.PrevSibling; prev != nil; prev = prev.PrevSibling {
-		if prev.Type == html.ElementNode {
-			break
-		}
-	}
-	for next = n.NextSibling; next != nil; next = next.NextSibling {
-		if next.Type == html.ElementNode {
-			break
-		}
-	}
-	if prev != nil && c.m.match(prev) {
-		nodes = append(nodes, prev)
-	}
-	if next != nil && c.m.match(next) {
-		nodes = append(nodes, next)
+func (c *adjacentCombinator) match(s *state, n *html.Node, next func(n *html.Node) bool) bool {
+	p := s.prevSibling(n)
+	if p == nil {
+		return false
 	}
-	return nodes
+	ok := s.matchCompound(c.m, "+", p)
+	return ok && next(p)
 }
 
 type siblingCombinator struct {
 	m *compoundSelectorMatcher
 }
 
-func (c *siblingCombinator) find(n *html.Node) []*html.Node {
-	var nodes []*html.Node
-	for n := n.PrevSibling; n != nil; n = n.PrevSibling {
-		if n.Type != html.ElementNode {
-			continue
-		}
-		if c.m.match(n) {
-			nodes = append(nodes, n)
-		}
-	}
-	for n := n.NextSibling; n != nil; n = n.NextSibling {
-		if n.Type != html.ElementNode {
-			continue
-		}
-		if c.m.match(n) {
-			nodes = append(nodes, n)
+func (c *siblingCombinator) match(s *state, n *html.Node, next func(n *html.Node) bool) bool {
+	for p := s.prevSibling(n); p != nil; p = s.prevSibling(p) {
+		ok := s.matchCompound(c.m, "~", p)
+		if ok && next(p) {
+			return true
 		}
 	}
-	return nodes
+	return false
 }
 
 func (c *compiler) compile(s *complexSelector) *selector {
+	// Gather compound selectors and combinators left to right, then compile
+	// them in reverse so the subject of the selector is matched first.
+	var (
+		sels        []*complexSelector
+		combinators []string
+	)
+	for curr := s; curr != nil; curr = curr.next {
+		sels = append(sels, curr)
+		if curr.next != nil {
+			combinators = append(combinators, curr.combinator)
+		}
+	}
+
+	last := sels[len(sels)-1]
 	m := &selector{
-		m: c.compoundSelector(&s.sel),
-	}
-	curr := s
-	for {
-		if curr.next == nil {
-			return m
-		}
-		sel := c.compoundSelector(&curr.next.sel)
-		comb := curr.combinator
-
-		curr = curr.next
-
-		var cm combinator
-		switch comb {
-		case "":
-			cm = &descendantCombinator{sel}
-		case ">":
-			cm = &childCombinator{sel}
-		case "+":
-			cm = &adjacentCombinator{sel}
-		case "~":
-			cm = &siblingCombinator{sel}
-		default:
-			c.errorf(curr.pos, "unexpected combinator: %s", comb)
+		m: c.compoundSelector(&last.sel),
+	}
+	if len(last.sel.pseudoSelectors) != 0 {
+		m.pseudo = c.pseudoElementSelectors(last.sel.pseudoSelectors)
+		if m.pseudo != nil {
+			m.text = c.textPseudoElement(&last.sel.pseudoSelectors[0].element)
+		}
+	}
+	for i := len(combinators) - 1; i >= 0; i-- {
+		if ps := sels[i].sel.pseudoSelectors; len(ps) != 0 {
+			c.errorf(ps[0].element.pos, "pseudo-elements must be part of the last compound selector")
+		}
+		sel := c.compoundSelector(&sels[i].sel)
+		comb := combinators[i]
+
+		cm := c.combinator(sels[i+1].pos, comb, sel)
+		if cm == nil {
 			continue
 		}
 		m.combinators = append(m.combinators, cm)
@@ -318,16 +807,24 @@ func (c *compiler) compile(s *complexSelector) *selector {
 type compoundSelectorMatcher struct {
 	m   *typeSelectorMatcher
 	scm []subclassSelectorMatcher
+	// src is the compound selector the matcher was compiled from.
+	src *compoundSelector
+	// host is set if the compound selector contains :host or :host(), and
+	// can match featureless shadow hosts.
+	host bool
 }
 
-func (c *compoundSelectorMatcher) match(n *html.Node) bool {
+func (c *compoundSelectorMatcher) match(s *state, n *html.Node) bool {
+	if !c.host && s.featureless(n) {
+		return false
+	}
 	if c.m != nil {
 		if !c.m.match(n) {
 			return false
 		}
 	}
 	for _, m := range c.scm {
-		if !m.match(n) {
+		if !m.match(s, n) {
 			return false
 		}
 	}
@@ -335,39 +832,130 @@ func (c *compoundSelectorMatcher) match(n *html.Node) bool {
 }
 
 func (c *compiler) compoundSelector(s *compoundSelector) *compoundSelectorMatcher {
-	m := &compoundSelectorMatcher{}
+	m := &compoundSelectorMatcher{src: s}
 	if s.typeSelector != nil {
 		m.m = c.typeSelector(s.typeSelector)
 	}
-	for _, sc := range s.subClasses {
-		scm := c.subclassSelector(&sc)
+	for i := range s.subClasses {
+		sc := &s.subClasses[i]
+		scm := c.subclassSelector(sc)
 		if scm != nil {
 			m.scm = append(m.scm, *scm)
 		}
-	}
-	if len(s.pseudoSelectors) != 0 {
-		// It's not clear that it makes sense for us to support pseudo elements,
-		// since this is more about modifying added elements than selecting elements.
-		//
-		// https://developer.mozilla.org/en-US/docs/Web/CSS/Pseudo-elements
-		if c.errorf(s.pos, "pseudo element selectors not supported") {
-			return nil
+		if pcs := sc.pseudoClassSelector; pcs != nil {
+			if pcs.ident == "host" || pcs.function == "host(" {
+				m.host = true
+			}
 		}
 	}
+	orderSubclasses(m)
 	return m
 }
 
+// compoundArg compiles the argument of a functional pseudo-class or
+// pseudo-element that accepts a single compound selector, such as :host().
+func (c *compiler) compoundArg(s *pseudoClassSelector) *compoundSelectorMatcher {
+	p := newParserFromTokens(s.args)
+	p.skipWhitespace()
+	cs, ok, err := p.compoundSelector()
+	if err != nil {
+		c.errorf(s.pos, "failed to parse compound selector: %v", err)
+		return nil
+	}
+	if !ok {
+		c.errorf(s.pos, "expected compound selector")
+		return nil
+	}
+	if err := p.expectWhitespaceOrEOF(); err != nil {
+		c.errorf(s.pos, "failed to parse compound selector: %v", err)
+		return nil
+	}
+	if len(cs.pseudoSelectors) != 0 {
+		// Like selector list arguments, a pseudo-element makes the argument
+		// match nothing rather than being an error.
+		return neverMatches(cs)
+	}
+	return c.compoundSelector(cs)
+}
+
+// pseudoElementMatcher maps an element matched by a selector to the elements
+// represented by a pseudo-element.
+type pseudoElementMatcher func(s *state, n *html.Node) []*html.Node
+
+func (c *compiler) pseudoElementSelectors(ps []pseudoSelector) pseudoElementMatcher {
+	if len(ps) > 1 {
+		if c.opts.report != nil {
+			c.gapf(ps[1].element.pos, GapPseudoElement, ":"+pseudoClassFeature(&ps[1].element), "multiple pseudo-elements not supported, selector never matches")
+			return func(s *state, n *html.Node) []*html.Node { return nil }
+		}
+		c.errorf(ps[1].element.pos, "multiple pseudo-elements not supported")
+		return nil
+	}
+	if len(ps[0].classes) != 0 {
+		if c.opts.report != nil {
+			c.gapf(ps[0].classes[0].pos, GapPseudoElement, pseudoClassFeature(&ps[0].classes[0]), "pseudo-classes following pseudo-elements not supported, selector never matches")
+			return func(s *state, n *html.Node) []*html.Node { return nil }
+		}
+		c.errorf(ps[0].classes[0].pos, "pseudo-classes following pseudo-elements not supported")
+		return nil
+	}
+	return c.pseudoElementSelector(&ps[0].element)
+}
+
+func (c *compiler) pseudoElementSelector(s *pseudoClassSelector) pseudoElementMatcher {
+	// Most pseudo-elements represent content that isn't part of the document
+	// tree, such as ::before, and don't make sense for selecting elements.
+	// Only pseudo-elements that represent elements are supported.
+	//
+	// https://developer.mozilla.org/en-US/docs/Web/CSS/Pseudo-elements
+	switch s.function {
+	case "part(":
+		return c.part(s)
+	case "slotted(":
+		return c.slotted(s)
+	case "":
+		if name := c.textPseudoElement(s); name != "" {
+			return textRanges(name)
+		}
+		return c.unknownPseudoElement(s, s.ident)
+	default:
+		return c.unknownPseudoElement(s, s.function)
+	}
+}
+
+// unknownPseudoElement handles a pseudo-element this package doesn't support,
+// such as ::before, according to the configured UnknownPseudoPolicy. Ignoring
+// a pseudo-element selects its originating element.
+func (c *compiler) unknownPseudoElement(s *pseudoClassSelector, name string) pseudoElementMatcher {
+	switch c.unknownPseudoPolicy() {
+	case UnknownPseudoNeverMatch:
+		c.gapf(s.pos, GapPseudoElement, ":"+pseudoClassFeature(s), "unsupported pseudo-element selector never matches: %s", name)
+		return func(s *state, n *html.Node) []*html.Node { return nil }
+	case UnknownPseudoAlwaysMatch:
+		c.gapf(s.pos, GapPseudoElement, ":"+pseudoClassFeature(s), "unsupported pseudo-element selector ignored: %s", name)
+		return func(s *state, n *html.Node) []*html.Node { return []*html.Node{n} }
+	default:
+		c.errorf(s.pos, "unsupported pseudo-element selector: %s", name)
+		return nil
+	}
+}
+
 type subclassSelectorMatcher struct {
 	idSelector        string
 	classSelector     string
 	attributeSelector *attributeSelectorMatcher
-	pseudoSelector    func(*html.Node) bool
+	pseudoSelector    matchFunc
+	// nfc is set if id and class values are compared after NFC
+	// normalization.
+	nfc bool
+	// src is the subclass selector the matcher was compiled from.
+	src *subclassSelector
 }
 
-func (s *subclassSelectorMatcher) match(n *html.Node) bool {
+func (s *subclassSelectorMatcher) match(st *state, n *html.Node) bool {
 	if s.idSelector != "" {
 		for _, a := range n.Attr {
-			if a.Key == "id" && a.Val == s.idSelector {
+			if a.Key == "id" && (a.Val == s.idSelector || s.nfc && nfc(a.Val) == s.idSelector) {
 				return true
 			}
 		}
@@ -375,9 +963,16 @@ func (s *subclassSelectorMatcher) match(n *html.Node) bool {
 	}
 
 	if s.classSelector != "" {
+		if st.session != nil && !s.nfc {
+			return st.session.classes(n)[s.classSelector]
+		}
 		for _, a := range n.Attr {
 			if a.Key == "class" {
-				for _, val := range strings.Fields(a.Val) {
+				val := a.Val
+				if s.nfc {
+					val = nfc(val)
+				}
+				for _, val := range strings.Fields(val) {
 					if val == s.classSelector {
 						return true
 					}
@@ -388,11 +983,11 @@ func (s *subclassSelectorMatcher) match(n *html.Node) bool {
 	}
 
 	if s.attributeSelector != nil {
-		return s.attributeSelector.match(n)
+		return s.attributeSelector.match(st, n)
 	}
 
 	if s.pseudoSelector != nil {
-		return s.pseudoSelector(n)
+		return s.pseudoSelector(st, n)
 	}
 	return false
 }
@@ -401,6 +996,12 @@ func (c *compiler) subclassSelector(s *subclassSelector) *subclassSelectorMatche
 	m := &subclassSelectorMatcher{
 		idSelector:    s.idSelector,
 		classSelector: s.classSelector,
+		src:           s,
+	}
+	if c.opts.nfc {
+		m.idSelector = nfc(m.idSelector)
+		m.classSelector = nfc(m.classSelector)
+		m.nfc = true
 	}
 	if s.attributeSelector != nil {
 		m.attributeSelector = c.attributeSelector(s.attributeSelector)
@@ -411,36 +1012,93 @@ func (c *compiler) subclassSelector(s *subclassSelector) *subclassSelectorMatche
 	return m
 }
 
-type pseudoClassSelectorMatcher struct {
-	matcher func(*html.Node) bool
+// matchFunc is a compiled matcher for a pseudo-class.
+type matchFunc func(s *state, n *html.Node) bool
+
+// stateless adapts a matcher that only depends on the element being matched.
+func stateless(fn func(n *html.Node) bool) matchFunc {
+	if fn == nil {
+		return nil
+	}
+	return func(s *state, n *html.Node) bool {
+		return fn(n)
+	}
 }
 
-func (c *compiler) pseudoClassSelector(s *pseudoClassSelector) func(*html.Node) bool {
+func (c *compiler) pseudoClassSelector(s *pseudoClassSelector) matchFunc {
 	// https://developer.mozilla.org/en-US/docs/Web/CSS/Pseudo-classes
 	switch s.ident {
+	case "active", "focus", "focus-visible", "focus-within", "hover":
+		if fn := c.userAction(s.ident); fn != nil {
+			return fn
+		}
+		return c.unknownPseudoClass(s, s.ident)
+	case "any-link":
+		return stateless(anyLinkMatcher)
+	case "checked":
+		return c.checked()
+	case "defined":
+		return definedMatcher
+	case "disabled":
+		return stateless(c.disabled())
 	case "empty":
-		return emptyMatcher
+		return stateless(emptyMatcher)
+	case "enabled":
+		return stateless(c.enabled())
 	case "first-child":
 		return firstChildMatcher
 	case "first-of-type":
 		return firstOfTypeMatcher
+	case "host":
+		return hostMatcher
 	case "last-child":
 		return lastChildMatcher
 	case "last-of-type":
 		return lastOfTypeMatcher
+	case "link":
+		return linkMatcher
 	case "only-child":
 		return onlyChildMatcher
 	case "only-of-type":
 		return onlyOfTypeMatcher
+	case "read-only":
+		return stateless(readOnlyMatcher)
+	case "read-write":
+		return stateless(readWriteMatcher)
 	case "root":
-		return rootMatcher
+		return stateless(rootMatcher)
+	case "scope":
+		return scopeMatcher
+	case "target":
+		return targetMatcher
+	case "visited":
+		return visitedMatcher
 	case "":
 	default:
-		c.errorf(s.pos, "unsupported pseudo-class selector: %s", s.ident)
-		return nil
+		if fn, ok := extensionMatchers[s.ident]; ok && c.opts.extensions {
+			return stateless(fn)
+		}
+		return c.unknownPseudoClass(s, s.ident)
 	}
 
 	switch s.function {
+	case "contains(":
+		if c.opts.extensions {
+			return stateless(c.contains(s))
+		}
+		return c.unknownPseudoClass(s, s.function)
+	case "has(":
+		return c.has(s)
+	case "host(":
+		return c.hostFunc(s)
+	case "is(":
+		return c.is(s)
+	case "lang(":
+		return stateless(c.lang(s))
+	case "not(":
+		return c.not(s)
+	case "where(":
+		return c.is(s)
 	case "nth-child(":
 		return c.nthChild(s)
 	case "nth-last-child(":
@@ -449,101 +1107,77 @@ func (c *compiler) pseudoClassSelector(s *pseudoClassSelector) func(*html.Node)
 		return c.nthLastOfType(s)
 	case "nth-of-type(":
 		return c.nthOfType(s)
+	case "role(":
+		if c.opts.extensions {
+			return stateless(c.role(s))
+		}
+		return c.unknownPseudoClass(s, s.function)
 	default:
-		c.errorf(s.pos, "unsupported pseudo-class selector: %s", s.function)
-		return nil
+		return c.unknownPseudoClass(s, s.function)
 	}
+}
 
-	return nil
+// unknownPseudoClass handles a pseudo-class this package doesn't support,
+// according to the configured UnknownPseudoPolicy.
+func (c *compiler) unknownPseudoClass(s *pseudoClassSelector, name string) matchFunc {
+	switch c.unknownPseudoPolicy() {
+	case UnknownPseudoNeverMatch:
+		c.gapf(s.pos, GapPseudoClass, pseudoClassFeature(s), "unsupported pseudo-class selector never matches: %s", name)
+		return func(s *state, n *html.Node) bool { return false }
+	case UnknownPseudoAlwaysMatch:
+		c.gapf(s.pos, GapPseudoClass, pseudoClassFeature(s), "unsupported pseudo-class selector ignored: %s", name)
+		return func(s *state, n *html.Node) bool { return true }
+	default:
+		c.errorf(s.pos, "unsupported pseudo-class selector: %s", name)
+		return nil
+	}
 }
 
 // https://developer.mozilla.org/en-US/docs/Web/CSS/:nth-child
-func (c *compiler) nthChild(s *pseudoClassSelector) func(n *html.Node) bool {
+func (c *compiler) nthChild(s *pseudoClassSelector) matchFunc {
 	nth := c.compileNth(s)
 	if nth == nil {
 		return nil
 	}
-	return func(n *html.Node) bool {
-		var i int64 = 1
-		for s := n.PrevSibling; s != nil; s = s.PrevSibling {
-			if s.Type == html.ElementNode {
-				i++
-			}
-		}
-		return nth.matches(i)
+	return func(st *state, n *html.Node) bool {
+		return nth.Matches(st.siblingIndex(n).child)
 	}
 }
 
 // https://developer.mozilla.org/en-US/docs/Web/CSS/:nth-of-type
-func (c *compiler) nthOfType(s *pseudoClassSelector) func(n *html.Node) bool {
+func (c *compiler) nthOfType(s *pseudoClassSelector) matchFunc {
 	nth := c.compileNth(s)
 	if nth == nil {
 		return nil
 	}
-	return func(n *html.Node) bool {
-		var i int64 = 1
-		for s := n.PrevSibling; s != nil; s = s.PrevSibling {
-			if s.Type == html.ElementNode && s.DataAtom == n.DataAtom {
-				i++
-			}
-		}
-		return nth.matches(i)
+	return func(st *state, n *html.Node) bool {
+		return nth.Matches(st.siblingIndex(n).ofType)
 	}
 }
 
 // https://developer.mozilla.org/en-US/docs/Web/CSS/:nth-last-child
-func (c *compiler) nthLastChild(s *pseudoClassSelector) func(n *html.Node) bool {
+func (c *compiler) nthLastChild(s *pseudoClassSelector) matchFunc {
 	nth := c.compileNth(s)
 	if nth == nil {
 		return nil
 	}
-	return func(n *html.Node) bool {
-		var i int64 = 1
-		for s := n.NextSibling; s != nil; s = s.NextSibling {
-			if s.Type == html.ElementNode {
-				i++
-			}
-		}
-		return nth.matches(i)
+	return func(st *state, n *html.Node) bool {
+		return nth.Matches(st.siblingIndex(n).lastChild)
 	}
 }
 
 // https://developer.mozilla.org/en-US/docs/Web/CSS/:nth-last-of-type
-func (c *compiler) nthLastOfType(s *pseudoClassSelector) func(n *html.Node) bool {
+func (c *compiler) nthLastOfType(s *pseudoClassSelector) matchFunc {
 	nth := c.compileNth(s)
 	if nth == nil {
 		return nil
 	}
-	return func(n *html.Node) bool {
-		var i int64 = 1
-		for s := n.NextSibling; s != nil; s = s.NextSibling {
-			if s.Type == html.ElementNode && n.DataAtom == s.DataAtom {
-				i++
-			}
-		}
-		return nth.matches(i)
+	return func(st *state, n *html.Node) bool {
+		return nth.Matches(st.siblingIndex(n).lastOfType)
 	}
 }
 
-// nth holds a computed An+B value for :nth-child() and its associated selectors.
-type nth struct {
-	a int64
-	b int64
-}
-
-func (nth nth) matches(val int64) bool {
-	// Is there a value for "n" given "An+B=val" where "n" is non-negative?
-
-	// An + B = val
-	// An = val - B
-	// n = (val - B) / A
-	if nth.a == 0 {
-		return val == nth.b
-	}
-	return (val-nth.b)%nth.a == 0 && (val-nth.b)/nth.a >= 0
-}
-
-func (c *compiler) compileNth(s *pseudoClassSelector) *nth {
+func (c *compiler) compileNth(s *pseudoClassSelector) *Nth {
 	p := newParserFromTokens(s.args)
 	a, err := p.aNPlusB()
 	if err != nil {
@@ -557,56 +1191,136 @@ func (c *compiler) compileNth(s *pseudoClassSelector) *nth {
 	return a
 }
 
-// https://developer.mozilla.org/en-US/docs/Web/CSS/:empty
-func emptyMatcher(n *html.Node) bool {
-	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		if c.Type == html.ElementNode {
-			return false
+// anyLinkMatcher matches hyperlinks: a and area elements with an href
+// attribute, and SVG a elements with an href or xlink:href attribute.
+//
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:any-link
+// https://html.spec.whatwg.org/multipage/semantics-other.html#selector-link
+// https://www.w3.org/TR/SVG2/linking.html#AElement
+func anyLinkMatcher(n *html.Node) bool {
+	_, ok := linkHref(n)
+	return ok
+}
+
+// linkHref returns the destination of the hyperlink n, as written in the
+// document, or false if n isn't a hyperlink.
+func linkHref(n *html.Node) (string, bool) {
+	switch {
+	case n.Namespace == "" && (n.DataAtom == atom.A || n.DataAtom == atom.Area):
+	case n.Namespace == "svg" && n.Data == "a":
+		for _, a := range n.Attr {
+			if a.Namespace == "xlink" && a.Key == "href" {
+				return a.Val, true
+			}
 		}
+	default:
+		return "", false
 	}
-	return true
+	return attr(n, "href")
 }
 
-// https://developer.mozilla.org/en-US/docs/Web/CSS/:first-child
-func firstChildMatcher(n *html.Node) bool {
-	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
-		if s.Type == html.ElementNode {
+// linkMatcher matches hyperlinks that haven't been visited, according to
+// MatchContext.Visited.
+//
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:link
+func linkMatcher(s *state, n *html.Node) bool {
+	href, ok := linkHref(n)
+	return ok && (s.ctx.Visited == nil || !s.ctx.Visited(href))
+}
+
+// visitedMatcher matches hyperlinks that have been visited, according to
+// MatchContext.Visited.
+//
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:visited
+func visitedMatcher(s *state, n *html.Node) bool {
+	href, ok := linkHref(n)
+	return ok && s.ctx.Visited != nil && s.ctx.Visited(href)
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:defined
+func definedMatcher(s *state, n *html.Node) bool {
+	if n.Namespace != "" {
+		return true
+	}
+	name := n.Data
+	if !customElementName(name) {
+		// A customized built-in element, such as <button is="fancy-button">, is
+		// undefined until its definition is registered.
+		is, ok := attr(n, "is")
+		if !ok || !customElementName(is) {
+			return true
+		}
+		name = is
+	}
+	return s.ctx.Defined != nil && s.ctx.Defined(name)
+}
+
+// reservedElementNames are names that contain a hyphen, but aren't valid
+// custom element names.
+var reservedElementNames = map[string]bool{
+	"annotation-xml":   true,
+	"color-profile":    true,
+	"font-face":        true,
+	"font-face-src":    true,
+	"font-face-uri":    true,
+	"font-face-format": true,
+	"font-face-name":   true,
+	"missing-glyph":    true,
+}
+
+// customElementName reports if name is a valid custom element name, which
+// starts with a lowercase ASCII letter and contains a hyphen.
+//
+// https://html.spec.whatwg.org/multipage/custom-elements.html#valid-custom-element-name
+func customElementName(name string) bool {
+	if name == "" || name[0] < 'a' || name[0] > 'z' || reservedElementNames[name] {
+		return false
+	}
+	if !strings.Contains(name, "-") {
+		return false
+	}
+	for _, r := range name {
+		if 'A' <= r && r <= 'Z' {
 			return false
 		}
 	}
 	return true
 }
 
-// https://developer.mozilla.org/en-US/docs/Web/CSS/:first-of-type
-func firstOfTypeMatcher(n *html.Node) bool {
-	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
-		if s.Type != html.ElementNode {
-			continue
-		}
-		if s.DataAtom == n.DataAtom {
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:empty
+func emptyMatcher(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if isContent(c) {
 			return false
 		}
 	}
 	return true
 }
 
-// https://developer.mozilla.org/en-US/docs/Web/CSS/:last-child
-func lastChildMatcher(n *html.Node) bool {
-	for s := n.NextSibling; s != nil; s = s.NextSibling {
-		if s.Type == html.ElementNode {
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:first-child
+func firstChildMatcher(s *state, n *html.Node) bool {
+	return s.prevElementSibling(n) == nil
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:first-of-type
+func firstOfTypeMatcher(s *state, n *html.Node) bool {
+	for sib := s.prevElementSibling(n); sib != nil; sib = s.prevElementSibling(sib) {
+		if sameType(sib, n) {
 			return false
 		}
 	}
 	return true
 }
 
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:last-child
+func lastChildMatcher(s *state, n *html.Node) bool {
+	return s.nextElementSibling(n) == nil
+}
+
 // https://developer.mozilla.org/en-US/docs/Web/CSS/:last-of-type
-func lastOfTypeMatcher(n *html.Node) bool {
-	for s := n.NextSibling; s != nil; s = s.NextSibling {
-		if s.Type != html.ElementNode {
-			continue
-		}
-		if s.DataAtom == n.DataAtom {
+func lastOfTypeMatcher(s *state, n *html.Node) bool {
+	for sib := s.nextElementSibling(n); sib != nil; sib = s.nextElementSibling(sib) {
+		if sameType(sib, n) {
 			return false
 		}
 	}
@@ -614,37 +1328,232 @@ func lastOfTypeMatcher(n *html.Node) bool {
 }
 
 // https://developer.mozilla.org/en-US/docs/Web/CSS/:only-child
-func onlyChildMatcher(n *html.Node) bool {
-	return firstChildMatcher(n) && lastChildMatcher(n)
+func onlyChildMatcher(s *state, n *html.Node) bool {
+	return firstChildMatcher(s, n) && lastChildMatcher(s, n)
 }
 
 // https://developer.mozilla.org/en-US/docs/Web/CSS/:only-of-type
-func onlyOfTypeMatcher(n *html.Node) bool {
-	return firstOfTypeMatcher(n) && lastOfTypeMatcher(n)
+func onlyOfTypeMatcher(s *state, n *html.Node) bool {
+	return firstOfTypeMatcher(s, n) && lastOfTypeMatcher(s, n)
 }
 
 // https://developer.mozilla.org/en-US/docs/Web/CSS/:root
 func rootMatcher(n *html.Node) bool {
-	return n.Parent == nil
+	return n.Parent == nil || n.Parent.Type == html.DocumentNode
 }
 
-type attributeSelectorMatcher struct {
-	ns namespaceMatcher
-	fn func(key, val string) bool
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:scope
+func scopeMatcher(s *state, n *html.Node) bool {
+	return n == s.scopeElement()
 }
 
-func (a *attributeSelectorMatcher) match(n *html.Node) bool {
-	for _, attr := range n.Attr {
-		if a.ns.match(attr.Namespace) && a.fn(attr.Key, attr.Val) {
+// scopeElement returns the element matched by :scope: the scope passed to
+// SelectFrom, or otherwise the node selection started from. If that's a
+// document, :scope matches the root element, like :root.
+func (s *state) scopeElement() *html.Node {
+	if s.scope != nil {
+		return s.scope
+	}
+	if s.root != nil && s.root.Type == html.DocumentNode {
+		return firstElementChild(s.root)
+	}
+	return s.root
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:target
+func targetMatcher(s *state, n *html.Node) bool {
+	if s.ctx.Fragment == "" {
+		return false
+	}
+	root := treeRoot(n)
+	target, ok := s.targets[root]
+	if !ok {
+		target = indicatedElement(root, s.ctx.Fragment)
+		if s.targets == nil {
+			s.targets = map[*html.Node]*html.Node{}
+		}
+		s.targets[root] = target
+	}
+	return n == target
+}
+
+// indicatedElement returns the element within root identified by a URL
+// fragment: the first element whose id is the fragment, or otherwise the first
+// a element with that name. If neither is found, the percent-decoded fragment
+// is tried.
+//
+// https://html.spec.whatwg.org/multipage/browsing-the-web.html#find-a-potential-indicated-element
+func indicatedElement(root *html.Node, fragment string) *html.Node {
+	candidates := []string{fragment}
+	if decoded, err := url.PathUnescape(fragment); err == nil && decoded != fragment {
+		candidates = append(candidates, decoded)
+	}
+	for _, frag := range candidates {
+		var byID, byName *html.Node
+		walkElements(root, func(e *html.Node) bool {
+			if id, ok := attr(e, "id"); ok && id == frag {
+				byID = e
+				return false
+			}
+			if byName == nil && e.Namespace == "" && e.DataAtom == atom.A {
+				if name, ok := attr(e, "name"); ok && name == frag {
+					byName = e
+				}
+			}
 			return true
+		})
+		if byID != nil {
+			return byID
+		}
+		if byName != nil {
+			return byName
 		}
 	}
+	return nil
+}
+
+// extensionMatchers holds the non-standard pseudo-classes without arguments
+// enabled by WithExtensions.
+var extensionMatchers = map[string]func(n *html.Node) bool{
+	"button":   buttonMatcher,
+	"checkbox": inputTypeMatcher("checkbox"),
+	"header":   headerMatcher,
+	"hidden":   hiddenMatcher,
+	"input":    inputMatcher,
+	"radio":    inputTypeMatcher("radio"),
+	"submit":   submitMatcher,
+	"visible":  visibleMatcher,
+}
+
+// headerMatcher matches heading elements, h1 through h6.
+//
+// https://api.jquery.com/header-selector/
+func headerMatcher(n *html.Node) bool {
+	if n.Namespace != "" {
+		return false
+	}
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		return true
+	}
 	return false
 }
 
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:checked
+func (c *compiler) checked() matchFunc {
+	aria := c.opts.aria
+	// Whether a radio button is checked depends on the other buttons in its
+	// group, anywhere in the tree, so incremental updates can't be scoped.
+	c.relational = true
+	return func(s *state, n *html.Node) bool {
+		if aria && ariaState(n, "aria-checked") {
+			return true
+		}
+		return s.checkedness(n)
+	}
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:disabled
+func (c *compiler) disabled() func(n *html.Node) bool {
+	aria := c.opts.aria
+	return func(n *html.Node) bool {
+		if aria && ariaState(n, "aria-disabled") {
+			return true
+		}
+		return canBeDisabled(n) && actuallyDisabled(n)
+	}
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:enabled
+func (c *compiler) enabled() func(n *html.Node) bool {
+	disabled := c.disabled()
+	return func(n *html.Node) bool {
+		return canBeDisabled(n) && !disabled(n)
+	}
+}
+
+// canBeDisabled reports if n is an element that supports the disabled
+// attribute.
+//
+// https://html.spec.whatwg.org/multipage/semantics-other.html#concept-element-disabled
+func canBeDisabled(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Button, atom.Input, atom.Select, atom.Textarea, atom.Optgroup, atom.Option, atom.Fieldset:
+		return n.Namespace == ""
+	}
+	return false
+}
+
+// ariaState reports if the ARIA state attribute key of n is "true".
+func ariaState(n *html.Node, key string) bool {
+	val, ok := attr(n, key)
+	return ok && strings.TrimSpace(strings.ToLower(val)) == "true"
+}
+
+type attributeSelectorMatcher struct {
+	ns namespaceMatcher
+	// key is the attribute name, as written in the selector. htmlKey is the
+	// name used for HTML elements, which is lowercased unless attribute names
+	// are case-sensitive, matching the HTML parser.
+	key     string
+	htmlKey string
+	fn      func(val string) bool
+	// fold is set for case-insensitive matching, and causes attribute keys
+	// and values to be lowercased before being compared.
+	fold bool
+	// nfc causes attribute values to be NFC normalized before being passed
+	// to fn.
+	nfc bool
+}
+
+func (a *attributeSelectorMatcher) match(st *state, n *html.Node) bool {
+	_, ok := a.find(st, n)
+	return ok
+}
+
+// find returns the index of the first attribute of n satisfying the matcher.
+func (a *attributeSelectorMatcher) find(st *state, n *html.Node) (int, bool) {
+	attrs := n.Attr
+	if a.fold {
+		if st.session != nil {
+			attrs = st.session.lowerAttrs(n)
+		} else {
+			attrs = lowerAttrs(n.Attr)
+		}
+	}
+	if a.nfc {
+		attrs = nfcAttrs(attrs)
+	}
+	// Names of attributes on foreign elements, such as SVG's "viewBox", keep
+	// their case.
+	key := a.key
+	if n.Namespace == "" {
+		key = a.htmlKey
+	}
+	for i, attr := range attrs {
+		if attr.Key == key && a.ns.match(attr.Namespace) && a.fn(attr.Val) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// lowerAttrs returns a copy of attrs with keys and values lowercased.
+func lowerAttrs(attrs []html.Attribute) []html.Attribute {
+	lower := make([]html.Attribute, len(attrs))
+	for i, a := range attrs {
+		lower[i] = html.Attribute{
+			Namespace: a.Namespace,
+			Key:       strings.ToLower(a.Key),
+			Val:       strings.ToLower(a.Val),
+		}
+	}
+	return lower
+}
+
 func (c *compiler) attributeSelector(s *attributeSelector) *attributeSelectorMatcher {
 	m := &attributeSelectorMatcher{
-		ns: newNamespaceMatcher(s.wqName.hasPrefix, s.wqName.prefix),
+		ns: c.namespace(s.pos, s.wqName.hasPrefix, s.wqName.prefix, attrNamespaces),
 	}
 	key := s.wqName.value
 	val := s.val
@@ -653,16 +1562,31 @@ func (c *compiler) attributeSelector(s *attributeSelector) *attributeSelectorMat
 		key = strings.ToLower(key)
 		val = strings.ToLower(val)
 	}
+	if c.opts.nfc {
+		val = nfc(val)
+		m.nfc = true
+	}
+	m.key = key
+	m.htmlKey = key
+	if !c.opts.caseSensitive {
+		m.htmlKey = strings.ToLower(key)
+	}
+
+	m.fold = s.modifier
+
+	if strings.HasPrefix(s.matcher, ":") {
+		if m.fn = c.comparator(s); m.fn == nil {
+			return nil
+		}
+		return m
+	}
 
 	// https://developer.mozilla.org/en-US/docs/Web/CSS/Attribute_selectors
 	switch s.matcher {
 	case "=":
-		m.fn = func(k, v string) bool { return k == key && v == val }
+		m.fn = func(v string) bool { return v == val }
 	case "~=":
-		m.fn = func(k, v string) bool {
-			if k != key {
-				return false
-			}
+		m.fn = func(v string) bool {
 			for _, f := range strings.Fields(v) {
 				if f == val {
 					return true
@@ -674,35 +1598,44 @@ func (c *compiler) attributeSelector(s *attributeSelector) *attributeSelectorMat
 		// "Represents elements with an attribute name of attr whose value can be
 		// exactly value or can begin with value immediately followed by a hyphen,
 		// - (U+002D). It is often used for language subcode matches."
-		m.fn = func(k, v string) bool {
-			return k == key && (v == val || strings.HasPrefix(v, val+"-"))
+		m.fn = func(v string) bool {
+			return v == val || strings.HasPrefix(v, val+"-")
 		}
 	case "^=":
-		m.fn = func(k, v string) bool {
-			return k == key && strings.HasPrefix(v, val)
+		m.fn = func(v string) bool {
+			return strings.HasPrefix(v, val)
 		}
 	case "$=":
-		m.fn = func(k, v string) bool {
-			return k == key && strings.HasSuffix(v, val)
+		m.fn = func(v string) bool {
+			return strings.HasSuffix(v, val)
 		}
 	case "*=":
-		m.fn = func(k, v string) bool {
-			return k == key && strings.Contains(v, val)
+		m.fn = func(v string) bool {
+			return strings.Contains(v, val)
+		}
+	case "%=":
+		if !c.opts.extensions {
+			c.errorf(s.pos, "attribute matcher %%= requires extensions to be enabled")
+			return nil
+		}
+		// Patterns aren't lowercased by the "i" modifier, since doing so
+		// could change the meaning of escapes such as "\D".
+		pattern := s.val
+		if s.modifier {
+			pattern = "(?i)" + pattern
 		}
+		fn, err := compileRegexp(pattern)
+		if err != nil {
+			c.errorf(s.pos, "invalid regular expression %q: %v", s.val, err)
+			return nil
+		}
+		m.fn = fn
 	case "":
-		m.fn = func(k, v string) bool { return k == key }
+		m.fn = func(v string) bool { return true }
 	default:
 		c.errorf(s.pos, "unsupported attribute matcher: %s", s.matcher)
 		return nil
 	}
-	if s.modifier {
-		fn := m.fn
-		m.fn = func(k, v string) bool {
-			k = strings.ToLower(k)
-			v = strings.ToLower(v)
-			return fn(k, v)
-		}
-	}
 	return m
 }
 
@@ -712,6 +1645,23 @@ type namespaceMatcher struct {
 	namespace   string
 }
 
+// Namespaces assigned to elements and attributes by the HTML parser. Since
+// selectors can't declare namespaces, prefixes are compared directly against
+// these values.
+var (
+	elementNamespaces = map[string]bool{"svg": true, "math": true}
+	attrNamespaces    = map[string]bool{"xlink": true, "xml": true, "xmlns": true}
+)
+
+// namespace returns a matcher for a namespace prefix, warning if the prefix
+// doesn't correspond to a namespace the HTML parser assigns.
+func (c *compiler) namespace(pos int, hasPrefix bool, prefix string, known map[string]bool) namespaceMatcher {
+	if hasPrefix && prefix != "" && prefix != "*" && !known[prefix] {
+		c.gapf(pos, GapNamespace, prefix+"|", "namespace prefix %q unresolved and will never match", prefix)
+	}
+	return newNamespaceMatcher(hasPrefix, prefix)
+}
+
 func newNamespaceMatcher(hasPrefix bool, prefix string) namespaceMatcher {
 	if !hasPrefix {
 		return namespaceMatcher{}
@@ -737,13 +1687,36 @@ func (n *namespaceMatcher) match(ns string) bool {
 
 type typeSelectorMatcher struct {
 	allAtoms bool
-	atom     atom.Atom
-	ns       namespaceMatcher
+	// atom is the atom of the lowercased name, or zero for names without
+	// one, such as custom elements.
+	atom atom.Atom
+	// name is the name as written, and lower the name in lowercase.
+	name  string
+	lower string
+	ns    namespaceMatcher
 }
 
+// match compares the name of n to the type selector. Names of HTML elements,
+// which the parser lowercases, are matched ASCII case-insensitively, while
+// names of foreign elements, such as SVG's "foreignObject", must match
+// exactly.
+//
+// https://www.w3.org/TR/selectors-4/#case-sensitive
 func (t *typeSelectorMatcher) match(n *html.Node) (ok bool) {
-	if !(t.allAtoms || t.atom == n.DataAtom) {
-		return false
+	switch {
+	case t.allAtoms:
+	case n.Namespace != "":
+		if t.name != n.Data {
+			return false
+		}
+	case t.atom != 0:
+		if t.atom != n.DataAtom {
+			return false
+		}
+	default:
+		if n.DataAtom != 0 || t.lower != n.Data {
+			return false
+		}
 	}
 	return t.ns.match(n.Namespace)
 }
@@ -753,14 +1726,27 @@ func (c *compiler) typeSelector(s *typeSelector) *typeSelectorMatcher {
 	if s.value == "*" {
 		m.allAtoms = true
 	} else {
-		a := atom.Lookup([]byte(s.value))
-		if a == 0 {
-			if c.errorf(s.pos, "unrecognized node name: %s", s.value) {
-				return nil
+		m.name = s.value
+		m.lower = lowerASCII(s.value)
+		m.atom = atom.Lookup([]byte(m.lower))
+	}
+	m.ns = c.namespace(s.pos, s.hasPrefix, s.prefix, elementNamespaces)
+	return m
+}
+
+// lowerASCII returns s with ASCII letters lowercased, the same way the HTML
+// tokenizer lowercases tag names. Other characters are left as written.
+func lowerASCII(s string) string {
+	for i := 0; i < len(s); i++ {
+		if 'A' <= s[i] && s[i] <= 'Z' {
+			b := []byte(s)
+			for j := i; j < len(b); j++ {
+				if 'A' <= b[j] && b[j] <= 'Z' {
+					b[j] += 'a' - 'A'
+				}
 			}
+			return string(b)
 		}
-		m.atom = a
 	}
-	m.ns = newNamespaceMatcher(s.hasPrefix, s.prefix)
-	return m
+	return s
 }
@@ -12,7 +12,9 @@ import (
 	"golang.org/x/net/html"
 )
 
-func (s *Selector) String() string {
+// formatSelector prints the internal representation of a compiled selector
+// for debugging test failures.
+func formatSelector(s *Selector) string {
 	var b strings.Builder
 	formatValue(reflect.ValueOf(s), &b, "")
 	return b.String()
@@ -104,6 +106,16 @@ var selectorTests = []selectorTest{
 		`<h1><a></a></h1>`,
 		[]string{`<a></a>`},
 	},
+	{
+		"A",
+		`<h1><a></a></h1>`,
+		[]string{`<a></a>`},
+	},
+	{
+		"X-Card",
+		`<h1><x-card></x-card></h1>`,
+		[]string{`<x-card></x-card>`},
+	},
 	{
 		"body",
 		`<h1><a></a></h1>`,
@@ -276,9 +288,13 @@ var selectorTests = []selectorTest{
 		[]string{
 			`<a href="http://bar"></a>`,
 			`<a href="http://foo"></a>`,
-			`<a href="http://foo"></a>`,
 		},
 	},
+	{
+		`.\.foo`,
+		`<div class=".foo"></div><div class="foo"></div>`,
+		[]string{`<div class=".foo"></div>`},
+	},
 	{
 		"div > a",
 		`
@@ -353,6 +369,31 @@ var selectorTests = []selectorTest{
 			`,
 		[]string{`<div class="bar">  </div>`},
 	},
+	{
+		"[HREF^=https], [dataFoo]",
+		`<a href="https://example.com"></a><a href="http://example.com"></a><div datafoo></div>`,
+		[]string{`<a href="https://example.com"></a>`, `<div datafoo=""></div>`},
+	},
+	{
+		"p:empty",
+		`<p><!-- note --></p><p>note</p><p> <!-- note --> </p><p><span></span></p>`,
+		[]string{`<p><!-- note --></p>`, `<p> <!-- note --> </p>`},
+	},
+	{
+		"p:first-child, p:last-child",
+		`<div>text<!-- a --><p>1</p><p>2</p><!-- b -->text</div>`,
+		[]string{`<p>1</p>`, `<p>2</p>`},
+	},
+	{
+		"x-item:first-of-type, x-item:nth-last-of-type(1)",
+		`<x-other></x-other><x-item>1</x-item><x-item>2</x-item><x-other></x-other>`,
+		[]string{`<x-item>1</x-item>`, `<x-item>2</x-item>`},
+	},
+	{
+		"p:nth-child(2) + p",
+		`<div><p>1</p><!-- a -->text<p>2</p> <p>3</p></div>`,
+		[]string{`<p>3</p>`},
+	},
 	{
 		":root",
 		`<html><head></head><body></body></html>`,
@@ -767,6 +808,97 @@ var selectorTests = []selectorTest{
 			`<li>7</li>`,
 		},
 	},
+	{
+		"div + a",
+		`<a id="1"></a><div></div><a id="2"></a><a id="3"></a>`,
+		[]string{`<a id="2"></a>`},
+	},
+	{
+		"div ~ a",
+		`<a id="1"></a><div></div><a id="2"></a><a id="3"></a>`,
+		[]string{`<a id="2"></a>`, `<a id="3"></a>`},
+	},
+	{
+		"p, div",
+		`<div></div><p></p>`,
+		[]string{`<div></div>`, `<p></p>`},
+	},
+	{
+		":root",
+		`<p></p>`,
+		[]string{`<html><head></head><body><p></p></body></html>`},
+	},
+	{
+		":checked",
+		`<input type="checkbox" checked><input type="checkbox"><div aria-checked="true"></div>`,
+		[]string{`<input type="checkbox" checked=""/>`},
+	},
+	{
+		":disabled",
+		`<button disabled></button><button></button><div disabled></div><span aria-disabled="true"></span>`,
+		[]string{`<button disabled=""></button>`},
+	},
+	{
+		":enabled",
+		`<button disabled></button><button></button><div></div>`,
+		[]string{`<button></button>`},
+	},
+	{
+		"div:not(.ad, [hidden])",
+		`<div class="ad"></div><div hidden></div><div id="content"></div>`,
+		[]string{`<div id="content"></div>`},
+	},
+	{
+		"li:not(:first-child)",
+		`<ul><li>a</li><li>b</li><li>c</li></ul>`,
+		[]string{`<li>b</li>`, `<li>c</li>`},
+	},
+	{
+		"p:not(div > p)",
+		`<div><p>a</p></div><section><p>b</p></section>`,
+		[]string{`<p>b</p>`},
+	},
+	{
+		":any-link",
+		`<a href="/">a</a><a>b</a><map><area href="/c"/></map><div href="/d"></div>`,
+		[]string{`<a href="/">a</a>`, `<area href="/c"/>`},
+	},
+	{
+		"a:link",
+		`<a href="">a</a><a name="b">b</a>`,
+		[]string{`<a href="">a</a>`},
+	},
+	{
+		":link",
+		`<svg><a href="/">a</a><a xlink:href="/b">b</a><a>c</a></svg>`,
+		[]string{`<a href="/">a</a>`, `<a xlink:href="/b">b</a>`},
+	},
+}
+
+// TestTypeSelectorCase checks that type selectors match the names of HTML
+// elements case-insensitively, and the names of foreign elements exactly.
+func TestTypeSelectorCase(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<a></a><svg><foreignObject></foreignObject><a></a></svg>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{"A", []string{`<a></a>`}},
+		{"svg|A", []string{}},
+		{"svg|a", []string{`<a></a>`}},
+		{"foreignObject", []string{`<foreignObject></foreignObject>`}},
+		{"FOREIGNOBJECT", []string{}},
+		{"foreignobject", []string{}},
+	}
+	for _, test := range tests {
+		got := renderNodes(t, MustParse(test.sel).Select(root))
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Selecting %q returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
 }
 
 func TestSelector(t *testing.T) {
@@ -801,7 +933,7 @@ func TestSelector(t *testing.T) {
 			got = append(got, b.String())
 		}
 		if diff := cmp.Diff(test.want, got); diff != "" {
-			t.Errorf("Selecting %q (%s) from %s returned diff (-want, +got): %s", test.sel, s, in, diff)
+			t.Errorf("Selecting %q (%s) from %s returned diff (-want, +got): %s", test.sel, formatSelector(s), in, diff)
 		}
 	}
 }
@@ -812,6 +944,11 @@ func TestBadSelector(t *testing.T) {
 		pos int
 	}{
 		{":nth-child(3+4n)", 0},
+		// An escaped '*' is a name, not the universal selector.
+		{`\*`, 0},
+		{`a > \2A`, 4},
+		{`svg|\*`, 4},
+		{`\*|a`, 0},
 	}
 	for _, test := range tests {
 		_, err := Parse(test.sel)
@@ -829,3 +966,223 @@ func TestBadSelector(t *testing.T) {
 		}
 	}
 }
+
+func TestInvalidRoot(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<!DOCTYPE html><!-- c --><p>text</p>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	doctype := doc.FirstChild
+	comment := doctype.NextSibling
+	text := doc.LastChild.LastChild.FirstChild.FirstChild
+
+	tests := []struct {
+		name string
+		root *html.Node
+		want string
+	}{
+		{"nil", nil, "css: root is nil"},
+		{"doctype", doctype, "css: root is a doctype node, not a document or element"},
+		{"comment", comment, "css: root is a comment node, not a document or element"},
+		{"text", text, "css: root is a text node, not a document or element"},
+	}
+	sel := MustParse("p, :root, p::part(x)")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := sel.Select(test.root); got == nil || len(got) != 0 {
+				t.Errorf("Select() = %v, want empty slice", got)
+			}
+			if got := sel.SelectDetailed(test.root); len(got) != 0 {
+				t.Errorf("SelectDetailed() = %v, want no matches", got)
+			}
+			if got := sel.SelectLast(test.root); got != nil {
+				t.Errorf("SelectLast() = %v, want nil", got)
+			}
+			if got := NewSession(test.root).Select(sel); len(got) != 0 {
+				t.Errorf("Session.Select() = %v, want no matches", got)
+			}
+			if got := NewTreeWalker(test.root, sel).NextNode(); got != nil {
+				t.Errorf("TreeWalker.NextNode() = %v, want nil", got)
+			}
+			if got, err := QuerySelectorAll(test.root, "p"); err != nil || len(got) != 0 {
+				t.Errorf("QuerySelectorAll() = %v, %v, want no matches", got, err)
+			}
+			if got, err := Matches(test.root, "p"); err != nil || got {
+				t.Errorf("Matches() = %v, %v, want false", got, err)
+			}
+
+			_, err := sel.SelectChecked(test.root)
+			var rerr *RootError
+			if !errors.As(err, &rerr) {
+				t.Fatalf("SelectChecked() returned %T %v, want *RootError", err, err)
+			}
+			if rerr.Node != test.root {
+				t.Errorf("RootError.Node = %v, want %v", rerr.Node, test.root)
+			}
+			if got := err.Error(); got != test.want {
+				t.Errorf("RootError.Error() = %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	if _, err := sel.SelectChecked(doc); err != nil {
+		t.Errorf("SelectChecked(document) failed: %v", err)
+	}
+	if got, err := Closest(nil, "p"); err != nil || got != nil {
+		t.Errorf("Closest(nil) = %v, %v, want nil", got, err)
+	}
+}
+
+func TestHeader(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<h1 id="h1"></h1><header id="header"></header><h2 id="h2"></h2>
+<section><h3 id="h3"></h3><h4 id="h4"></h4><h5 id="h5"></h5><h6 id="h6"></h6></section>
+<hgroup id="hgroup"></hgroup>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{":header", []string{"h1", "h2", "h3", "h4", "h5", "h6"}},
+		{"section > :header:first-child", []string{"h3"}},
+		{":is(:header, header)", []string{"h1", "header", "h2", "h3", "h4", "h5", "h6"}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel, WithExtensions())
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		var got []string
+		for _, n := range s.Select(root) {
+			id, _ := attr(n, "id")
+			got = append(got, id)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+	if _, err := Parse(":header"); err == nil {
+		t.Errorf("Parse() of :header without WithExtensions() didn't return an error")
+	}
+}
+
+func TestVisited(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<a id="home" href="/"></a><a id="about" href="/about"></a><a id="anchor"></a>
+<map><area id="area" href="/about"></map>
+<svg><a id="svg" xlink:href="/"></a></svg>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	visited := map[string]bool{"/": true}
+	ctx := MatchContext{Visited: func(href string) bool { return visited[href] }}
+
+	tests := []struct {
+		sel  string
+		ctx  MatchContext
+		want []string
+	}{
+		{":visited", ctx, []string{"home", "svg"}},
+		{":link", ctx, []string{"about", "area"}},
+		{":any-link", ctx, []string{"home", "about", "area", "svg"}},
+		{"a:not(:visited)", ctx, []string{"about", "anchor"}},
+		{":visited", MatchContext{}, nil},
+		{":link", MatchContext{}, []string{"home", "about", "area", "svg"}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		var got []string
+		for _, n := range s.SelectWithContext(root, test.ctx) {
+			id, _ := attr(n, "id")
+			got = append(got, id)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+}
+
+func TestScope(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<ul id="outer"><li id="a"><ul id="inner"><li id="b"></li></ul></li><li id="c"></li></ul>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	byID := func(id string) *html.Node {
+		for _, n := range MustParse("#" + id).Select(root) {
+			return n
+		}
+		t.Fatalf("no element with id %q", id)
+		return nil
+	}
+	ids := func(nodes []*html.Node) []string {
+		var got []string
+		for _, n := range nodes {
+			id, _ := attr(n, "id")
+			got = append(got, id)
+		}
+		return got
+	}
+	outer, inner := byID("outer"), byID("inner")
+
+	tests := []struct {
+		name string
+		got  []*html.Node
+		want []string
+	}{
+		{"Select(outer)", MustParse(":scope > li").Select(outer), []string{"a", "c"}},
+		{"Select(inner)", MustParse(":scope > li").Select(inner), []string{"b"}},
+		{"SelectFrom(inner, root)", MustParse(":scope > li").SelectFrom(inner, root), []string{"b"}},
+		{"SelectFrom(inner, root) descendant", MustParse("li :scope li").SelectFrom(inner, root), []string{"b"}},
+		{"SelectFrom(inner, outer)", MustParse(":scope").SelectFrom(inner, outer), []string{"inner"}},
+		{"Select(root)", MustParse(":scope").Select(root), []string{""}},
+		{"Select(root) :root", MustParse(":scope:root > body > ul").Select(root), []string{"outer"}},
+		{"SelectFrom(inner, root) :has()", MustParse("li:has(> :scope)").SelectFrom(inner, root), []string{"a"}},
+	}
+	for _, test := range tests {
+		if diff := cmp.Diff(test.want, ids(test.got)); diff != "" {
+			t.Errorf("%s returned diff (-want, +got): %s", test.name, diff)
+		}
+	}
+}
+
+func TestTarget(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<a id="top" name="intro"></a>
+<section id="intro"></section>
+<section id="section-2"></section><section id="section-2"></section>
+<a id="named" name="notes"></a>
+<h2 id="caf&eacute;"></h2>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	tests := []struct {
+		fragment string
+		want     []string
+	}{
+		{"", nil},
+		{"section-2", []string{"section-2"}},
+		{"intro", []string{"intro"}},
+		{"notes", []string{"named"}},
+		{"caf%C3%A9", []string{"café"}},
+		{"missing", nil},
+	}
+	s := MustParse(":target")
+	for _, test := range tests {
+		var got []string
+		for _, n := range s.SelectWithContext(root, MatchContext{Fragment: test.fragment}) {
+			id, _ := attr(n, "id")
+			got = append(got, id)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Fragment %q returned diff (-want, +got): %s", test.fragment, diff)
+		}
+	}
+}
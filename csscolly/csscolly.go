@@ -0,0 +1,135 @@
+// Package csscolly allows compiled selectors to be used with the colly
+// scraping framework.
+//
+// colly evaluates selectors passed to methods such as Collector.OnHTML and
+// HTMLElement.ForEach with goquery. The functions in this package accept a
+// *css.Selector instead, supporting the selectors this module implements:
+//
+//	sel := css.MustParse(`tr:nth-child(odd) > td a[href^="https://"]`)
+//	csscolly.OnHTML(c, sel, func(e *colly.HTMLElement) {
+//		fmt.Println(e.Attr("href"))
+//	})
+package csscolly
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/ericchiang/css"
+	"github.com/gocolly/colly/v2"
+	"golang.org/x/net/html"
+)
+
+// OnHTML registers a function to be called on every HTML element matched by
+// sel. It's equivalent to Collector.OnHTML.
+func OnHTML(c *colly.Collector, sel *css.Selector, fn colly.HTMLCallback) {
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		for _, root := range e.DOM.Nodes {
+			// Select from the document so selectors such as ":root" can match
+			// the html element.
+			for root.Parent != nil {
+				root = root.Parent
+			}
+			for i, n := range sel.Select(root) {
+				fn(newElement(e, n, i))
+			}
+		}
+	})
+}
+
+// ForEach calls fn for each descendant of e matched by sel. It's equivalent to
+// HTMLElement.ForEach.
+func ForEach(e *colly.HTMLElement, sel *css.Selector, fn func(int, *colly.HTMLElement)) {
+	for i, n := range find(e, sel) {
+		fn(i, newElement(e, n, i))
+	}
+}
+
+// ForEachWithBreak is like ForEach, but stops iterating when fn returns false.
+// It's equivalent to HTMLElement.ForEachWithBreak.
+func ForEachWithBreak(e *colly.HTMLElement, sel *css.Selector, fn func(int, *colly.HTMLElement) bool) {
+	for i, n := range find(e, sel) {
+		if !fn(i, newElement(e, n, i)) {
+			return
+		}
+	}
+}
+
+// ChildText returns the concatenated and stripped text content of the
+// descendants of e matched by sel. It's equivalent to HTMLElement.ChildText.
+func ChildText(e *colly.HTMLElement, sel *css.Selector) string {
+	return strings.TrimSpace(selection(e, find(e, sel)).Text())
+}
+
+// ChildTexts returns the stripped text content of each descendant of e
+// matched by sel. It's equivalent to HTMLElement.ChildTexts.
+func ChildTexts(e *colly.HTMLElement, sel *css.Selector) []string {
+	var texts []string
+	for _, n := range find(e, sel) {
+		texts = append(texts, strings.TrimSpace(selection(e, []*html.Node{n}).Text()))
+	}
+	return texts
+}
+
+// ChildAttr returns the stripped value of an attribute of the first
+// descendant of e matched by sel. It's equivalent to HTMLElement.ChildAttr.
+func ChildAttr(e *colly.HTMLElement, sel *css.Selector, attr string) string {
+	for _, n := range find(e, sel) {
+		if val, ok := attrValue(n, attr); ok {
+			return strings.TrimSpace(val)
+		}
+		break
+	}
+	return ""
+}
+
+// ChildAttrs returns the stripped values of an attribute of each descendant of
+// e matched by sel. Elements without the attribute are skipped. It's
+// equivalent to HTMLElement.ChildAttrs.
+func ChildAttrs(e *colly.HTMLElement, sel *css.Selector, attr string) []string {
+	var vals []string
+	for _, n := range find(e, sel) {
+		if val, ok := attrValue(n, attr); ok {
+			vals = append(vals, strings.TrimSpace(val))
+		}
+	}
+	return vals
+}
+
+// find returns the descendants of e matched by sel in document order.
+func find(e *colly.HTMLElement, sel *css.Selector) []*html.Node {
+	var nodes []*html.Node
+	for _, root := range e.DOM.Nodes {
+		for _, n := range sel.Select(root) {
+			// Like goquery's Find, only consider descendants of the element.
+			if n != root {
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	return css.SortNodes(nodes)
+}
+
+// selection returns the nodes as a selection within e's document.
+func selection(e *colly.HTMLElement, nodes []*html.Node) *goquery.Selection {
+	s := e.DOM.FindNodes(nodes...)
+	for _, n := range nodes {
+		if e.DOM.IsNodes(n) {
+			s = s.AddNodes(n)
+		}
+	}
+	return s
+}
+
+func newElement(e *colly.HTMLElement, n *html.Node, i int) *colly.HTMLElement {
+	return colly.NewHTMLElementFromSelectionNode(e.Response, selection(e, []*html.Node{n}), n, i)
+}
+
+func attrValue(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
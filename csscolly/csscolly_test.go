@@ -0,0 +1,103 @@
+package csscolly
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ericchiang/css"
+	"github.com/gocolly/colly/v2"
+	"github.com/google/go-cmp/cmp"
+)
+
+const testPage = `<!DOCTYPE html>
+<html>
+<body>
+<ul>
+	<li><a href=" /a ">A</a></li>
+	<li class="skip"><a href="/b">B</a></li>
+	<li><a>C</a></li>
+</ul>
+</body>
+</html>`
+
+func visit(t *testing.T, fn func(c *colly.Collector)) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(testPage))
+	}))
+	defer s.Close()
+
+	c := colly.NewCollector()
+	fn(c)
+	if err := c.Visit(s.URL); err != nil {
+		t.Fatalf("visiting test server: %v", err)
+	}
+}
+
+func TestOnHTML(t *testing.T) {
+	var got []string
+	var indexes []int
+	visit(t, func(c *colly.Collector) {
+		OnHTML(c, css.MustParse("li:nth-child(odd) > a"), func(e *colly.HTMLElement) {
+			got = append(got, e.Text)
+			indexes = append(indexes, e.Index)
+		})
+		OnHTML(c, css.MustParse(":root"), func(e *colly.HTMLElement) {
+			got = append(got, e.Name)
+		})
+	})
+	want := []string{"A", "C", "html"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("OnHTML() returned diff (-want, +got): %s", diff)
+	}
+	if diff := cmp.Diff([]int{0, 1}, indexes); diff != "" {
+		t.Errorf("OnHTML() returned index diff (-want, +got): %s", diff)
+	}
+}
+
+func TestElementHelpers(t *testing.T) {
+	var (
+		texts     []string
+		text      string
+		attr      string
+		attrs     []string
+		forEach   []string
+		withBreak []string
+	)
+	visit(t, func(c *colly.Collector) {
+		OnHTML(c, css.MustParse("ul"), func(e *colly.HTMLElement) {
+			a := css.MustParse("a")
+			texts = ChildTexts(e, a)
+			text = ChildText(e, css.MustParse("li:first-child"))
+			attr = ChildAttr(e, a, "href")
+			attrs = ChildAttrs(e, a, "href")
+			ForEach(e, css.MustParse("li"), func(i int, e *colly.HTMLElement) {
+				forEach = append(forEach, ChildText(e, css.MustParse("a")))
+			})
+			ForEachWithBreak(e, a, func(i int, e *colly.HTMLElement) bool {
+				withBreak = append(withBreak, e.Text)
+				return i < 1
+			})
+		})
+	})
+
+	if diff := cmp.Diff([]string{"A", "B", "C"}, texts); diff != "" {
+		t.Errorf("ChildTexts() returned diff (-want, +got): %s", diff)
+	}
+	if text != "A" {
+		t.Errorf("ChildText() = %q, want %q", text, "A")
+	}
+	if attr != "/a" {
+		t.Errorf("ChildAttr() = %q, want %q", attr, "/a")
+	}
+	if diff := cmp.Diff([]string{"/a", "/b"}, attrs); diff != "" {
+		t.Errorf("ChildAttrs() returned diff (-want, +got): %s", diff)
+	}
+	if diff := cmp.Diff([]string{"A", "B", "C"}, forEach); diff != "" {
+		t.Errorf("ForEach() returned diff (-want, +got): %s", diff)
+	}
+	if diff := cmp.Diff([]string{"A", "B"}, withBreak); diff != "" {
+		t.Errorf("ForEachWithBreak() returned diff (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,124 @@
+// Package csstest provides assertions for tests that check HTML output using
+// selectors.
+//
+//	func TestPage(t *testing.T) {
+//		root, err := html.Parse(strings.NewReader(renderPage()))
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		csstest.AssertText(t, root, "h1#title", "Welcome")
+//		csstest.AssertCount(t, root, "ul.results > li", 10)
+//	}
+//
+// Failure messages include the rendered HTML of the matched elements, or of
+// the root when nothing matched.
+package csstest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ericchiang/css"
+	"github.com/ericchiang/css/internal/nodetext"
+	"golang.org/x/net/html"
+)
+
+// maxContext is the maximum number of bytes of HTML included in a failure
+// message for each element.
+const maxContext = 512
+
+// MustSelectOne returns the single element matched by the selector, failing
+// the test immediately if the selector is invalid or doesn't match exactly
+// one element.
+func MustSelectOne(t testing.TB, root *html.Node, sel string) *html.Node {
+	t.Helper()
+	nodes, err := css.Select(root, sel)
+	if err != nil {
+		t.Fatalf("invalid selector %q: %v", sel, err)
+	}
+	if len(nodes) != 1 {
+		t.Fatalf("selector %q matched %d elements, want 1\n%s", sel, len(nodes), context(root, nodes))
+	}
+	return nodes[0]
+}
+
+// AssertCount checks that the selector matches n elements, reporting an error
+// if it doesn't. It returns true if the assertion passed.
+func AssertCount(t testing.TB, root *html.Node, sel string, n int) bool {
+	t.Helper()
+	nodes, err := css.Select(root, sel)
+	if err != nil {
+		t.Errorf("invalid selector %q: %v", sel, err)
+		return false
+	}
+	if len(nodes) != n {
+		t.Errorf("selector %q matched %d elements, want %d\n%s", sel, len(nodes), n, context(root, nodes))
+		return false
+	}
+	return true
+}
+
+// AssertText checks that the selector matches a single element whose text
+// content is want, reporting an error if it doesn't. Leading and trailing
+// whitespace is ignored, and other runs of whitespace are treated as a single
+// space. It returns true if the assertion passed.
+func AssertText(t testing.TB, root *html.Node, sel string, want string) bool {
+	t.Helper()
+	nodes, err := css.Select(root, sel)
+	if err != nil {
+		t.Errorf("invalid selector %q: %v", sel, err)
+		return false
+	}
+	if len(nodes) != 1 {
+		t.Errorf("selector %q matched %d elements, want 1\n%s", sel, len(nodes), context(root, nodes))
+		return false
+	}
+	got := Text(nodes[0])
+	if got != normalizeSpace(want) {
+		t.Errorf("selector %q matched element with text %q, want %q\n%s", sel, got, want, context(root, nodes))
+		return false
+	}
+	return true
+}
+
+// Text returns the text content of n, with leading and trailing whitespace
+// removed and other runs of whitespace collapsed to a single space.
+func Text(n *html.Node) string {
+	return nodetext.Text(n)
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// context renders the matched elements for a failure message, or the root if
+// there were no matches.
+func context(root *html.Node, nodes []*html.Node) string {
+	if len(nodes) == 0 {
+		return "no elements matched in:\n\t" + render(root)
+	}
+	var b strings.Builder
+	b.WriteString("matched elements:")
+	for i, n := range nodes {
+		fmt.Fprintf(&b, "\n\t%d: %s", i, render(n))
+	}
+	return b.String()
+}
+
+func render(n *html.Node) string {
+	var b strings.Builder
+	if err := html.Render(&b, n); err != nil {
+		return fmt.Sprintf("<failed to render: %v>", err)
+	}
+	s := b.String()
+	if len(s) > maxContext {
+		i := maxContext
+		for i > 0 && !utf8.RuneStart(s[i]) {
+			i--
+		}
+		s = s[:i] + "..."
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package csstest
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// fakeT records failures instead of failing the test.
+type fakeT struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, v ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, v...))
+}
+
+func (f *fakeT) Fatalf(format string, v ...interface{}) {
+	f.Errorf(format, v...)
+	f.fatal = true
+	runtime.Goexit()
+}
+
+// run calls fn with a fakeT, returning once fn completes or calls Fatalf.
+func run(fn func(t *fakeT)) *fakeT {
+	t := &fakeT{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(t)
+	}()
+	<-done
+	return t
+}
+
+func TestAssertions(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<h1 id="title">  Hello,
+	world </h1><ul><li>a</li><li>b</li></ul>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		fn      func(t *fakeT)
+		wantErr string
+		fatal   bool
+	}{
+		{"MustSelectOne", func(t *fakeT) { MustSelectOne(t, root, "h1#title") }, "", false},
+		{"MustSelectOneMany", func(t *fakeT) { MustSelectOne(t, root, "li") }, `selector "li" matched 2 elements, want 1`, true},
+		{"MustSelectOneInvalid", func(t *fakeT) { MustSelectOne(t, root, "[") }, `invalid selector "["`, true},
+		{"AssertCount", func(t *fakeT) { AssertCount(t, root, "ul > li", 2) }, "", false},
+		{"AssertCountNone", func(t *fakeT) { AssertCount(t, root, "p", 1) }, "no elements matched in:", false},
+		{"AssertText", func(t *fakeT) { AssertText(t, root, "#title", "Hello, world") }, "", false},
+		{"AssertTextMismatch", func(t *fakeT) { AssertText(t, root, "#title", "Goodbye") }, `matched element with text "Hello, world", want "Goodbye"`, false},
+		{"AssertTextMany", func(t *fakeT) { AssertText(t, root, "li", "a") }, "0: <li>a</li>", false},
+	}
+	for _, test := range tests {
+		ft := run(test.fn)
+		if test.wantErr == "" {
+			if len(ft.errors) != 0 {
+				t.Errorf("%s: unexpected failure: %s", test.name, ft.errors)
+			}
+			continue
+		}
+		if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], test.wantErr) {
+			t.Errorf("%s: got failures %q, want failure containing %q", test.name, ft.errors, test.wantErr)
+		}
+		if ft.fatal != test.fatal {
+			t.Errorf("%s: got fatal=%t, want %t", test.name, ft.fatal, test.fatal)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package csstest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ericchiang/css"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+// Update causes AssertGolden to write golden files instead of comparing
+// against them. It defaults to true if the CSSTEST_UPDATE environment variable
+// is set to a non-empty value, so golden files can be updated by running
+// "CSSTEST_UPDATE=1 go test".
+var Update = os.Getenv("CSSTEST_UPDATE") != ""
+
+// Snapshot renders every element matched by the selector in a normalized
+// form suitable for comparing against golden files. Each match is rendered on
+// its own line, with attributes sorted by name, whitespace-only text removed,
+// and other runs of whitespace collapsed to a single space. Whitespace within
+// pre and textarea elements is preserved.
+func Snapshot(root *html.Node, sel string) (string, error) {
+	nodes, err := css.Select(root, sel)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	for _, n := range nodes {
+		if err := html.Render(&b, normalize(n, false)); err != nil {
+			return "", err
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// AssertGolden checks that the snapshot of the elements matched by the
+// selector is equal to the contents of the file at path, reporting an error
+// if it isn't. If Update is set, the file is written instead. It returns true if the assertion passed.
+func AssertGolden(t testing.TB, root *html.Node, sel, path string) bool {
+	t.Helper()
+	got, err := Snapshot(root, sel)
+	if err != nil {
+		t.Errorf("snapshot of selector %q: %v", sel, err)
+		return false
+	}
+	if Update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Errorf("updating golden file: %v", err)
+			return false
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Errorf("updating golden file: %v", err)
+			return false
+		}
+		return true
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("reading golden file (set CSSTEST_UPDATE to create it): %v", err)
+		return false
+	}
+	if diff := cmp.Diff(string(want), got); diff != "" {
+		t.Errorf("selector %q doesn't match golden file %s (-want, +got): %s", sel, path, diff)
+		return false
+	}
+	return true
+}
+
+// normalize returns a normalized copy of the tree rooted at n.
+func normalize(n *html.Node, pre bool) *html.Node {
+	c := &html.Node{
+		Type:      n.Type,
+		DataAtom:  n.DataAtom,
+		Data:      n.Data,
+		Namespace: n.Namespace,
+		Attr:      append([]html.Attribute(nil), n.Attr...),
+	}
+	sort.SliceStable(c.Attr, func(i, j int) bool {
+		if c.Attr[i].Namespace != c.Attr[j].Namespace {
+			return c.Attr[i].Namespace < c.Attr[j].Namespace
+		}
+		return c.Attr[i].Key < c.Attr[j].Key
+	})
+	if n.Type == html.ElementNode && (n.Data == "pre" || n.Data == "textarea") {
+		pre = true
+	}
+	if n.Type == html.TextNode && !pre {
+		c.Data = collapseSpace(n.Data)
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode && !pre && strings.TrimSpace(child.Data) == "" {
+			continue
+		}
+		c.AppendChild(normalize(child, pre))
+	}
+	return c
+}
+
+// collapseSpace replaces runs of whitespace with a single space, keeping
+// leading and trailing space so adjacent inline content stays separated.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
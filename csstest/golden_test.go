@@ -0,0 +1,73 @@
+package csstest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const goldenPage = `<nav>
+	<a   title="home" href="/"  class="x">
+		Home
+		page</a>
+	<a href="/about">About</a>
+</nav>
+<pre>  keep
+  this </pre>`
+
+func TestSnapshot(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(goldenPage))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	tests := []struct {
+		sel  string
+		want string
+	}{
+		{"a", "<a class=\"x\" href=\"/\" title=\"home\"> Home page</a>\n<a href=\"/about\">About</a>\n"},
+		{"nav", "<nav><a class=\"x\" href=\"/\" title=\"home\"> Home page</a><a href=\"/about\">About</a></nav>\n"},
+		{"pre", "<pre>  keep\n  this </pre>\n"},
+		{"p", ""},
+	}
+	for _, test := range tests {
+		got, err := Snapshot(root, test.sel)
+		if err != nil {
+			t.Errorf("Snapshot(%q) failed: %v", test.sel, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Snapshot(%q) = %q, want %q", test.sel, got, test.want)
+		}
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(goldenPage))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	AssertGolden(t, root, "nav a", filepath.Join("testdata", "links.golden"))
+
+	ft := run(func(t *fakeT) { AssertGolden(t, root, "nav a[href='/']", filepath.Join("testdata", "links.golden")) })
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "doesn't match golden file") {
+		t.Errorf("AssertGolden() with mismatched snapshot got failures %q", ft.errors)
+	}
+
+	// Updating writes the golden file.
+	defer func(update bool) { Update = update }(Update)
+	Update = true
+	path := filepath.Join(t.TempDir(), "dir", "pre.golden")
+	if !AssertGolden(t, root, "pre", path) {
+		t.Fatalf("AssertGolden() with Update set failed")
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading updated golden file: %v", err)
+	}
+	if want := "<pre>  keep\n  this </pre>\n"; string(got) != want {
+		t.Errorf("AssertGolden() wrote %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+package csstest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ericchiang/css"
+	"golang.org/x/net/html"
+)
+
+// Response is a response that assertions can be made against: either a
+// response returned by a client, or one recorded by an httptest.ResponseRecorder.
+type Response interface {
+	*http.Response | *httptest.ResponseRecorder
+}
+
+// parseResponse parses the body of resp as HTML, failing the test immediately
+// if it can't be read or parsed.
+//
+// The body of an *http.Response is replaced with a copy of what was read, so
+// several assertions can be made against the same response.
+func parseResponse[R Response](t testing.TB, resp R) *html.Node {
+	t.Helper()
+	var body []byte
+	switch r := any(resp).(type) {
+	case *http.Response:
+		if r == nil || r.Body == nil {
+			t.Fatalf("response has no body")
+		}
+		b, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("reading response body: %v", err)
+		}
+		body = b
+	case *httptest.ResponseRecorder:
+		if r == nil || r.Body == nil {
+			t.Fatalf("response has no body")
+		}
+		body = r.Body.Bytes()
+	}
+	root, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("parsing response body: %v", err)
+	}
+	return root
+}
+
+// ExistsInResponse checks that the selector matches at least one element in
+// the body of the response, reporting an error if it doesn't. It returns true
+// if the assertion passed.
+//
+//	func TestIndex(t *testing.T) {
+//		rec := httptest.NewRecorder()
+//		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+//		csstest.ExistsInResponse(t, rec, "form#login")
+//	}
+func ExistsInResponse[R Response](t testing.TB, resp R, sel string) bool {
+	t.Helper()
+	root := parseResponse(t, resp)
+	nodes, err := css.Select(root, sel)
+	if err != nil {
+		t.Errorf("invalid selector %q: %v", sel, err)
+		return false
+	}
+	if len(nodes) == 0 {
+		t.Errorf("selector %q matched no elements\n%s", sel, context(root, nodes))
+		return false
+	}
+	return true
+}
+
+// TextInResponse is like AssertText, but checks the body of the response.
+func TextInResponse[R Response](t testing.TB, resp R, sel string, want string) bool {
+	t.Helper()
+	return AssertText(t, parseResponse(t, resp), sel, want)
+}
@@ -0,0 +1,57 @@
+package csstest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseAssertions(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<h1>Welcome</h1><form id="login"><input name="user"></form>`)
+	})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("http.Get() failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	tests := []struct {
+		name    string
+		fn      func(t *fakeT)
+		wantErr string
+		fatal   bool
+	}{
+		{"ExistsRecorder", func(t *fakeT) { ExistsInResponse(t, rec, "form#login input") }, "", false},
+		{"ExistsResponse", func(t *fakeT) { ExistsInResponse(t, resp, "form#login") }, "", false},
+		{"ExistsNone", func(t *fakeT) { ExistsInResponse(t, rec, "form#signup") }, `selector "form#signup" matched no elements`, false},
+		{"ExistsInvalid", func(t *fakeT) { ExistsInResponse(t, rec, "[") }, `invalid selector "["`, false},
+		{"TextRecorder", func(t *fakeT) { TextInResponse(t, rec, "h1", "Welcome") }, "", false},
+		// The body of resp was read by ExistsResponse, and must be readable again.
+		{"TextResponse", func(t *fakeT) { TextInResponse(t, resp, "h1", "Welcome") }, "", false},
+		{"TextMismatch", func(t *fakeT) { TextInResponse(t, rec, "h1", "Goodbye") }, `matched element with text "Welcome", want "Goodbye"`, false},
+		{"NilResponse", func(t *fakeT) { ExistsInResponse(t, (*http.Response)(nil), "h1") }, "response has no body", true},
+	}
+	for _, test := range tests {
+		ft := run(test.fn)
+		if test.wantErr == "" {
+			if len(ft.errors) != 0 {
+				t.Errorf("%s: unexpected failure: %s", test.name, ft.errors)
+			}
+			continue
+		}
+		if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], test.wantErr) {
+			t.Errorf("%s: got failures %q, want failure containing %q", test.name, ft.errors, test.wantErr)
+		}
+		if ft.fatal != test.fatal {
+			t.Errorf("%s: got fatal=%t, want %t", test.name, ft.fatal, test.fatal)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package css
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Match is an element returned by SelectDetailed, along with information about
+// why it was selected.
+type Match struct {
+	Node *html.Node
+	// Index is the position of the matched selector within the selector list,
+	// and Selector is its serialization. If multiple selectors in the list
+	// match, the first is reported.
+	Index    int
+	Selector string
+	// Attrs holds the attributes that satisfied each attribute selector in
+	// the subject of the matched selector, in the order the attribute
+	// selectors appear.
+	Attrs []html.Attribute
+	// Positions holds the 1-based sibling index used by each :nth-*()
+	// pseudo-class in the subject of the matched selector, keyed by the name
+	// of the pseudo-class, such as "nth-child".
+	Positions map[string]int
+}
+
+// SelectDetailed is like Select, but returns details about how each element
+// was matched.
+//
+// Details are reported for the subject of the matched selector, the rightmost
+// compound selector. For selectors with a pseudo-element, details describe
+// the originating element rather than the element the pseudo-element
+// represents.
+func (s *Selector) SelectDetailed(n *html.Node) []Match {
+	if !validRoot(n) {
+		return []Match{}
+	}
+	st := s.newState(n, &MatchContext{})
+
+	var nodes []*html.Node
+	matches := map[*html.Node]Match{}
+	add := func(n *html.Node, m Match) {
+		if _, ok := matches[n]; ok {
+			return
+		}
+		m.Node = n
+		matches[n] = m
+		nodes = append(nodes, n)
+	}
+	st.walk(n, func(e *html.Node) {
+		for i, sel := range s.s {
+			if !sel.match(st, e) {
+				continue
+			}
+			m := sel.details(st, e)
+			m.Index = i
+			m.Selector = s.componentString(i)
+			if sel.pseudo == nil {
+				add(e, m)
+				continue
+			}
+			for _, n := range sel.pseudo(st, e) {
+				add(n, m)
+			}
+		}
+	})
+	if s.pseudo {
+		st.sortNodes(nodes)
+	}
+
+	detailed := make([]Match, len(nodes))
+	for i, n := range nodes {
+		detailed[i] = matches[n]
+	}
+	return detailed
+}
+
+// componentString returns the serialization of the ith selector in the list.
+func (s *Selector) componentString(i int) string {
+	var b strings.Builder
+	writeComplexSelector(&b, &s.list[i])
+	return b.String()
+}
+
+// details describes how the subject of the selector matched n.
+func (s *selector) details(st *state, n *html.Node) Match {
+	var m Match
+	for _, scm := range s.m.scm {
+		if scm.attributeSelector != nil {
+			if i, ok := scm.attributeSelector.find(st, n); ok {
+				m.Attrs = append(m.Attrs, n.Attr[i])
+			}
+			continue
+		}
+		p := scm.src.pseudoClassSelector
+		if p == nil {
+			continue
+		}
+		var pos int
+		switch p.function {
+		case "nth-child(":
+			pos = st.siblingIndex(n).child
+		case "nth-last-child(":
+			pos = st.siblingIndex(n).lastChild
+		case "nth-of-type(":
+			pos = st.siblingIndex(n).ofType
+		case "nth-last-of-type(":
+			pos = st.siblingIndex(n).lastOfType
+		default:
+			continue
+		}
+		if m.Positions == nil {
+			m.Positions = map[string]int{}
+		}
+		m.Positions[strings.TrimSuffix(p.function, "(")] = pos
+	}
+	return m
+}
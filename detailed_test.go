@@ -0,0 +1,76 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/net/html"
+)
+
+func TestSelectDetailed(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<ul><li class="a" data-id="1">1</li><li title="Two">2</li><li data-id="3">3</li></ul>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	type match struct {
+		Node      string
+		Index     int
+		Selector  string
+		Attrs     []html.Attribute
+		Positions map[string]int
+	}
+	tests := []struct {
+		sel  string
+		want []match
+	}{
+		{
+			"li[data-id]:nth-child(odd)",
+			[]match{
+				{
+					Node:      `<li class="a" data-id="1">1</li>`,
+					Selector:  "li[data-id]:nth-child(odd)",
+					Attrs:     []html.Attribute{{Key: "data-id", Val: "1"}},
+					Positions: map[string]int{"nth-child": 1},
+				},
+				{
+					Node:      `<li data-id="3">3</li>`,
+					Selector:  "li[data-id]:nth-child(odd)",
+					Attrs:     []html.Attribute{{Key: "data-id", Val: "3"}},
+					Positions: map[string]int{"nth-child": 3},
+				},
+			},
+		},
+		{
+			".a, ul > [title=two i]:nth-last-of-type(2), li",
+			[]match{
+				{Node: `<li class="a" data-id="1">1</li>`, Selector: ".a"},
+				{
+					Node:      `<li title="Two">2</li>`,
+					Index:     1,
+					Selector:  `ul > [title="two" i]:nth-last-of-type(2)`,
+					Attrs:     []html.Attribute{{Key: "title", Val: "Two"}},
+					Positions: map[string]int{"nth-last-of-type": 2},
+				},
+				{Node: `<li data-id="3">3</li>`, Index: 2, Selector: "li"},
+			},
+		},
+	}
+	for _, test := range tests {
+		var got []match
+		for _, m := range MustParse(test.sel).SelectDetailed(root) {
+			got = append(got, match{
+				Node:      renderNodes(t, []*html.Node{m.Node})[0],
+				Index:     m.Index,
+				Selector:  m.Selector,
+				Attrs:     m.Attrs,
+				Positions: m.Positions,
+			})
+		}
+		if diff := cmp.Diff(test.want, got, cmpopts.EquateEmpty()); diff != "" {
+			t.Errorf("SelectDetailed(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package css
+
+import (
+	"golang.org/x/net/html"
+)
+
+// Dispatcher runs multiple selectors against a document in a single
+// traversal, calling a function for each element a selector matches. This is
+// useful for extracting several kinds of data from a page at once:
+//
+//	var d css.Dispatcher
+//	d.On(css.MustParse("title"), func(n *html.Node) { ... })
+//	d.On(css.MustParse("a[href]"), func(n *html.Node) { ... })
+//	d.Run(root)
+//
+// The zero value is ready to use.
+type Dispatcher struct {
+	handlers []handler
+}
+
+type handler struct {
+	sel *Selector
+	fn  func(n *html.Node)
+}
+
+// On registers fn to be called for each element matched by sel.
+func (d *Dispatcher) On(sel *Selector, fn func(n *html.Node)) {
+	d.handlers = append(d.handlers, handler{sel, fn})
+}
+
+// Run selects from the tree rooted at n, calling the registered functions
+// with any matches. Functions are called in document order and, for each
+// element, in the order they were registered. Functions must not modify the
+// tree.
+func (d *Dispatcher) Run(n *html.Node) {
+	if !validRoot(n) {
+		return
+	}
+	// Selectors that skip subtrees or use pseudo-elements can't share the
+	// traversal, and have their results computed up front.
+	pending := make([]map[*html.Node]bool, len(d.handlers))
+	for i, h := range d.handlers {
+		if !h.sel.pseudo && h.sel.exclude == nil {
+			continue
+		}
+		pending[i] = map[*html.Node]bool{}
+		for _, e := range h.sel.Select(n) {
+			pending[i][e] = true
+		}
+	}
+
+	st := newState(n, &MatchContext{})
+	st.walk(n, func(e *html.Node) {
+		for i, h := range d.handlers {
+			if pending[i] != nil {
+				if pending[i][e] {
+					delete(pending[i], e)
+					h.fn(e)
+				}
+				continue
+			}
+			if h.sel.match(st, e) {
+				h.fn(e)
+			}
+		}
+	})
+
+	// Pseudo-elements may represent elements that weren't traversed, such as
+	// elements within shadow trees.
+	for i, h := range d.handlers {
+		if len(pending[i]) == 0 {
+			continue
+		}
+		for _, e := range h.sel.Select(n) {
+			if pending[i][e] {
+				h.fn(e)
+			}
+		}
+	}
+}
+
+// Histogram returns the number of elements in the tree rooted at n matched by
+// each selector, evaluating every selector in a single traversal. This is
+// useful for measuring how often patterns appear across a corpus of pages:
+//
+//	counts := css.Histogram(root, sels)
+//	for i, sel := range sels {
+//		totals[sel.String()] += counts[i]
+//	}
+//
+// The count for each selector is the number of elements Select would return.
+func Histogram(n *html.Node, sels []*Selector) []int {
+	counts := make([]int, len(sels))
+	var d Dispatcher
+	for i, sel := range sels {
+		i := i
+		d.On(sel, func(*html.Node) { counts[i]++ })
+	}
+	d.Run(n)
+	return counts
+}
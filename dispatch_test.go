@@ -0,0 +1,71 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestDispatcher(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<h1>Title</h1><nav><a href="/">home</a></nav><p><a href="/a">a</a></p><a>b</a>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	var got []string
+	record := func(name string) func(n *html.Node) {
+		return func(n *html.Node) {
+			got = append(got, name+" "+renderNodes(t, []*html.Node{n})[0])
+		}
+	}
+
+	var d Dispatcher
+	d.On(MustParse("a[href]"), record("link"))
+	d.On(MustParse("h1, a"), record("any"))
+	d.On(MustParse("a", WithExclude(MustParse("nav"))), record("content"))
+	d.On(MustParse("span"), record("none"))
+	d.Run(root)
+
+	want := []string{
+		`any <h1>Title</h1>`,
+		`link <a href="/">home</a>`,
+		`any <a href="/">home</a>`,
+		`link <a href="/a">a</a>`,
+		`any <a href="/a">a</a>`,
+		`content <a href="/a">a</a>`,
+		`any <a>b</a>`,
+		`content <a>b</a>`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Dispatcher.Run() returned diff (-want, +got): %s", diff)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<h1>Title</h1><nav><a href="/">home</a></nav><p><a href="/a">a</a></p><a>b</a>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	sels := []*Selector{
+		MustParse("a[href]"),
+		MustParse("h1, a"),
+		MustParse("a", WithExclude(MustParse("nav"))),
+		MustParse("span"),
+		MustParse("a[href]"),
+	}
+	got := Histogram(root, sels)
+	want := []int{2, 4, 2, 0, 2}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Histogram() returned diff (-want, +got): %s", diff)
+	}
+	for i, sel := range sels {
+		if n := len(sel.Select(root)); n != got[i] {
+			t.Errorf("Histogram() returned %d matches for %q, Select returned %d", got[i], sel, n)
+		}
+	}
+	if got := Histogram(nil, sels); !cmp.Equal(got, []int{0, 0, 0, 0, 0}) {
+		t.Errorf("Histogram(nil) = %v, want all zeros", got)
+	}
+}
@@ -0,0 +1,153 @@
+package css
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ChangeKind describes how a selected element differs between two documents.
+type ChangeKind int
+
+const (
+	// Added elements are only selected in the new document.
+	Added ChangeKind = iota + 1
+	// Removed elements are only selected in the old document.
+	Removed
+	// Changed elements are selected in both documents, but their text or
+	// attributes differ.
+	Changed
+)
+
+// String returns a human readable name for the kind of change.
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Changed:
+		return "changed"
+	}
+	return "ChangeKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Change records a difference between the elements selected from two
+// documents.
+type Change struct {
+	Kind ChangeKind
+	// Path identifies the element, as returned by Path.
+	Path string
+	// Old and New are the element in the old and new document. Old is nil
+	// for added elements, and New is nil for removed elements.
+	Old, New *html.Node
+	// Text reports if the text content of a changed element differs.
+	Text bool
+	// Attrs holds the sorted names of attributes that were added, removed,
+	// or modified on a changed element.
+	Attrs []string
+}
+
+// DiffDocuments selects elements from two versions of a document and reports
+// how they differ, such as for monitoring if a price on a scraped page
+// changed:
+//
+//	for _, c := range css.DiffDocuments(css.MustParse(".price"), old, new) {
+//		fmt.Println(c.Kind, c.Path)
+//	}
+//
+// Elements are aligned by their position in the document, using Path. Since
+// paths include ids and distinguishing classes, an element whose id changes
+// is reported as removed and added. An element is changed if its text
+// content, ignoring differences in whitespace, or its attributes differ.
+// Changes to descendants that don't affect the text, such as markup, aren't
+// reported.
+//
+// Changed and removed elements are reported in the order of the old document,
+// followed by added elements in the order of the new document. DiffDocuments
+// returns nil if there are no differences.
+func DiffDocuments(sel *Selector, old, new *html.Node) []Change {
+	oldKeys, oldNodes := diffKeys(sel.Select(old))
+	newKeys, newNodes := diffKeys(sel.Select(new))
+
+	var changes []Change
+	for _, key := range oldKeys {
+		o := oldNodes[key]
+		n, ok := newNodes[key]
+		if !ok {
+			changes = append(changes, Change{Kind: Removed, Path: Path(o), Old: o})
+			continue
+		}
+		text := normalizeSpace(textContent(o)) != normalizeSpace(textContent(n))
+		attrs := diffAttrs(o, n)
+		if text || len(attrs) > 0 {
+			changes = append(changes, Change{Kind: Changed, Path: Path(o), Old: o, New: n, Text: text, Attrs: attrs})
+		}
+	}
+	for _, key := range newKeys {
+		if _, ok := oldNodes[key]; !ok {
+			n := newNodes[key]
+			changes = append(changes, Change{Kind: Added, Path: Path(n), New: n})
+		}
+	}
+	return changes
+}
+
+// diffKeys returns a key for each node used to align them between
+// documents, along with the nodes indexed by key. Paths are usually unique,
+// but elements that share an id may have the same path, so repeated paths
+// are distinguished by their occurrence.
+func diffKeys(nodes []*html.Node) ([]string, map[string]*html.Node) {
+	keys := make([]string, 0, len(nodes))
+	byKey := make(map[string]*html.Node, len(nodes))
+	seen := map[string]int{}
+	for _, n := range nodes {
+		path := Path(n)
+		key := path
+		if i := seen[path]; i > 0 {
+			key += " " + strconv.Itoa(i)
+		}
+		seen[path]++
+		keys = append(keys, key)
+		byKey[key] = n
+	}
+	return keys, byKey
+}
+
+// diffAttrs returns the sorted names of attributes that differ between a and
+// b.
+func diffAttrs(a, b *html.Node) []string {
+	vals := func(n *html.Node) map[string]string {
+		m := make(map[string]string, len(n.Attr))
+		for _, a := range n.Attr {
+			key := a.Key
+			if a.Namespace != "" {
+				key = a.Namespace + ":" + a.Key
+			}
+			m[key] = a.Val
+		}
+		return m
+	}
+	av, bv := vals(a), vals(b)
+	var names []string
+	for k, v := range av {
+		if w, ok := bv[k]; !ok || v != w {
+			names = append(names, k)
+		}
+	}
+	for k := range bv {
+		if _, ok := av[k]; !ok {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// normalizeSpace collapses runs of whitespace, and trims leading and trailing
+// whitespace.
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,66 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestDiffDocuments(t *testing.T) {
+	parse := func(s string) *html.Node {
+		t.Helper()
+		n, err := html.Parse(strings.NewReader(s))
+		if err != nil {
+			t.Fatalf("parsing html: %v", err)
+		}
+		return n
+	}
+	old := parse(`
+<ul>
+  <li class="item"><span class="price">1.00</span></li>
+  <li class="item"><span class="price" data-currency="usd">2.00</span></li>
+  <li class="item"><span class="price">3.00</span></li>
+  <li class="item"><span class="price">  4.00 </span></li>
+</ul>`)
+	new := parse(`
+<ul>
+  <li class="item"><span class="price">1.50</span></li>
+  <li class="item"><span class="price" data-currency="eur">2.00</span></li>
+  <li class="item"><span class="price">3.00</span></li>
+  <li class="item"><span class="price">4.00</span></li>
+  <li class="item"><span class="price" id="new">5.00</span></li>
+</ul>`)
+
+	type change struct {
+		Kind  ChangeKind
+		Path  string
+		Text  bool
+		Attrs []string
+	}
+	var got []change
+	for _, c := range DiffDocuments(MustParse(".price"), old, new) {
+		got = append(got, change{c.Kind, c.Path, c.Text, c.Attrs})
+	}
+	want := []change{
+		{Kind: Changed, Path: "html > body > ul > li:nth-child(1) > span", Text: true},
+		{Kind: Changed, Path: "html > body > ul > li:nth-child(2) > span", Attrs: []string{"data-currency"}},
+		{Kind: Added, Path: "html > body > ul > li:nth-child(5) > span#new"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("DiffDocuments returned diff (-want, +got): %s", diff)
+	}
+
+	got = nil
+	for _, c := range DiffDocuments(MustParse(".price"), new, old) {
+		got = append(got, change{c.Kind, c.Path, c.Text, c.Attrs})
+	}
+	if len(got) != 3 || got[2].Kind != Removed {
+		t.Errorf("DiffDocuments reversed returned %+v, want last change removed", got)
+	}
+
+	if changes := DiffDocuments(MustParse(".price"), old, old); changes != nil {
+		t.Errorf("DiffDocuments of identical documents returned %v, want nil", changes)
+	}
+}
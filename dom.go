@@ -0,0 +1,119 @@
+package css
+
+import (
+	"golang.org/x/net/html"
+)
+
+// The following functions mirror the selector methods of the DOM, for
+// programs ported from JavaScript. Unlike Selector.Select, combinators are
+// evaluated against the entire tree containing n, not just the subtree rooted
+// at n. For example, QuerySelectorAll(n, "div p") matches p elements within n
+// that have a div ancestor, even if the div contains n.
+//
+// As in the DOM, selectors with pseudo-elements never match. Selectors are
+// cached in the same way as Select.
+//
+// https://dom.spec.whatwg.org/#interface-parentnode
+// https://dom.spec.whatwg.org/#interface-element
+
+// QuerySelector returns the first descendant of n in document order that
+// matches the selector, or nil if there is no match.
+func QuerySelector(n *html.Node, selector string) (*html.Node, error) {
+	sel, err := selectorCache.parse(selector)
+	if err != nil {
+		return nil, err
+	}
+	var found *html.Node
+	sel.query(n, func(e *html.Node) bool {
+		found = e
+		return false
+	})
+	return found, nil
+}
+
+// QuerySelectorAll returns the descendants of n that match the selector, in
+// document order.
+func QuerySelectorAll(n *html.Node, selector string) ([]*html.Node, error) {
+	sel, err := selectorCache.parse(selector)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []*html.Node{}
+	sel.query(n, func(e *html.Node) bool {
+		nodes = append(nodes, e)
+		return true
+	})
+	return nodes, nil
+}
+
+// Matches reports if the element n matches the selector.
+func Matches(n *html.Node, selector string) (bool, error) {
+	sel, err := selectorCache.parse(selector)
+	if err != nil {
+		return false, err
+	}
+	if n == nil || n.Type != html.ElementNode {
+		return false, nil
+	}
+	return sel.matchElement(newState(treeRoot(n), &MatchContext{}), n), nil
+}
+
+// Closest returns n or the nearest ancestor of n that matches the selector,
+// or nil if there is no match.
+func Closest(n *html.Node, selector string) (*html.Node, error) {
+	sel, err := selectorCache.parse(selector)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil {
+		return nil, nil
+	}
+	st := newState(treeRoot(n), &MatchContext{})
+	for ; n != nil; n = n.Parent {
+		if isElement(n) && sel.matchElement(st, n) {
+			return n, nil
+		}
+	}
+	return nil, nil
+}
+
+// query calls fn for each descendant of n that matches the selector, until fn
+// returns false.
+func (s *Selector) query(n *html.Node, fn func(e *html.Node) bool) {
+	if !validRoot(n) {
+		return
+	}
+	st := newState(treeRoot(n), &MatchContext{})
+	var walk func(n *html.Node) bool
+	walk = func(n *html.Node) bool {
+		for c := firstElementChild(n); c != nil; c = nextElementSibling(c) {
+			if s.matchElement(st, c) && !fn(c) {
+				return false
+			}
+			if !walk(c) {
+				return false
+			}
+		}
+		return true
+	}
+	walk(n)
+}
+
+// matchElement reports if n matches any selector in the list without a
+// pseudo-element.
+func (s *Selector) matchElement(st *state, n *html.Node) bool {
+	for _, sel := range s.s {
+		if sel.pseudo == nil && sel.match(st, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// treeRoot returns the top of the tree containing n.
+func treeRoot(n *html.Node) *html.Node {
+	for n.Parent != nil {
+		n = n.Parent
+	}
+	return n
+}
@@ -0,0 +1,113 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestDOMFunctions(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<div id="outer"><section id="s"><p id="a">a</p><div><p id="b">b</p></div></section></div><p id="c">c</p>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	section, err := QuerySelector(root, "#s")
+	if err != nil {
+		t.Fatalf("QuerySelector() failed: %v", err)
+	}
+
+	tests := []struct {
+		n    *html.Node
+		sel  string
+		want []string
+	}{
+		// Ancestors outside of the section satisfy combinators.
+		{section, "div p", []string{`<p id="a">a</p>`, `<p id="b">b</p>`}},
+		{section, "#outer > section > p", []string{`<p id="a">a</p>`}},
+		// The element itself isn't included.
+		{section, "section", []string{}},
+		{section, "p, p#b, section p", []string{`<p id="a">a</p>`, `<p id="b">b</p>`}},
+		{section, "div::part(foo)", []string{}},
+		{root, "#c", []string{`<p id="c">c</p>`}},
+	}
+	for _, test := range tests {
+		got, err := QuerySelectorAll(test.n, test.sel)
+		if err != nil {
+			t.Errorf("QuerySelectorAll(%q) failed: %v", test.sel, err)
+			continue
+		}
+		if diff := cmp.Diff(test.want, renderNodes(t, got)); diff != "" {
+			t.Errorf("QuerySelectorAll(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+
+		first, err := QuerySelector(test.n, test.sel)
+		if err != nil {
+			t.Errorf("QuerySelector(%q) failed: %v", test.sel, err)
+			continue
+		}
+		if len(got) == 0 {
+			if first != nil {
+				t.Errorf("QuerySelector(%q) returned a node, want nil", test.sel)
+			}
+		} else if first != got[0] {
+			t.Errorf("QuerySelector(%q) didn't return the first match", test.sel)
+		}
+	}
+
+	b, _ := QuerySelector(root, "#b")
+	for _, test := range []struct {
+		sel  string
+		want bool
+	}{
+		{"p", true},
+		{"#outer p", true},
+		{"section > p", false},
+		{"div::part(foo)", false},
+	} {
+		got, err := Matches(b, test.sel)
+		if err != nil {
+			t.Errorf("Matches(%q) failed: %v", test.sel, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("Matches(%q) = %t, want %t", test.sel, got, test.want)
+		}
+	}
+
+	for _, test := range []struct {
+		sel  string
+		want string
+	}{
+		{"p", "b"},
+		{"section", "s"},
+		{"div", ""},
+		{"body > div", "outer"},
+		{"table", ""},
+	} {
+		got, err := Closest(b, test.sel)
+		if err != nil {
+			t.Errorf("Closest(%q) failed: %v", test.sel, err)
+			continue
+		}
+		var id string
+		if got != nil {
+			id, _ = attr(got, "id")
+		}
+		if id != test.want {
+			t.Errorf("Closest(%q) returned element with id %q, want %q", test.sel, id, test.want)
+		}
+	}
+
+	for _, fn := range []func() error{
+		func() error { _, err := QuerySelector(root, "["); return err },
+		func() error { _, err := QuerySelectorAll(root, "["); return err },
+		func() error { _, err := Matches(b, "["); return err },
+		func() error { _, err := Closest(b, "["); return err },
+	} {
+		if fn() == nil {
+			t.Errorf("invalid selector didn't return an error")
+		}
+	}
+}
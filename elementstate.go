@@ -0,0 +1,108 @@
+package css
+
+import (
+	"golang.org/x/net/html"
+)
+
+// ElementState reports the state of elements that depends on user
+// interaction, which isn't represented by the document. It's implemented by
+// headless browsers and other programs that simulate a user, and is used to
+// match the user action pseudo-classes :hover, :active, :focus,
+// :focus-visible and :focus-within.
+//
+// https://www.w3.org/TR/selectors-4/#useraction-pseudos
+type ElementState interface {
+	// Hovered reports if the pointer is over n. Following browsers, an element
+	// should also be reported as hovered if one of its descendants is.
+	Hovered(n *html.Node) bool
+	// Focused reports if n has focus.
+	Focused(n *html.Node) bool
+	// FocusVisible reports if n has focus, and a browser would indicate it,
+	// such as an element focused using the keyboard.
+	FocusVisible(n *html.Node) bool
+	// Active reports if n is being activated by the user, such as a button
+	// being pressed. Like Hovered, the ancestors of an active element should
+	// also be reported as active.
+	Active(n *html.Node) bool
+}
+
+// WithElementState causes the user action pseudo-classes to be matched by
+// consulting s:
+//
+//	sel, err := css.Parse("button:hover", css.WithElementState(browser))
+//
+// Without this option, they're handled like any other unsupported
+// pseudo-class.
+func WithElementState(s ElementState) Option {
+	return func(o *options) {
+		o.elementState = s
+	}
+}
+
+// userAction compiles a user action pseudo-class, or returns nil if there's
+// no ElementState to consult.
+func (c *compiler) userAction(name string) matchFunc {
+	es := c.opts.elementState
+	if es == nil {
+		return nil
+	}
+	switch name {
+	case "active":
+		return stateless(es.Active)
+	case "focus":
+		return stateless(es.Focused)
+	case "focus-visible":
+		return stateless(es.FocusVisible)
+	case "focus-within":
+		return func(s *state, n *html.Node) bool {
+			return s.focusWithin(es, n)
+		}
+	case "hover":
+		return stateless(es.Hovered)
+	}
+	return nil
+}
+
+// focusWithin reports if n or any of its descendants has focus. Focus within
+// a shadow tree also propagates to the tree's host.
+//
+// Rather than searching the subtree of each element, the focused elements are
+// found once per selection, and their ancestors recorded.
+//
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:focus-within
+func (s *state) focusWithin(es ElementState, n *html.Node) bool {
+	if s.focused == nil {
+		s.focused = map[*html.Node]bool{}
+		s.focusScanned = map[*html.Node]bool{}
+		for _, root := range s.ctx.ShadowRoots {
+			s.scanFocus(es, root)
+		}
+	}
+	s.scanFocus(es, treeRoot(n))
+	return s.focused[n]
+}
+
+// scanFocus records the focused elements of the tree rooted at root, along
+// with their ancestors, if the tree hasn't been scanned already.
+func (s *state) scanFocus(es ElementState, root *html.Node) {
+	if s.focusScanned[root] {
+		return
+	}
+	s.focusScanned[root] = true
+	walkElements(root, func(e *html.Node) bool {
+		if !es.Focused(e) {
+			return true
+		}
+		for p := e; p != nil && !s.focused[p]; {
+			if p.Type == html.ElementNode {
+				s.focused[p] = true
+			}
+			if p.Parent == nil {
+				p = s.hosts[p]
+				continue
+			}
+			p = p.Parent
+		}
+		return true
+	})
+}
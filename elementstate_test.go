@@ -0,0 +1,109 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+// fakeElementState reports the state of elements by id.
+type fakeElementState struct {
+	hovered, focused, visible, active map[string]bool
+}
+
+func (f *fakeElementState) has(set map[string]bool, n *html.Node) bool {
+	id, _ := attr(n, "id")
+	return set[id]
+}
+
+func (f *fakeElementState) Hovered(n *html.Node) bool { return f.has(f.hovered, n) }
+func (f *fakeElementState) Focused(n *html.Node) bool { return f.has(f.focused, n) }
+func (f *fakeElementState) Active(n *html.Node) bool  { return f.has(f.active, n) }
+
+func (f *fakeElementState) FocusVisible(n *html.Node) bool { return f.has(f.visible, n) }
+
+func TestElementState(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<nav id="nav"><a id="home" href="/"></a><a id="about" href="/about"></a></nav>
+<form id="form"><input id="name"><button id="submit"></button></form>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	es := &fakeElementState{
+		hovered: map[string]bool{"nav": true, "about": true},
+		focused: map[string]bool{"name": true},
+		visible: map[string]bool{"name": true},
+		active:  map[string]bool{"form": true, "submit": true},
+	}
+
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{"a:hover", []string{"about"}},
+		{":hover > a:not(:hover)", []string{"home"}},
+		{":focus", []string{"name"}},
+		{"button:active", []string{"submit"}},
+		{"form:active :focus", []string{"name"}},
+		{"input:focus-visible", []string{"name"}},
+		{"body :focus-within", []string{"form", "name"}},
+		{"nav:focus-within", nil},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel, WithElementState(es))
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		var got []string
+		for _, n := range s.Select(root) {
+			id, _ := attr(n, "id")
+			got = append(got, id)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+
+	if _, err := Parse(":hover"); err == nil {
+		t.Errorf("Parse() of :hover without WithElementState() didn't return an error")
+	}
+}
+
+func TestFocusWithinShadow(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<div id="outer"><my-input id="host"></my-input></div><p id="p"></p>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	shadow, err := html.Parse(strings.NewReader(`<label id="label"><input id="inner"></label>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	host := MustParse("#host").Select(root)[0]
+	ctx := MatchContext{ShadowRoots: map[*html.Node]*html.Node{host: shadow}}
+	es := &fakeElementState{focused: map[string]bool{"inner": true}}
+
+	s, err := Parse("body :focus-within, label:focus-within", WithElementState(es))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	tests := []struct {
+		root *html.Node
+		want []string
+	}{
+		{root, []string{"outer", "host"}},
+		{shadow, []string{"label", "inner"}},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, n := range s.SelectWithContext(test.root, ctx) {
+			id, _ := attr(n, "id")
+			got = append(got, id)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Select() returned diff (-want, +got): %s", diff)
+		}
+	}
+}
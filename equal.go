@@ -0,0 +1,121 @@
+package css
+
+import (
+	"reflect"
+	"strings"
+)
+
+// EqualAST reports if two selectors have the same structure, ignoring the
+// positions of their components and any formatting of the source text. For
+// example, "a>b" and "a > b" are equal, while "a > b" and "b > a" aren't.
+// Arguments to functional pseudo-classes are compared as written, so
+// ":nth-child(odd)" and ":nth-child(2n+1)" aren't equal.
+//
+// Compile options aren't compared.
+func EqualAST(a, b *Selector) bool {
+	return reflect.DeepEqual(stripPositions(a.AST()), stripPositions(b.AST()))
+}
+
+// stripPositions zeros the source positions recorded by an AST.
+func stripPositions(list []*ComplexSelector) []*ComplexSelector {
+	for _, cs := range list {
+		cs.Pos, cs.End = 0, 0
+		for _, c := range cs.Compounds {
+			c.Pos, c.End = 0, 0
+			if c.Type != nil {
+				c.Type.Pos, c.Type.End = 0, 0
+			}
+			for _, sc := range c.Subclasses {
+				sc.Pos, sc.End = 0, 0
+				if sc.Attribute != nil {
+					sc.Attribute.Pos, sc.Attribute.End = 0, 0
+				}
+				if sc.PseudoClass != nil {
+					sc.PseudoClass.Pos, sc.PseudoClass.End = 0, 0
+				}
+			}
+			for _, pe := range c.PseudoElements {
+				pe.Pos, pe.End = 0, 0
+				for _, pc := range pe.Classes {
+					pc.Pos, pc.End = 0, 0
+				}
+			}
+		}
+	}
+	return list
+}
+
+// DiffAST returns a human readable, line based diff between two selectors,
+// or an empty string if they're equal according to EqualAST. Each line holds
+// a compound selector, prefixed by the combinator joining it to the previous
+// compound selector, if any other than a descendant combinator. Selectors in a list are separated by a line holding
+// a comma. Lines only in a are prefixed with "-", and lines only in b with
+// "+":
+//
+//	  div
+//	- > a.link
+//	+ > a.external
+//
+// The format is intended for people, such as in test failures, and may
+// change.
+func DiffAST(a, b *Selector) string {
+	if EqualAST(a, b) {
+		return ""
+	}
+	x, y := diffLines(a), diffLines(b)
+
+	// Longest common subsequence of lines, where lcs[i][j] is the length of
+	// the subsequence for x[i:] and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			out.WriteString("  " + x[i] + "\n")
+			i++
+			j++
+		case j == len(y) || (i < len(x) && lcs[i+1][j] >= lcs[i][j+1]):
+			out.WriteString("- " + x[i] + "\n")
+			i++
+		default:
+			out.WriteString("+ " + y[j] + "\n")
+			j++
+		}
+	}
+	return out.String()
+}
+
+// diffLines returns the lines compared by DiffAST.
+func diffLines(s *Selector) []string {
+	var lines []string
+	for i := range s.list {
+		if i > 0 {
+			lines = append(lines, ",")
+		}
+		combinator := ""
+		for curr := &s.list[i]; curr != nil; curr = curr.next {
+			var b strings.Builder
+			if combinator != "" {
+				b.WriteString(combinator + " ")
+			}
+			writeCompoundSelector(&b, &curr.sel)
+			lines = append(lines, b.String())
+			combinator = curr.combinator
+		}
+	}
+	return lines
+}
@@ -0,0 +1,64 @@
+package css
+
+import (
+	"testing"
+)
+
+func TestEqualAST(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"a > b", "a>b", true},
+		{"a  b,  c", "a b,c", true},
+		{"a[href = 'x' i]", `a[href="x" i]`, true},
+		{"a > b", "b > a", false},
+		{"a > b", "a + b", false},
+		{"a b", "a > b", false},
+		{"a, b", "b, a", false},
+		{".a.b", ".b.a", false},
+		{"a[href=x]", "a[href=x i]", false},
+		{":nth-child(odd)", ":nth-child(2n+1)", false},
+	}
+	for _, test := range tests {
+		got := EqualAST(MustParse(test.a), MustParse(test.b))
+		if got != test.want {
+			t.Errorf("EqualAST(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestDiffAST(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"div > a.link", "div>a.link", ""},
+		{
+			"div > a.link", "div > a.external",
+			"  div\n" +
+				"- > a.link\n" +
+				"+ > a.external\n",
+		},
+		{
+			"nav a, p", "#main nav a, p",
+			"+ #main\n" +
+				"  nav\n" +
+				"  a\n" +
+				"  ,\n" +
+				"  p\n",
+		},
+		{
+			"a, b", "a",
+			"  a\n" +
+				"- ,\n" +
+				"- b\n",
+		},
+	}
+	for _, test := range tests {
+		got := DiffAST(MustParse(test.a), MustParse(test.b))
+		if got != test.want {
+			t.Errorf("DiffAST(%q, %q) returned\n%s\nwant\n%s", test.a, test.b, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package css
+
+import (
+	"strings"
+)
+
+// Specifications that define selector features.
+const (
+	SpecSelectors3   = "selectors-3"
+	SpecSelectors4   = "selectors-4"
+	SpecScoping1     = "css-scoping-1"
+	SpecShadowParts1 = "css-shadow-parts-1"
+	// SpecExtension is reported for non-standard features enabled by
+	// WithExtensions.
+	SpecExtension = "extension"
+)
+
+// Feature is a selector feature used by a selector.
+type Feature struct {
+	// Name describes the feature, such as "child combinator", ":has()", or
+	// "case-insensitive attribute".
+	Name string
+	// Spec is the earliest specification that defines the feature. Features
+	// from CSS 1 and 2 are reported as SpecSelectors3.
+	Spec string
+	// Supported reports if this package can match the feature.
+	Supported bool
+}
+
+// selectors3PseudoClasses holds pseudo-classes defined by Selectors Level 3.
+// Pseudo-classes not in this list are reported as Level 4.
+var selectors3PseudoClasses = map[string]bool{
+	"active":            true,
+	"checked":           true,
+	"disabled":          true,
+	"empty":             true,
+	"enabled":           true,
+	"first-child":       true,
+	"first-of-type":     true,
+	"focus":             true,
+	"hover":             true,
+	"lang(":             true,
+	"last-child":        true,
+	"last-of-type":      true,
+	"link":              true,
+	"not(":              true,
+	"nth-child(":        true,
+	"nth-last-child(":   true,
+	"nth-last-of-type(": true,
+	"nth-of-type(":      true,
+	"only-child":        true,
+	"only-of-type":      true,
+	"root":              true,
+	"target":            true,
+	"visited":           true,
+}
+
+// extensionPseudoClasses holds non-standard pseudo-classes enabled by
+// WithExtensions.
+var extensionPseudoClasses = map[string]bool{
+	"button":    true,
+	"checkbox":  true,
+	"contains(": true,
+	"header":    true,
+	"hidden":    true,
+	"input":     true,
+	"radio":     true,
+	"role(":     true,
+	"submit":    true,
+	"visible":   true,
+}
+
+// Features reports the features used by a selector list, such as combinators,
+// attribute matchers, and pseudo-classes, along with the specification that
+// defines them. Features are returned in the order they first appear.
+//
+// Unlike Parse, Features accepts selectors that use features this package
+// can't match, which are reported with Supported set to false. This can be
+// used to validate that selectors are portable between engines.
+func Features(s string) ([]Feature, error) {
+	list, err := parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var features []Feature
+	seen := map[string]bool{}
+	add := func(pos int, name, spec string, supported bool) {
+		if !seen[name] {
+			seen[name] = true
+			features = append(features, Feature{name, spec, supported})
+		}
+	}
+	for i := range list {
+		complexFeatures(&list[i], add)
+	}
+	return features, nil
+}
+
+// featureFunc is called for each feature found in a selector, along with the
+// position of the selector that uses it.
+type featureFunc func(pos int, name, spec string, supported bool)
+
+func complexFeatures(cs *complexSelector, add featureFunc) {
+	for curr := cs; curr != nil; curr = curr.next {
+		compoundFeatures(&curr.sel, add)
+		if curr.next == nil {
+			break
+		}
+		pos := curr.sel.end
+		switch curr.combinator {
+		case "":
+			add(pos, "descendant combinator", SpecSelectors3, true)
+		case ">":
+			add(pos, "child combinator", SpecSelectors3, true)
+		case "+":
+			add(pos, "next-sibling combinator", SpecSelectors3, true)
+		case "~":
+			add(pos, "subsequent-sibling combinator", SpecSelectors3, true)
+		case "||":
+			add(pos, "column combinator", SpecSelectors4, false)
+		case "^":
+			add(pos, "reverse combinator", SpecExtension, false)
+		default:
+			add(pos, curr.combinator+" combinator", SpecExtension, false)
+		}
+	}
+}
+
+func compoundFeatures(c *compoundSelector, add featureFunc) {
+	if t := c.typeSelector; t != nil {
+		if t.value == "*" {
+			add(c.pos, "universal selector", SpecSelectors3, true)
+		} else {
+			add(c.pos, "type selector", SpecSelectors3, true)
+		}
+		if t.hasPrefix {
+			add(c.pos, "namespace prefix", SpecSelectors3, true)
+		}
+	}
+	for _, sc := range c.subClasses {
+		switch {
+		case sc.idSelector != "":
+			add(sc.pos, "id selector", SpecSelectors3, true)
+		case sc.classSelector != "":
+			add(sc.pos, "class selector", SpecSelectors3, true)
+		case sc.attributeSelector != nil:
+			a := sc.attributeSelector
+			if a.wqName.hasPrefix {
+				add(a.pos, "namespace prefix", SpecSelectors3, true)
+			}
+			switch a.matcher {
+			case "":
+				add(a.pos, "[attr]", SpecSelectors3, true)
+			case "%=":
+				add(a.pos, "[attr%=value]", SpecExtension, false)
+			default:
+				if strings.HasPrefix(a.matcher, ":") {
+					add(a.pos, "[attr"+a.matcher+"()]", SpecExtension, false)
+					break
+				}
+				add(a.pos, "[attr"+a.matcher+"value]", SpecSelectors3, true)
+			}
+			if a.modifier {
+				add(a.pos, "case-insensitive attribute", SpecSelectors4, true)
+			}
+		case sc.pseudoClassSelector != nil:
+			pseudoClassFeatures(sc.pseudoClassSelector, add)
+		}
+	}
+	for _, ps := range c.pseudoSelectors {
+		name, spec := "::"+ps.element.ident, SpecSelectors3
+		if ps.element.function != "" {
+			name = "::" + ps.element.function + ")"
+		}
+		switch ps.element.function {
+		case "part(":
+			spec = SpecShadowParts1
+		case "slotted(":
+			spec = SpecScoping1
+		}
+		el := compoundSelector{pseudoSelectors: []pseudoSelector{{element: ps.element}}}
+		add(ps.pos, name, spec, compiles(el))
+		for i := range ps.classes {
+			pseudoClassFeatures(&ps.classes[i], add)
+		}
+	}
+}
+
+func pseudoClassFeatures(p *pseudoClassSelector, add featureFunc) {
+	key := p.ident
+	name := ":" + p.ident
+	if p.function != "" {
+		key = p.function
+		name = ":" + p.function + ")"
+	}
+	spec := SpecSelectors4
+	switch {
+	case selectors3PseudoClasses[key]:
+		spec = SpecSelectors3
+	case key == "host" || key == "host(" || key == "host-context(":
+		spec = SpecScoping1
+	case extensionPseudoClasses[key]:
+		spec = SpecExtension
+	}
+	supported := compiles(compoundSelector{subClasses: []subclassSelector{{pseudoClassSelector: p}}})
+	add(p.pos, name, spec, supported)
+
+	if key == "not(" {
+		// Selectors Level 3 only allows a single simple selector, such as
+		// ":not(.a)", as the argument of :not().
+		list, _, err := parseSelectorListArg(p.args, false)
+		if err == nil {
+			if len(list) != 1 {
+				add(p.pos, ":not() with a selector list", SpecSelectors4, supported)
+			} else if !simpleSelector(&list[0]) {
+				add(p.pos, ":not() with a compound or complex selector", SpecSelectors4, supported)
+			} else if sc := list[0].sel.subClasses; len(sc) == 1 && sc[0].pseudoClassSelector != nil {
+				pseudoClassFeatures(sc[0].pseudoClassSelector, add)
+			}
+		}
+	}
+
+	if key == "lang(" {
+		// Selectors Level 3 only allows a single identifier, such as
+		// ":lang(en)", as the argument of :lang().
+		if _, err := langRanges(p.args); err == nil && !identArg(p.args) {
+			add(p.pos, ":lang() with a language range list", SpecSelectors4, supported)
+		}
+	}
+
+	if strings.HasPrefix(key, "nth-") {
+		for _, t := range p.args {
+			if t.typ == tokenIdent && strings.EqualFold(t.s, "of") {
+				add(p.pos, ":"+p.function+"An+B of S)", SpecSelectors4, supported)
+				break
+			}
+		}
+	}
+}
+
+// simpleSelector reports if cs is a single simple selector, such as "a",
+// ".a" or ":first-child".
+func simpleSelector(cs *complexSelector) bool {
+	c := &cs.sel
+	if cs.next != nil || len(c.pseudoSelectors) != 0 {
+		return false
+	}
+	n := len(c.subClasses)
+	if c.typeSelector != nil {
+		n++
+	}
+	return n == 1
+}
+
+// identArg reports if the arguments of a pseudo-class are a single
+// identifier, optionally surrounded by whitespace.
+func identArg(args []token) bool {
+	n := 0
+	for _, t := range args {
+		switch t.typ {
+		case tokenWhitespace, tokenEOF:
+		case tokenIdent:
+			n++
+		default:
+			return false
+		}
+	}
+	return n == 1
+}
+
+// compiles reports if this package can compile a compound selector.
+func compiles(c compoundSelector) bool {
+	_, err := compile([]complexSelector{{sel: c}})
+	return err == nil
+}
+
+// selectors3 reports an error for each feature used by cs that isn't defined
+// by Selectors Level 3. See WithSelectors3.
+func (c *compiler) selectors3(cs *complexSelector) {
+	complexFeatures(cs, func(pos int, name, spec string, supported bool) {
+		if spec == SpecSelectors3 {
+			return
+		}
+		if strings.HasPrefix(name, ":not() with") {
+			c.errorf(pos, "%s isn't part of Selectors Level 3, where :not() accepts a single simple selector", name)
+			return
+		}
+		c.errorf(pos, "%s isn't part of Selectors Level 3", name)
+	})
+}
@@ -0,0 +1,127 @@
+package css
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFeatures(t *testing.T) {
+	tests := []struct {
+		sel  string
+		want []Feature
+	}{
+		{
+			"div > a.foo",
+			[]Feature{
+				{"type selector", SpecSelectors3, true},
+				{"child combinator", SpecSelectors3, true},
+				{"class selector", SpecSelectors3, true},
+			},
+		},
+		{
+			`[href^="https" i]:first-child, * ~ *`,
+			[]Feature{
+				{"[attr^=value]", SpecSelectors3, true},
+				{"case-insensitive attribute", SpecSelectors4, true},
+				{":first-child", SpecSelectors3, true},
+				{"universal selector", SpecSelectors3, true},
+				{"subsequent-sibling combinator", SpecSelectors3, true},
+			},
+		},
+		{
+			"col || td:has(> p)",
+			[]Feature{
+				{"type selector", SpecSelectors3, true},
+				{"column combinator", SpecSelectors4, false},
+				{":has()", SpecSelectors4, true},
+			},
+		},
+		{
+			":host(.a)::part(label)",
+			[]Feature{
+				{":host()", SpecScoping1, true},
+				{"::part()", SpecShadowParts1, true},
+			},
+		},
+		{
+			"::before",
+			[]Feature{
+				{"::before", SpecSelectors3, false},
+			},
+		},
+		{
+			"p:first-line",
+			[]Feature{
+				{"type selector", SpecSelectors3, true},
+				{"::first-line", SpecSelectors3, false},
+			},
+		},
+	}
+	for _, test := range tests {
+		got, err := Features(test.sel)
+		if err != nil {
+			t.Errorf("Features(%q) failed: %v", test.sel, err)
+			continue
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Features(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+
+	if _, err := Features("a["); err == nil {
+		t.Errorf("Features() with invalid selector didn't return an error")
+	}
+}
+
+func TestSelectors3(t *testing.T) {
+	valid := []string{
+		"div > a.foo",
+		"ul li:nth-child(2n+1), p ~ span + b",
+		`[href^="https"]:first-child`,
+		"svg|circle",
+		"p:not(.a):not([hidden])",
+		"p:lang(en)",
+	}
+	for _, sel := range valid {
+		if _, err := Parse(sel, WithSelectors3()); err != nil {
+			t.Errorf("Parse(%q, WithSelectors3()) failed: %v", sel, err)
+		}
+	}
+
+	tests := []struct {
+		sel string
+		msg string
+		pos int
+		// unsupported is set for features Parse rejects regardless.
+		unsupported bool
+	}{
+		{`a[href="x" i]`, "case-insensitive attribute isn't part of Selectors Level 3", 1, false},
+		{"a, p:is(a, b)", ":is() isn't part of Selectors Level 3", 4, false},
+		{"div:has(> p)", ":has() isn't part of Selectors Level 3", 3, false},
+		{"li:nth-child(2n of .x)", ":nth-child(An+B of S) isn't part of Selectors Level 3", 2, true},
+		{"col || td", "column combinator isn't part of Selectors Level 3", 3, true},
+		{"a:role(button)", ":role() isn't part of Selectors Level 3", 1, false},
+		{"p:not(.a, .b)", ":not() with a selector list isn't part of Selectors Level 3, where :not() accepts a single simple selector", 1, false},
+		{"p:not(.a.b)", ":not() with a compound or complex selector isn't part of Selectors Level 3, where :not() accepts a single simple selector", 1, false},
+		{"p:not(div > p)", ":not() with a compound or complex selector isn't part of Selectors Level 3, where :not() accepts a single simple selector", 1, false},
+		{"p:not(:is(.a))", ":is() isn't part of Selectors Level 3", 6, false},
+		{"p:lang(en, fr)", ":lang() with a language range list isn't part of Selectors Level 3", 1, false},
+		{`p:lang("*-CH")`, ":lang() with a language range list isn't part of Selectors Level 3", 1, false},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.sel, WithSelectors3(), WithExtensions())
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("Parse(%q, WithSelectors3()) returned %v, want *ParseError", test.sel, err)
+			continue
+		}
+		if perr.Msg != test.msg || perr.Pos != test.pos {
+			t.Errorf("Parse(%q, WithSelectors3()) returned error %q at %d, want %q at %d", test.sel, perr.Msg, perr.Pos, test.msg, test.pos)
+		}
+		if _, err := Parse(test.sel, WithExtensions()); err != nil && !test.unsupported {
+			t.Errorf("Parse(%q) failed without WithSelectors3(): %v", test.sel, err)
+		}
+	}
+}
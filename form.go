@@ -0,0 +1,67 @@
+package css
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// The form extensions match the pseudo-classes of the same name supported by
+// jQuery, which select form controls by element and type.
+//
+// https://api.jquery.com/category/selectors/jquery-selector-extensions/
+
+// inputType returns the lowercased type attribute of n, if it's an HTML
+// element with the given name.
+func inputType(n *html.Node, a atom.Atom) (string, bool) {
+	if n.Namespace != "" || n.DataAtom != a {
+		return "", false
+	}
+	typ, _ := attr(n, "type")
+	return strings.ToLower(strings.TrimSpace(typ)), true
+}
+
+// https://api.jquery.com/input-selector/
+func inputMatcher(n *html.Node) bool {
+	if n.Namespace != "" {
+		return false
+	}
+	switch n.DataAtom {
+	case atom.Input, atom.Textarea, atom.Select, atom.Button:
+		return true
+	}
+	return false
+}
+
+// https://api.jquery.com/button-selector/
+func buttonMatcher(n *html.Node) bool {
+	if n.Namespace == "" && n.DataAtom == atom.Button {
+		return true
+	}
+	typ, ok := inputType(n, atom.Input)
+	return ok && typ == "button"
+}
+
+// inputTypeMatcher returns a matcher for inputs of the given type, such as
+// :checkbox.
+func inputTypeMatcher(want string) func(n *html.Node) bool {
+	return func(n *html.Node) bool {
+		typ, ok := inputType(n, atom.Input)
+		return ok && typ == want
+	}
+}
+
+// submitMatcher matches inputs of type submit, and buttons whose type is
+// submit. A button's type defaults to submit if it's missing or invalid.
+//
+// https://api.jquery.com/submit-selector/
+func submitMatcher(n *html.Node) bool {
+	if typ, ok := inputType(n, atom.Input); ok {
+		return typ == "submit"
+	}
+	if typ, ok := inputType(n, atom.Button); ok {
+		return typ != "button" && typ != "reset"
+	}
+	return false
+}
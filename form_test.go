@@ -0,0 +1,69 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestFormExtensions(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<form>
+<input id="text">
+<input id="check" type="checkbox"><input id="check-upper" type=" CHECKBOX ">
+<input id="radio" type="radio">
+<input id="input-button" type="button"><input id="input-submit" type="submit"><input id="reset" type="reset">
+<button id="default"></button><button id="button" type="button"></button>
+<button id="button-submit" type="submit"></button><button id="invalid" type="bogus"></button>
+<button id="button-reset" type="reset"></button>
+<textarea id="textarea"></textarea><select id="select"><option id="option"></option></select>
+<label id="label"></label>
+</form>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{
+			":input",
+			[]string{
+				"text", "check", "check-upper", "radio", "input-button", "input-submit", "reset",
+				"default", "button", "button-submit", "invalid", "button-reset", "textarea", "select",
+			},
+		},
+		{
+			":button",
+			[]string{"input-button", "default", "button", "button-submit", "invalid", "button-reset"},
+		},
+		{":checkbox", []string{"check", "check-upper"}},
+		{":radio", []string{"radio"}},
+		{":submit", []string{"input-submit", "default", "button-submit", "invalid"}},
+		{"input:is(:checkbox, :radio)", []string{"check", "check-upper", "radio"}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel, WithExtensions())
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		var got []string
+		for _, n := range s.Select(root) {
+			id, _ := attr(n, "id")
+			got = append(got, id)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+
+	for _, sel := range []string{":input", ":button", ":checkbox", ":radio", ":submit"} {
+		if _, err := Parse(sel); err == nil {
+			t.Errorf("Parse(%q) without WithExtensions() didn't return an error", sel)
+		}
+	}
+}
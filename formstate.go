@@ -0,0 +1,345 @@
+package css
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Form state pseudo-classes are matched against the state a browser would
+// give form controls after parsing the document, derived from their content
+// attributes. Changes a user or script would make aren't represented.
+//
+// https://html.spec.whatwg.org/multipage/semantics-other.html#pseudo-classes
+
+// checkedness reports if n is a checked checkbox or radio button, or a
+// selected option.
+//
+// https://html.spec.whatwg.org/multipage/semantics-other.html#selector-checked
+func (s *state) checkedness(n *html.Node) bool {
+	if typ, ok := inputType(n, atom.Input); ok {
+		switch typ {
+		case "checkbox":
+			_, ok := attr(n, "checked")
+			return ok
+		case "radio":
+			return s.checkedRadio(n)
+		}
+		return false
+	}
+	if n.Namespace == "" && n.DataAtom == atom.Option {
+		return selectedOption(n)
+	}
+	return false
+}
+
+// checkedRadio reports if the radio button n is checked. Checking a radio
+// button unchecks the others in its group, so of the buttons in a group with
+// the checked attribute, only the last is checked.
+//
+// Rather than searching the tree for the rest of each button's group, the
+// checked buttons of a tree are found once per selection.
+//
+// https://html.spec.whatwg.org/multipage/input.html#radio-button-group
+func (s *state) checkedRadio(n *html.Node) bool {
+	if _, ok := attr(n, "checked"); !ok {
+		return false
+	}
+	root := treeRoot(n)
+	if !s.radiosScanned[root] {
+		if s.radiosScanned == nil {
+			s.radiosScanned = map[*html.Node]bool{}
+			s.checkedRadios = map[*html.Node]bool{}
+		}
+		s.radiosScanned[root] = true
+		for _, r := range checkedRadios(root) {
+			s.checkedRadios[r] = true
+		}
+	}
+	return s.checkedRadios[n]
+}
+
+// radioGroup identifies a radio button group by form owner and name.
+type radioGroup struct {
+	owner *html.Node
+	name  string
+}
+
+// checkedRadios returns the checked radio buttons in the tree rooted at root.
+func checkedRadios(root *html.Node) []*html.Node {
+	var (
+		// ids maps ids to the first element with that id, to resolve form
+		// attributes.
+		ids     = map[string]*html.Node{}
+		checked []*html.Node
+		radios  []*html.Node
+	)
+	walkElements(root, func(e *html.Node) bool {
+		if id, ok := attr(e, "id"); ok {
+			if _, seen := ids[id]; !seen {
+				ids[id] = e
+			}
+		}
+		if typ, ok := inputType(e, atom.Input); ok && typ == "radio" {
+			if _, ok := attr(e, "checked"); ok {
+				radios = append(radios, e)
+			}
+		}
+		return true
+	})
+
+	// The last button with the checked attribute in each group is checked.
+	last := map[radioGroup]*html.Node{}
+	for _, r := range radios {
+		name, _ := attr(r, "name")
+		if name == "" {
+			// A button without a name is in a group of its own.
+			checked = append(checked, r)
+			continue
+		}
+		last[radioGroup{formOwner(r, ids), name}] = r
+	}
+	for _, r := range last {
+		checked = append(checked, r)
+	}
+	return checked
+}
+
+// formOwner returns the form a control is associated with: the form named by
+// its form attribute, or otherwise its nearest form ancestor. It returns nil
+// if the control has no form owner. ids maps the ids of the control's tree
+// to the first element with that id.
+//
+// https://html.spec.whatwg.org/multipage/form-control-infrastructure.html#form-owner
+func formOwner(n *html.Node, ids map[string]*html.Node) *html.Node {
+	if id, ok := attr(n, "form"); ok {
+		if e := ids[id]; e != nil && e.Namespace == "" && e.DataAtom == atom.Form {
+			return e
+		}
+		return nil
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Namespace == "" && p.DataAtom == atom.Form {
+			return p
+		}
+	}
+	return nil
+}
+
+// selectedOption reports if the option n is selected. Options with the
+// selected attribute are selected, except in a select element that only
+// allows one selection, where the last such option is selected. If none of
+// its options have the attribute, a drop-down select element selects its first
+// option that isn't disabled.
+//
+// https://html.spec.whatwg.org/multipage/form-elements.html#selectedness-setting-algorithm
+func selectedOption(n *html.Node) bool {
+	_, selected := attr(n, "selected")
+	sel := optionSelect(n)
+	if sel == nil {
+		return selected
+	}
+	if _, ok := attr(sel, "multiple"); ok {
+		return selected
+	}
+	var last, firstEnabled *html.Node
+	for _, o := range selectOptions(sel) {
+		if _, ok := attr(o, "selected"); ok {
+			last = o
+		}
+		if firstEnabled == nil && !disabledOption(o) {
+			firstEnabled = o
+		}
+	}
+	if last != nil {
+		return last == n
+	}
+	if size, ok := attr(sel, "size"); ok {
+		if v, err := strconv.Atoi(strings.TrimSpace(size)); err == nil && v > 1 {
+			return false
+		}
+	}
+	return firstEnabled == n
+}
+
+// optionSelect returns the select element whose list of options includes n,
+// or nil if there isn't one.
+func optionSelect(n *html.Node) *html.Node {
+	p := n.Parent
+	if p != nil && p.Type == html.ElementNode && p.Namespace == "" && p.DataAtom == atom.Optgroup {
+		p = p.Parent
+	}
+	if p != nil && p.Type == html.ElementNode && p.Namespace == "" && p.DataAtom == atom.Select {
+		return p
+	}
+	return nil
+}
+
+// selectOptions returns the list of options of a select element, its option
+// children and those of its optgroup children, in tree order.
+//
+// https://html.spec.whatwg.org/multipage/form-elements.html#concept-select-option-list
+func selectOptions(sel *html.Node) []*html.Node {
+	var options []*html.Node
+	for c := firstElementChild(sel); c != nil; c = nextElementSibling(c) {
+		if c.Namespace != "" {
+			continue
+		}
+		switch c.DataAtom {
+		case atom.Option:
+			options = append(options, c)
+		case atom.Optgroup:
+			for o := firstElementChild(c); o != nil; o = nextElementSibling(o) {
+				if o.Namespace == "" && o.DataAtom == atom.Option {
+					options = append(options, o)
+				}
+			}
+		}
+	}
+	return options
+}
+
+// disabledOption reports if the option n is disabled, either by its own
+// disabled attribute or that of its optgroup.
+//
+// https://html.spec.whatwg.org/multipage/form-elements.html#concept-option-disabled
+func disabledOption(n *html.Node) bool {
+	if _, ok := attr(n, "disabled"); ok {
+		return true
+	}
+	p := n.Parent
+	if p != nil && p.Type == html.ElementNode && p.Namespace == "" && p.DataAtom == atom.Optgroup {
+		_, ok := attr(p, "disabled")
+		return ok
+	}
+	return false
+}
+
+// actuallyDisabled reports if the element n, which supports the disabled
+// attribute, is disabled. Options are disabled by their own attribute or their
+// optgroup's. Other form controls are also disabled by a disabled fieldset
+// ancestor, unless they're within the fieldset's first legend.
+//
+// https://html.spec.whatwg.org/multipage/semantics-other.html#concept-element-disabled
+func actuallyDisabled(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.Option:
+		return disabledOption(n)
+	case atom.Optgroup:
+		_, ok := attr(n, "disabled")
+		return ok
+	}
+	if _, ok := attr(n, "disabled"); ok {
+		return true
+	}
+	child := n
+	for p := n.Parent; p != nil && p.Type == html.ElementNode; child, p = p, p.Parent {
+		if p.Namespace != "" || p.DataAtom != atom.Fieldset {
+			continue
+		}
+		if _, ok := attr(p, "disabled"); !ok {
+			continue
+		}
+		if child != firstLegend(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstLegend returns the first legend child of a fieldset, or nil.
+func firstLegend(fieldset *html.Node) *html.Node {
+	for c := firstElementChild(fieldset); c != nil; c = nextElementSibling(c) {
+		if c.Namespace == "" && c.DataAtom == atom.Legend {
+			return c
+		}
+	}
+	return nil
+}
+
+// uneditableInputTypes are the input types the readonly attribute doesn't
+// apply to. Inputs of other types, including those with a missing or invalid
+// type, which are text inputs, can be edited.
+//
+// https://html.spec.whatwg.org/multipage/input.html#attr-input-readonly
+var uneditableInputTypes = map[string]bool{
+	"button":   true,
+	"checkbox": true,
+	"color":    true,
+	"file":     true,
+	"hidden":   true,
+	"image":    true,
+	"radio":    true,
+	"range":    true,
+	"reset":    true,
+	"submit":   true,
+}
+
+// readWriteMatcher matches elements the user can edit: text inputs and
+// textareas that aren't readonly or disabled, and elements made editable
+// by the contenteditable attribute.
+//
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:read-write
+// https://html.spec.whatwg.org/multipage/semantics-other.html#selector-read-write
+func readWriteMatcher(n *html.Node) bool {
+	if n.Namespace == "" {
+		switch n.DataAtom {
+		case atom.Input:
+			if typ, _ := inputType(n, atom.Input); uneditableInputTypes[typ] {
+				return false
+			}
+			fallthrough
+		case atom.Textarea:
+			_, readonly := attr(n, "readonly")
+			return !readonly && !actuallyDisabled(n)
+		}
+	}
+	return editable(n)
+}
+
+// readOnlyMatcher matches elements that aren't matched by readWriteMatcher.
+//
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:read-only
+func readOnlyMatcher(n *html.Node) bool {
+	return !readWriteMatcher(n)
+}
+
+// editable reports if n is editable because of the contenteditable attribute
+// of n or its nearest ancestor that sets it.
+//
+// https://html.spec.whatwg.org/multipage/interaction.html#attr-contenteditable
+func editable(n *html.Node) bool {
+	for e := n; e != nil && e.Type == html.ElementNode; e = e.Parent {
+		if e.Namespace != "" {
+			continue
+		}
+		val, ok := attr(e, "contenteditable")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(val)) {
+		case "", "true", "plaintext-only":
+			return true
+		case "false":
+			return false
+		}
+		// Invalid values inherit from the parent.
+	}
+	return false
+}
+
+// walkElements calls fn for each element in the tree rooted at n, in
+// document order, until fn returns false.
+func walkElements(n *html.Node, fn func(e *html.Node) bool) bool {
+	if n.Type == html.ElementNode && !fn(n) {
+		return false
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if !walkElements(c, fn) {
+			return false
+		}
+	}
+	return true
+}
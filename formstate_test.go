@@ -0,0 +1,104 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestChecked(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<input id="check" type="checkbox" checked><input id="unchecked" type="checkbox">
+<input id="text" checked>
+<input id="r1" type="radio" name="a" checked><input id="r2" type="Radio" name="a" checked>
+<input id="r3" type="radio" name="b" checked><input id="r4" type="radio" checked>
+<form><input id="r5" type="radio" name="b" checked></form>
+<input id="r6" type="radio" name="c" form="f" checked><form id="f"><input id="r7" type="radio" name="c" checked></form>
+<select><option id="o1">1</option><option id="o2">2</option></select>
+<select><option id="o3" disabled>3</option><optgroup><option id="o4">4</option></optgroup></select>
+<select><option id="o5" selected>5</option><option id="o6" selected>6</option></select>
+<select multiple><option id="o7" selected>7</option><option id="o8" selected>8</option><option id="o9">9</option></select>
+<select size="3"><option id="o10">10</option></select>
+<datalist><option id="o11" selected>11</option></datalist>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	s := MustParse(":checked")
+	var got []string
+	for _, n := range s.Select(root) {
+		id, _ := attr(n, "id")
+		got = append(got, id)
+	}
+	want := []string{"check", "r2", "r3", "r4", "r5", "r7", "o1", "o4", "o6", "o7", "o8", "o11"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf(":checked returned diff (-want, +got): %s", diff)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<input id="i1" disabled><input id="i2">
+<fieldset id="f1" disabled>
+	<legend><input id="i3"></legend>
+	<legend><input id="i4"></legend>
+	<div><button id="b1"></button></div>
+	<fieldset id="f2"><select id="s1"></select></fieldset>
+</fieldset>
+<fieldset id="f3"><legend><fieldset id="f4" disabled><textarea id="t1"></textarea></fieldset></legend></fieldset>
+<select id="s2"><optgroup id="g1" disabled><option id="o1"></option></optgroup><option id="o2" disabled></option><option id="o3"></option></select>
+<div id="d1" disabled></div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{":disabled", []string{"i1", "f1", "i4", "b1", "f2", "s1", "f4", "t1", "g1", "o1", "o2"}},
+		{":enabled", []string{"i2", "i3", "f3", "s2", "o3"}},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, n := range MustParse(test.sel).Select(root) {
+			id, _ := attr(n, "id")
+			got = append(got, id)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<input id="i1"><input id="i2" type="EMAIL"><input id="i3" type="bogus">
+<input id="i4" readonly><input id="i5" disabled><input id="i6" type="checkbox">
+<textarea id="t1"></textarea><textarea id="t2" readonly></textarea>
+<fieldset disabled><textarea id="t3"></textarea></fieldset>
+<div id="d1" contenteditable><p id="p1"></p><p id="p2" contenteditable="false"></p><p id="p3" contenteditable="bogus"></p></div>
+<div id="d2" contenteditable="plaintext-only"></div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	var got []string
+	for _, n := range MustParse(":read-write").Select(root) {
+		id, _ := attr(n, "id")
+		got = append(got, id)
+	}
+	want := []string{"i1", "i2", "i3", "t1", "d1", "p1", "p3", "d2"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf(":read-write returned diff (-want, +got): %s", diff)
+	}
+
+	got = nil
+	for _, n := range MustParse("input:read-only, textarea:read-only, p:read-only").Select(root) {
+		id, _ := attr(n, "id")
+		got = append(got, id)
+	}
+	want = []string{"i4", "i5", "i6", "t2", "t3", "p2"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf(":read-only returned diff (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,86 @@
+package css
+
+import (
+	"golang.org/x/net/html"
+)
+
+// SelectAll is like Select, but evaluates the selector across multiple roots,
+// such as the nodes returned by html.ParseFragment. Matches are returned in
+// the order of roots, then in document order, without duplicates.
+//
+// Roots that have no parent or siblings are treated as siblings of one
+// another, so sibling combinators and pseudo-classes such as :nth-child()
+// consider the other top-level nodes of a fragment. The roots aren't modified,
+// so like Select, SelectAll can be called concurrently on the same nodes.
+func (s *Selector) SelectAll(roots []*html.Node) []*html.Node {
+	var st *state
+	if len(roots) > 1 && detached(roots) {
+		st = s.newState(roots[0], &MatchContext{})
+		st.fragment = make(map[*html.Node]int, len(roots))
+		for i, n := range roots {
+			st.fragment[n] = i
+			if st.scope == nil && isElement(n) {
+				st.scope = n
+			}
+		}
+		st.roots = roots
+	}
+
+	selected := []*html.Node{}
+	seen := map[*html.Node]bool{}
+	for _, root := range roots {
+		var nodes []*html.Node
+		if st != nil {
+			nodes = s.selectState(st, root)
+		} else {
+			nodes = s.Select(root)
+		}
+		for _, n := range nodes {
+			if !seen[n] {
+				seen[n] = true
+				selected = append(selected, n)
+			}
+		}
+	}
+	return selected
+}
+
+// detached reports if every node has no parent or siblings.
+func detached(nodes []*html.Node) bool {
+	for _, n := range nodes {
+		if n.Parent != nil || n.PrevSibling != nil || n.NextSibling != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// prevElementSibling returns the previous sibling of n that's an element,
+// treating the roots passed to SelectAll as siblings.
+func (s *state) prevElementSibling(n *html.Node) *html.Node {
+	i, ok := s.fragment[n]
+	if !ok {
+		return prevElementSibling(n)
+	}
+	for i--; i >= 0; i-- {
+		if isElement(s.roots[i]) {
+			return s.roots[i]
+		}
+	}
+	return nil
+}
+
+// nextElementSibling returns the next sibling of n that's an element,
+// treating the roots passed to SelectAll as siblings.
+func (s *state) nextElementSibling(n *html.Node) *html.Node {
+	i, ok := s.fragment[n]
+	if !ok {
+		return nextElementSibling(n)
+	}
+	for i++; i < len(s.roots); i++ {
+		if isElement(s.roots[i]) {
+			return s.roots[i]
+		}
+	}
+	return nil
+}
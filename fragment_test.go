@@ -0,0 +1,70 @@
+package css
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestSelectAll(t *testing.T) {
+	context := &html.Node{Type: html.ElementNode, Data: "ul", DataAtom: atom.Ul}
+	roots, err := html.ParseFragment(strings.NewReader(`<li>1</li><li class="a">2</li><li>3<span></span></li>`), context)
+	if err != nil {
+		t.Fatalf("html.ParseFragment() failed: %v", err)
+	}
+
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{"li:first-child", []string{`<li>1</li>`}},
+		{"li:nth-child(2n+1)", []string{`<li>1</li>`, `<li>3<span></span></li>`}},
+		{".a + li", []string{`<li>3<span></span></li>`}},
+		{".a ~ li span", []string{`<span></span>`}},
+		{"li:last-child", []string{`<li>3<span></span></li>`}},
+		{"li:nth-last-of-type(2)", []string{`<li class="a">2</li>`}},
+		{"li:has(+ .a)", []string{`<li>1</li>`}},
+		{":scope ~ .a", []string{`<li class="a">2</li>`}},
+	}
+	for _, test := range tests {
+		got := MustParse(test.sel).SelectAll(roots)
+		if diff := cmp.Diff(test.want, renderNodes(t, got)); diff != "" {
+			t.Errorf("SelectAll(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+
+	// Roots aren't modified, so fragments can be selected from concurrently.
+	// Run with -race to detect writes.
+	sel := MustParse("li + li")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := len(sel.SelectAll(roots)); got != 2 {
+				t.Errorf("SelectAll() concurrently returned %d elements, want 2", got)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, n := range roots {
+		if n.Parent != nil || n.PrevSibling != nil || n.NextSibling != nil {
+			t.Errorf("SelectAll() modified fragment roots")
+		}
+	}
+
+	// Roots that are already part of a tree are selected from individually.
+	doc, err := html.Parse(strings.NewReader(`<div><p>1</p></div><div><p>2</p></div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	divs := MustParse("div").Select(doc)
+	got := MustParse("p").SelectAll([]*html.Node{divs[1], divs[0], divs[1]})
+	if diff := cmp.Diff([]string{`<p>2</p>`, `<p>1</p>`}, renderNodes(t, got)); diff != "" {
+		t.Errorf("SelectAll() returned diff (-want, +got): %s", diff)
+	}
+}
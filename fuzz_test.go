@@ -1,51 +1,56 @@
 package css
 
 import (
+	"regexp"
 	"strings"
 	"testing"
 
+	"github.com/andybalholm/cascadia"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/net/html"
 )
 
+var parseCorpus = []string{
+	"*",
+	"a",
+	"ns|a",
+	".red",
+	"#demo",
+	"[attr]",
+	"[attr=value]",
+	"[herf~=foo]",
+	"[herf|=foo]",
+	"[herf^=foo]",
+	"[herf$=foo]",
+	"[herf*=foo]",
+	"[herf=foo i]",
+	"h1 a",
+	"h1, a",
+	"h1 > a",
+	"h1 ~ a",
+	"h1 + a",
+	"h1:empty",
+	"h1:first-child",
+	"h1:first-of-type",
+	"h1:last-child",
+	"h1:last-of-type",
+	"h1:only-child",
+	"h1:only-of-type",
+	"h1:root",
+	"h1:nth-child(1n + 3)",
+	"h1:nth-child(odd)",
+	"h1:nth-child(even)",
+	"h1:nth-child(1n)",
+	"h1:nth-child(3)",
+	"h1:nth-child(+3)",
+	"h1:last-child(1n + 3)",
+	"h1:last-of-type(1n + 3)",
+	"h1:nth-of-type(1n + 3)",
+}
+
 func FuzzParse(f *testing.F) {
-	corpus := []string{
-		"*",
-		"a",
-		"ns|a",
-		".red",
-		"#demo",
-		"[attr]",
-		"[attr=value]",
-		"[herf~=foo]",
-		"[herf|=foo]",
-		"[herf^=foo]",
-		"[herf$=foo]",
-		"[herf*=foo]",
-		"[herf=foo i]",
-		"h1 a",
-		"h1, a",
-		"h1 > a",
-		"h1 ~ a",
-		"h1 + a",
-		"h1:empty",
-		"h1:first-child",
-		"h1:first-of-type",
-		"h1:last-child",
-		"h1:last-of-type",
-		"h1:only-child",
-		"h1:only-of-type",
-		"h1:root",
-		"h1:nth-child(1n + 3)",
-		"h1:nth-child(odd)",
-		"h1:nth-child(even)",
-		"h1:nth-child(1n)",
-		"h1:nth-child(3)",
-		"h1:nth-child(+3)",
-		"h1:last-child(1n + 3)",
-		"h1:last-of-type(1n + 3)",
-		"h1:nth-of-type(1n + 3)",
-	}
-	for _, s := range corpus {
+	for _, s := range parseCorpus {
 		f.Add(s)
 	}
 	f.Fuzz(func(t *testing.T, s string) {
@@ -69,3 +74,128 @@ func FuzzSelector(f *testing.F) {
 		s.Select(root)
 	})
 }
+
+// FuzzSerialize verifies that serializing a selector produces a string that
+// parses to an equivalent selector.
+func FuzzSerialize(f *testing.F) {
+	for _, s := range parseCorpus {
+		f.Add(s)
+	}
+	f.Add(`#\31 23`)
+	f.Add(`.a\.b`)
+	f.Add(`[title="a \"quoted\" string"]`)
+	f.Add(`.\-`)
+	f.Add(`.-\31`)
+	opts := []cmp.Option{
+		cmpopts.IgnoreFields(ComplexSelector{}, "Pos", "End"),
+		cmpopts.IgnoreFields(CompoundSelector{}, "Pos", "End"),
+		cmpopts.IgnoreFields(TypeSelector{}, "Pos", "End"),
+		cmpopts.IgnoreFields(SubclassSelector{}, "Pos", "End"),
+		cmpopts.IgnoreFields(AttributeSelector{}, "Pos", "End"),
+		cmpopts.IgnoreFields(PseudoClassSelector{}, "Pos", "End"),
+		cmpopts.IgnoreFields(PseudoElementSelector{}, "Pos", "End"),
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		sel, err := Parse(s)
+		if err != nil {
+			t.Skip()
+		}
+		str := sel.String()
+		got, err := Parse(str)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed to parse serialized selector %q: %v", s, str, err)
+		}
+		if gotStr := got.String(); gotStr != str {
+			t.Errorf("Parse(%q) serialized to %q, reparsing serialized to %q", s, str, gotStr)
+		}
+		if diff := cmp.Diff(sel.AST(), got.AST(), opts...); diff != "" {
+			t.Errorf("Parse(%q) serialized to %q which parsed differently (-want, +got): %s", s, str, diff)
+		}
+	})
+}
+
+// FuzzCascadia compares selection against github.com/andybalholm/cascadia,
+// for selectors that both packages are able to compile.
+func FuzzCascadia(f *testing.F) {
+	for _, test := range selectorTests {
+		f.Add(test.sel, test.in)
+	}
+	f.Fuzz(func(t *testing.T, sel, in string) {
+		s, err := Parse(sel)
+		if err != nil {
+			t.Skip()
+		}
+		cs, err := cascadia.Compile(sel)
+		if err != nil {
+			t.Skip()
+		}
+		root, err := html.Parse(strings.NewReader(in))
+		if err != nil {
+			t.Skip()
+		}
+		if reason := cascadiaDivergence(sel, root); reason != "" {
+			t.Skip(reason)
+		}
+		got := s.Select(root)
+		want := cs.MatchAll(root)
+		if !cmp.Equal(got, want) && structuralPseudoClass.MatchString(sel) {
+			// Following Selectors Level 4, structural pseudo-classes such as
+			// :first-child match the root element, which has no parent
+			// element. Cascadia never matches it.
+			t.Skip("structural pseudo-class matching the root element")
+		}
+		if len(got) != len(want) {
+			t.Fatalf("Selecting %q from %q returned %d elements, cascadia returned %d", sel, in, len(got), len(want))
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Fatalf("Selecting %q from %q returned different element at index %d than cascadia", sel, in, i)
+			}
+		}
+	})
+}
+
+// structuralPseudoClass matches selectors using a pseudo-class that depends
+// on an element's siblings.
+var structuralPseudoClass = regexp.MustCompile(`(?i)(first|last|only|nth)-`)
+
+// cascadiaDivergence returns a reason if selecting sel from root is expected
+// to differ from cascadia, where this package follows Selectors Level 4 and
+// cascadia doesn't.
+func cascadiaDivergence(sel string, root *html.Node) string {
+	// Type selectors match the names of foreign elements, such as SVG's
+	// "foreignObject", case-sensitively. Cascadia lowercases them.
+	if strings.ToLower(sel) != sel && hasForeignElement(root) {
+		return "case-sensitive type selectors for foreign elements"
+	}
+	// :empty ignores ASCII whitespace, while cascadia ignores any Unicode
+	// whitespace, such as "\v".
+	if strings.Contains(strings.ToLower(sel), "empty") && hasNonASCIISpace(root) {
+		return ":empty with text of non-ASCII whitespace"
+	}
+	return ""
+}
+
+func hasNonASCIISpace(n *html.Node) bool {
+	if n.Type == html.TextNode && strings.TrimLeft(n.Data, " \t\n\r\f") != "" && strings.TrimSpace(n.Data) == "" {
+		return true
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if hasNonASCIISpace(c) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasForeignElement(n *html.Node) bool {
+	if n.Type == html.ElementNode && n.Namespace != "" {
+		return true
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if hasForeignElement(c) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,12 @@
 module github.com/ericchiang/css
 
-go 1.15
+go 1.21
 
 require (
+	github.com/andybalholm/cascadia v1.3.1
 	github.com/google/go-cmp v0.5.6
 	golang.org/x/net v0.0.0-20211216030914-fe4d6282115f
+	golang.org/x/text v0.22.0
 )
+
+require golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
@@ -0,0 +1,55 @@
+package css
+
+import (
+	"golang.org/x/net/html"
+)
+
+// Group holds the items selected within a container element.
+type Group struct {
+	Container *html.Node
+	// Items are the selected elements within the container, in document
+	// order.
+	Items []*html.Node
+}
+
+// SelectGrouped selects the elements matching container from root, then the
+// elements matching item within each container, returning the items grouped
+// by the container they were found in:
+//
+//	for _, g := range css.SelectGrouped(doc, css.MustParse(".card"), css.MustParse("a[href]")) {
+//		fmt.Println(path(g.Container), len(g.Items))
+//	}
+//
+// Groups are returned in document order, including containers without any
+// items. Items are selected within each container like the stages of a
+// Pipeline, so the container itself is never an item, and combinators aren't
+// evaluated past the container. When containers are nested, such as a table
+// within a table, an item belongs only to its innermost container.
+func SelectGrouped(root *html.Node, container, item *Selector) []Group {
+	containers := container.Select(root)
+	isContainer := make(map[*html.Node]bool, len(containers))
+	for _, c := range containers {
+		isContainer[c] = true
+	}
+	groups := make([]Group, 0, len(containers))
+	for _, c := range containers {
+		g := Group{Container: c}
+		for _, e := range item.Select(c) {
+			if e != c && innermost(isContainer, e) == c {
+				g.Items = append(g.Items, e)
+			}
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// innermost returns the closest ancestor of n that's a container.
+func innermost(isContainer map[*html.Node]bool, n *html.Node) *html.Node {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if isContainer[p] {
+			return p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestSelectGrouped(t *testing.T) {
+	tests := []struct {
+		container string
+		item      string
+		in        string
+		want      []string
+	}{
+		{
+			container: ".card",
+			item:      "a",
+			in: `<div class="card" id="c1"><a id="a1"></a><p><a id="a2"></a></p></div>` +
+				`<a id="a3"></a>` +
+				`<div class="card" id="c2"><a id="a4"></a></div>` +
+				`<div class="card" id="c3"></div>`,
+			want: []string{"c1: a1 a2", "c2: a4", "c3:"},
+		},
+		{
+			container: "table",
+			item:      "tr",
+			in: `<table id="t1"><tr id="r1"><td><table id="t2"><tr id="r2"></tr></table></td></tr>` +
+				`<tr id="r3"></tr></table>`,
+			want: []string{"t1: r1 r3", "t2: r2"},
+		},
+		{
+			// Items are scoped to their container, and the container itself
+			// isn't an item.
+			container: "section",
+			item:      "section, body p",
+			in:        `<section id="s1"><p id="p1"></p></section>`,
+			want:      []string{"s1:"},
+		},
+	}
+	for _, test := range tests {
+		root, err := html.Parse(strings.NewReader(test.in))
+		if err != nil {
+			t.Fatalf("html.Parse(%q) failed: %v", test.in, err)
+		}
+		var got []string
+		for _, g := range SelectGrouped(root, MustParse(test.container), MustParse(test.item)) {
+			id, _ := attr(g.Container, "id")
+			group := id + ":"
+			for _, n := range g.Items {
+				itemID, _ := attr(n, "id")
+				group += " " + itemID
+			}
+			got = append(got, group)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("SelectGrouped(%q, %q) returned diff (-want, +got): %s", test.container, test.item, diff)
+		}
+	}
+}
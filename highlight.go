@@ -0,0 +1,64 @@
+package css
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Marker describes how Highlight marks matched elements. Any combination of
+// fields may be set.
+type Marker struct {
+	// Wrap, if set, is the tag name of an element, such as "mark", that each
+	// match is wrapped in.
+	Wrap string
+	// Class, if set, is added to the class list of each marker.
+	Class string
+	// Attr, if set, is an attribute set on each marker, holding the index of
+	// the match within the selection, so matches can be related back to the
+	// results of Select.
+	Attr string
+}
+
+// Highlight marks each element in the document matched by sel, so tools that
+// preview a selector can render what it selects:
+//
+//	d := css.NewDocument(root)
+//	d.Highlight(sel, css.Marker{Wrap: "mark", Class: "match", Attr: "data-match"})
+//	html.Render(w, d.Root())
+//
+// A marker is the wrapper element if Wrap is set, and otherwise the matched
+// element itself. Elements that aren't children of another element, such as
+// the html element, can't be wrapped, and are marked directly.
+//
+// The document is modified through its mutation methods, so live queries
+// observe the change. Highlight returns the matched elements, in the order
+// used for the values of Attr.
+func (d *Document) Highlight(sel *Selector, m Marker) []*html.Node {
+	nodes := sel.Select(d.root)
+	for i, n := range nodes {
+		marker := n
+		if parent := n.Parent; m.Wrap != "" && parent != nil && parent.Type == html.ElementNode {
+			marker = &html.Node{
+				Type:     html.ElementNode,
+				Data:     m.Wrap,
+				DataAtom: atom.Lookup([]byte(m.Wrap)),
+			}
+			d.InsertBefore(parent, marker, n)
+			d.RemoveChild(parent, n)
+			d.AppendChild(marker, n)
+		}
+		if m.Class != "" {
+			class, _ := attr(marker, "class")
+			if !containsAll(strings.Fields(class), []string{m.Class}) {
+				d.SetAttr(marker, "class", strings.TrimSpace(class+" "+m.Class))
+			}
+		}
+		if m.Attr != "" {
+			d.SetAttr(marker, m.Attr, strconv.Itoa(i))
+		}
+	}
+	return nodes
+}
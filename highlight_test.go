@@ -0,0 +1,78 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		sel    string
+		marker Marker
+		in     string
+		want   string
+	}{
+		{
+			sel:    "li.x",
+			marker: Marker{Wrap: "mark"},
+			in:     `<ul><li class="x">a</li><li>b</li></ul>`,
+			want:   `<html><head></head><body><ul><mark><li class="x">a</li></mark><li>b</li></ul></body></html>`,
+		},
+		{
+			sel:    "p",
+			marker: Marker{Class: "match", Attr: "data-match"},
+			in:     `<p>a</p><p class="match">b</p><p class="c">c</p>`,
+			want:   `<html><head></head><body><p class="match" data-match="0">a</p><p class="match" data-match="1">b</p><p class="c match" data-match="2">c</p></body></html>`,
+		},
+		{
+			sel:    "span",
+			marker: Marker{Wrap: "mark", Class: "match", Attr: "data-match"},
+			in:     `<div><span id="a"><span id="b">x</span></span></div>`,
+			want:   `<html><head></head><body><div><mark class="match" data-match="0"><span id="a"><mark class="match" data-match="1"><span id="b">x</span></mark></span></mark></div></body></html>`,
+		},
+		{
+			sel:    "body",
+			marker: Marker{Wrap: "mark"},
+			in:     `<p>a</p>`,
+			want:   `<html><head></head><mark><body><p>a</p></body></mark></html>`,
+		},
+		{
+			sel:    "html",
+			marker: Marker{Wrap: "mark", Attr: "data-match"},
+			in:     `<p>a</p>`,
+			want:   `<html data-match="0"><head></head><body><p>a</p></body></html>`,
+		},
+	}
+	for _, test := range tests {
+		root, err := html.Parse(strings.NewReader(test.in))
+		if err != nil {
+			t.Fatalf("html.Parse(%q) failed: %v", test.in, err)
+		}
+		d := NewDocument(root)
+		matched := d.Highlight(MustParse(test.sel), test.marker)
+		if len(matched) == 0 {
+			t.Errorf("Highlight(%q) matched no elements", test.sel)
+		}
+		got := OuterHTML(root)
+		if got != test.want {
+			t.Errorf("Highlight(%q, %+v) on %q returned %q, want %q", test.sel, test.marker, test.in, got, test.want)
+		}
+	}
+}
+
+func TestHighlightLiveQuery(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<p>a</p><p>b</p>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	d := NewDocument(root)
+	q := d.LiveQuery(MustParse("mark > p"))
+	d.Highlight(MustParse("p"), Marker{Wrap: "mark"})
+	want := []string{"<p>a</p>", "<p>b</p>"}
+	if diff := cmp.Diff(want, renderNodes(t, q.Nodes())); diff != "" {
+		t.Errorf("Live query returned diff (-want, +got): %s", diff)
+	}
+}
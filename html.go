@@ -0,0 +1,79 @@
+package css
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// SelectHTML parses an HTML document from r and returns the elements matching
+// selector. Like Select, compiled selectors are cached.
+//
+//	resp, err := http.Get("https://example.com")
+//	// ...
+//	links, err := css.SelectHTML("a[href]", resp.Body)
+func SelectHTML(selector string, r io.Reader) ([]*html.Node, error) {
+	sel, err := selectorCache.parse(selector)
+	if err != nil {
+		return nil, err
+	}
+	root, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return sel.Select(root), nil
+}
+
+// SelectHTMLString is like SelectHTML, but parses the document from a string.
+func SelectHTMLString(selector, s string) ([]*html.Node, error) {
+	return SelectHTML(selector, strings.NewReader(s))
+}
+
+// SelectHTML returns the outer HTML of each element matched in the tree
+// rooted at n, in the order returned by Select. Unlike the SelectHTML
+// function, which parses a document, the method renders its matches:
+//
+//	for _, s := range sel.SelectHTML(root) {
+//		fmt.Println(s) // <li class="item">one</li>
+//	}
+func (s *Selector) SelectHTML(n *html.Node) []string {
+	return renderEach(s.Select(n), OuterHTML)
+}
+
+// SelectInnerHTML is like the SelectHTML method, but returns the HTML of each
+// match's children.
+func (s *Selector) SelectInnerHTML(n *html.Node) []string {
+	return renderEach(s.Select(n), InnerHTML)
+}
+
+func renderEach(nodes []*html.Node, render func(n *html.Node) string) []string {
+	out := make([]string, len(nodes))
+	for i, n := range nodes {
+		out[i] = render(n)
+	}
+	return out
+}
+
+// OuterHTML renders n and its descendants using html.Render.
+//
+// html.Render only fails for trees html.Parse doesn't produce, such as void
+// elements with children. OuterHTML returns the HTML rendered before the
+// failure.
+func OuterHTML(n *html.Node) string {
+	var b strings.Builder
+	html.Render(&b, n)
+	return b.String()
+}
+
+// InnerHTML renders the children of n using html.Render. Like OuterHTML, it
+// returns the HTML rendered before any failure.
+func InnerHTML(n *html.Node) string {
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if err := html.Render(&b, c); err != nil {
+			break
+		}
+	}
+	return b.String()
+}
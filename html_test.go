@@ -0,0 +1,72 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestSelectHTML(t *testing.T) {
+	doc := `<ul><li>1</li><li class="a">2</li></ul>`
+	got, err := SelectHTML("li.a", strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("SelectHTML() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{`<li class="a">2</li>`}, renderNodes(t, got)); diff != "" {
+		t.Errorf("SelectHTML() returned diff (-want, +got): %s", diff)
+	}
+
+	got, err = SelectHTMLString("li", doc)
+	if err != nil {
+		t.Fatalf("SelectHTMLString() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{`<li>1</li>`, `<li class="a">2</li>`}, renderNodes(t, got)); diff != "" {
+		t.Errorf("SelectHTMLString() returned diff (-want, +got): %s", diff)
+	}
+
+	if _, err := SelectHTMLString("li[", doc); err == nil {
+		t.Errorf("SelectHTMLString() with invalid selector didn't return an error")
+	}
+}
+
+func TestSelectorSelectHTML(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<ul><li>1</li><li class="a">2 <b>&amp; 3</b></li><li></li></ul>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	sel := MustParse("li")
+
+	got := sel.SelectHTML(root)
+	want := []string{`<li>1</li>`, `<li class="a">2 <b>&amp; 3</b></li>`, `<li></li>`}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("SelectHTML() returned diff (-want, +got): %s", diff)
+	}
+
+	got = sel.SelectInnerHTML(root)
+	want = []string{`1`, `2 <b>&amp; 3</b>`, ``}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("SelectInnerHTML() returned diff (-want, +got): %s", diff)
+	}
+
+	if got := MustParse("p").SelectHTML(root); len(got) != 0 {
+		t.Errorf("SelectHTML() with no matches returned %q", got)
+	}
+}
+
+func TestRenderInvalidTree(t *testing.T) {
+	br := &html.Node{Type: html.ElementNode, Data: "br", DataAtom: atom.Br}
+	br.AppendChild(&html.Node{Type: html.TextNode, Data: "x"})
+	p := &html.Node{Type: html.ElementNode, Data: "p", DataAtom: atom.P}
+	p.AppendChild(&html.Node{Type: html.TextNode, Data: "a"})
+	p.AppendChild(br)
+
+	if got, want := InnerHTML(p), "a<br"; !strings.HasPrefix(got, want) {
+		t.Errorf("InnerHTML() = %q, want prefix %q", got, want)
+	}
+	if got, want := OuterHTML(p), "<p>a<br"; !strings.HasPrefix(got, want) {
+		t.Errorf("OuterHTML() = %q, want prefix %q", got, want)
+	}
+}
@@ -0,0 +1,235 @@
+package css
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// inferAttrs are attributes commonly used to identify elements, in the order
+// they're preferred by Infer.
+var inferAttrs = []string{"data-testid", "data-test", "data-qa", "name", "role", "type", "aria-label"}
+
+// Infer generates a selector that matches every element in targets, similar
+// to the "copy selector" feature of browser developer tools.
+//
+// If negatives is empty, the selector doesn't match any other element in the
+// document. Otherwise, the selector doesn't match any of the negative
+// examples, but may match other elements.
+//
+// Infer prefers selectors that are likely to survive changes to the
+// document: ids, test attributes, and classes, followed by tag names. Ids and
+// classes containing runs of digits are assumed to be generated, and aren't
+// used. If needed, ancestors are added using child combinators, falling back
+// to :nth-child() when no other component distinguishes an element.
+func Infer(targets, negatives []*html.Node) (*Selector, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("css: no target elements provided")
+	}
+	for _, n := range targets {
+		if n == nil {
+			return nil, fmt.Errorf("css: target is nil")
+		}
+		if n.Type != html.ElementNode {
+			return nil, fmt.Errorf("css: target is not an element")
+		}
+	}
+	// Sort a copy, leaving the caller's slice unmodified.
+	targets = SortNodes(append([]*html.Node(nil), targets...))
+	root := treeRoot(targets[0])
+	for _, n := range targets[1:] {
+		if treeRoot(n) != root {
+			return nil, fmt.Errorf("css: targets are not part of the same tree")
+		}
+	}
+
+	isTarget := map[*html.Node]bool{}
+	for _, n := range targets {
+		isTarget[n] = true
+	}
+	isNegative := map[*html.Node]bool{}
+	for _, n := range negatives {
+		if isTarget[n] {
+			return nil, fmt.Errorf("css: element is both a target and a negative example")
+		}
+		isNegative[n] = true
+	}
+
+	// evaluate reports the number of unwanted elements matched by s, and if
+	// s matches every target.
+	evaluate := func(s *Selector) (extra int, ok bool) {
+		found := 0
+		for _, n := range s.Select(root) {
+			switch {
+			case isTarget[n]:
+				found++
+			case len(negatives) == 0 || isNegative[n]:
+				extra++
+			}
+		}
+		return extra, found == len(targets)
+	}
+
+	// Work upwards from the targets, adding the compound selector that best
+	// narrows the matches at each level.
+	level := targets
+	suffix := ""
+	for {
+		var best *Selector
+		bestExtra := -1
+		for _, c := range inferCandidates(level) {
+			s, err := Parse(c + suffix)
+			if err != nil {
+				continue
+			}
+			extra, ok := evaluate(s)
+			if !ok {
+				continue
+			}
+			if extra == 0 {
+				return s, nil
+			}
+			if bestExtra < 0 || extra < bestExtra {
+				best, bestExtra = s, extra
+			}
+		}
+		if best == nil {
+			break
+		}
+		suffix = " > " + best.String()
+
+		parents := make([]*html.Node, len(level))
+		for i, n := range level {
+			if n.Parent == nil || n.Parent.Type != html.ElementNode {
+				return nil, fmt.Errorf("css: no selector distinguishes the targets")
+			}
+			parents[i] = n.Parent
+		}
+		level = parents
+	}
+	return nil, fmt.Errorf("css: no selector distinguishes the targets")
+}
+
+// inferCandidates returns compound selectors shared by every node, from most
+// to least preferred.
+func inferCandidates(nodes []*html.Node) []string {
+	var tag strings.Builder
+	name := nodes[0].Data
+	for _, n := range nodes[1:] {
+		if n.Data != name {
+			name = ""
+			break
+		}
+	}
+	if name != "" {
+		writeIdent(&tag, name)
+	}
+
+	var cands []string
+	if len(nodes) == 1 {
+		if id, ok := attr(nodes[0], "id"); ok && inferStable(id) {
+			var b strings.Builder
+			b.WriteString("#")
+			writeIdent(&b, id)
+			cands = append(cands, b.String())
+		}
+	}
+
+	for _, key := range inferAttrs {
+		val, shared := attr(nodes[0], key)
+		for _, n := range nodes[1:] {
+			if v, ok := attr(n, key); !ok || v != val {
+				shared = false
+				break
+			}
+		}
+		if !shared {
+			continue
+		}
+		b := strings.Builder{}
+		b.WriteString(tag.String())
+		b.WriteString("[" + key + "=")
+		writeString(&b, val)
+		b.WriteString("]")
+		cands = append(cands, b.String())
+	}
+
+	classes := inferClasses(nodes)
+	for _, c := range classes {
+		b := strings.Builder{}
+		b.WriteString(tag.String())
+		b.WriteString(".")
+		writeIdent(&b, c)
+		cands = append(cands, b.String())
+	}
+	if len(classes) > 1 {
+		b := strings.Builder{}
+		b.WriteString(tag.String())
+		for _, c := range classes {
+			b.WriteString(".")
+			writeIdent(&b, c)
+		}
+		cands = append(cands, b.String())
+	}
+
+	if tag.Len() > 0 {
+		cands = append(cands, tag.String())
+	}
+
+	s := &state{}
+	index := s.computeSiblingIndex(nodes[0]).child
+	for _, n := range nodes[1:] {
+		if s.computeSiblingIndex(n).child != index {
+			index = 0
+			break
+		}
+	}
+	if index > 0 {
+		cands = append(cands, fmt.Sprintf("%s:nth-child(%d)", tag.String(), index))
+	}
+	return cands
+}
+
+// inferClasses returns the stable classes shared by every node.
+func inferClasses(nodes []*html.Node) []string {
+	var classes []string
+	for i, n := range nodes {
+		val, _ := attr(n, "class")
+		fields := strings.Fields(val)
+		if i == 0 {
+			for _, c := range fields {
+				if inferStable(c) && !containsAll(classes, []string{c}) {
+					classes = append(classes, c)
+				}
+			}
+			continue
+		}
+		var common []string
+		for _, c := range classes {
+			if containsAll(fields, []string{c}) {
+				common = append(common, c)
+			}
+		}
+		classes = common
+	}
+	return classes
+}
+
+// inferStable reports if an id or class looks like it was written by hand,
+// rather than generated. Values containing three or more consecutive digits
+// are assumed to be generated.
+func inferStable(s string) bool {
+	digits := 0
+	for _, r := range s {
+		if isDigit(r) {
+			digits++
+			if digits >= 3 {
+				return false
+			}
+		} else {
+			digits = 0
+		}
+	}
+	return s != ""
+}
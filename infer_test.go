@@ -0,0 +1,74 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestInfer(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<div id="main">
+	<ul class="nav">
+		<li class="item active"><a href="/">Home</a></li>
+		<li class="item"><a href="/about">About</a></li>
+		<li class="item"><a id="x12345" href="/contact">Contact</a></li>
+	</ul>
+	<form><input name="q"><button type="submit">Go</button></form>
+	<ul><li>1</li><li>2</li></ul>
+</div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	find := func(sel string) []*html.Node {
+		return MustParse(sel).Select(root)
+	}
+
+	tests := []struct {
+		targets   []*html.Node
+		negatives []*html.Node
+		want      string
+	}{
+		{find("#main"), nil, "#main"},
+		{find("li.active"), nil, "li.active"},
+		{find("li.item"), nil, "li.item"},
+		{find("input"), nil, `input[name="q"]`},
+		{find("button"), nil, `button[type="submit"]`},
+		// Generated ids aren't used.
+		{find("#x12345"), nil, "li:nth-child(3) > a"},
+		{find("ul:nth-of-type(2) > li:first-child"), nil, "ul:nth-child(3) > li:nth-child(1)"},
+		// Negative examples allow matching other elements.
+		{find(".nav a[href='/']"), find("ul:nth-of-type(2) li"), "a"},
+	}
+	for _, test := range tests {
+		sel, err := Infer(test.targets, test.negatives)
+		if err != nil {
+			t.Errorf("Infer(%s) failed: %v", test.want, err)
+			continue
+		}
+		if got := sel.String(); got != test.want {
+			t.Errorf("Infer() = %q, want %q", got, test.want)
+		}
+	}
+
+	if _, err := Infer(nil, nil); err == nil {
+		t.Errorf("Infer() with no targets didn't return an error")
+	}
+	lis := find("ul:nth-of-type(2) li")
+	if _, err := Infer(lis[:1], lis[:1]); err == nil {
+		t.Errorf("Infer() with target as a negative example didn't return an error")
+	}
+	if _, err := Infer([]*html.Node{lis[0], nil}, nil); err == nil {
+		t.Errorf("Infer() with a nil target didn't return an error")
+	}
+
+	// The caller's targets aren't reordered.
+	targets := []*html.Node{lis[1], lis[0]}
+	if _, err := Infer(targets, nil); err != nil {
+		t.Fatalf("Infer() failed: %v", err)
+	}
+	if targets[0] != lis[1] || targets[1] != lis[0] {
+		t.Errorf("Infer() modified the order of its targets")
+	}
+}
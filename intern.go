@@ -0,0 +1,46 @@
+package css
+
+import "sync"
+
+// Interner deduplicates the identifiers and values held by parsed selectors,
+// such as tag names, class names and attribute names, so selectors that
+// repeat a name share its storage. Parsing allocates a new string for each
+// name, so programs that hold thousands of selectors, such as the rules of
+// a stylesheet, can share an Interner between Parse calls with WithInterner
+// to store each name once.
+//
+// Names within the arguments of pseudo-classes, such as :is(), are parsed
+// when the selector is compiled, and aren't interned.
+//
+// The zero value is an empty Interner ready for use. An Interner is safe for
+// concurrent use, and holds every string it has seen for as long as it's
+// reachable.
+type Interner struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+// Len returns the number of distinct strings held by the Interner.
+func (in *Interner) Len() int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return len(in.m)
+}
+
+// intern returns a string equal to s, reusing a previously interned string if
+// possible. A nil Interner returns s.
+func (in *Interner) intern(s string) string {
+	if in == nil || s == "" {
+		return s
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if v, ok := in.m[s]; ok {
+		return v
+	}
+	if in.m == nil {
+		in.m = map[string]string{}
+	}
+	in.m[s] = s
+	return s
+}
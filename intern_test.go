@@ -0,0 +1,134 @@
+package css
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestParseMany(t *testing.T) {
+	in := []string{"li.item", "a[href", "ul > li.item:first-child", "", "p"}
+	sels, errs := ParseMany(in)
+	if len(sels) != len(in) || len(errs) != len(in) {
+		t.Fatalf("ParseMany() returned %d selectors and %d errors, want %d", len(sels), len(errs), len(in))
+	}
+	for i, s := range in {
+		_, want := Parse(s)
+		if (errs[i] == nil) != (want == nil) {
+			t.Errorf("ParseMany()[%d] (%q) returned error %v, Parse() returned %v", i, s, errs[i], want)
+			continue
+		}
+		if want != nil {
+			if errs[i].Error() != want.Error() {
+				t.Errorf("ParseMany()[%d] (%q) returned error %q, Parse() returned %q", i, s, errs[i], want)
+			}
+			if sels[i] != nil {
+				t.Errorf("ParseMany()[%d] (%q) returned a selector and an error", i, s)
+			}
+			continue
+		}
+		if got := sels[i].String(); got != MustParse(s).String() {
+			t.Errorf("ParseMany()[%d] = %q, want %q", i, got, s)
+		}
+	}
+
+	root, err := html.Parse(strings.NewReader(`<ul><li class="item">1</li><li class="item">2</li></ul>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	got := renderNodes(t, sels[2].Select(root))
+	if diff := cmp.Diff([]string{`<li class="item">1</li>`}, got); diff != "" {
+		t.Errorf("Select() returned diff (-want, +got): %s", diff)
+	}
+}
+
+func TestParseManyInterning(t *testing.T) {
+	sels, errs := ParseMany([]string{"div.item", "span.item > div"}, WithStrict())
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("ParseMany() failed: %v", err)
+		}
+	}
+	same := func(a, b string) bool {
+		return a == b && unsafe.StringData(a) == unsafe.StringData(b)
+	}
+	a, b := sels[0].list[0], sels[1].list[0]
+	if !same(a.sel.subClasses[0].classSelector, b.sel.subClasses[0].classSelector) {
+		t.Errorf("class names parsed by ParseMany() don't share storage")
+	}
+	if !same(a.sel.typeSelector.value, b.next.sel.typeSelector.value) {
+		t.Errorf("tag names parsed by ParseMany() don't share storage")
+	}
+}
+
+func TestInterner(t *testing.T) {
+	var nilInterner *Interner
+	if got := nilInterner.intern("a"); got != "a" {
+		t.Errorf("nil Interner returned %q, want %q", got, "a")
+	}
+
+	in := &Interner{}
+	x := in.intern(strings.Repeat("x", 3))
+	y := in.intern(strings.Repeat("x", 3))
+	if unsafe.StringData(x) != unsafe.StringData(y) {
+		t.Errorf("intern() returned different strings for equal values")
+	}
+	if got := in.Len(); got != 1 {
+		t.Errorf("Len() = %d, want 1", got)
+	}
+}
+
+func TestWithInterner(t *testing.T) {
+	in := &Interner{}
+	a, err := Parse("div.item[data-id]", WithInterner(in))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	b, err := Parse("span.item", WithInterner(in))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	x, y := a.list[0].sel.subClasses[0].classSelector, b.list[0].sel.subClasses[0].classSelector
+	if unsafe.StringData(x) != unsafe.StringData(y) {
+		t.Errorf("class names parsed with a shared Interner don't share storage")
+	}
+	// div, item, data-id and span.
+	if got := in.Len(); got != 4 {
+		t.Errorf("Len() = %d, want 4", got)
+	}
+
+	sels, errs := ParseMany([]string{"p.item"}, WithInterner(in))
+	if errs[0] != nil {
+		t.Fatalf("ParseMany() failed: %v", errs[0])
+	}
+	z := sels[0].list[0].sel.subClasses[0].classSelector
+	if unsafe.StringData(x) != unsafe.StringData(z) {
+		t.Errorf("ParseMany() didn't use the provided Interner")
+	}
+}
+
+func TestInternerConcurrent(t *testing.T) {
+	in := &Interner{}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := Parse("ul > li.item:nth-child(2n)", WithInterner(in)); err != nil {
+					t.Errorf("Parse() failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// ul, li, item and nth-child(.
+	if got := in.Len(); got != 4 {
+		t.Errorf("Len() = %d, want 4", got)
+	}
+}
@@ -0,0 +1,26 @@
+// Package nodetext extracts the text of HTML nodes for the commands and test
+// helpers that report it.
+package nodetext
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Text returns the text content of n, with leading and trailing whitespace
+// removed and other runs of whitespace collapsed to a single space.
+func Text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
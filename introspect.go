@@ -0,0 +1,96 @@
+package css
+
+import (
+	"sort"
+	"strings"
+)
+
+// IDs returns the IDs referenced by the selector, such as "foo" for "#foo",
+// sorted and without duplicates.
+func (s *Selector) IDs() []string {
+	return s.collect(func(c *compoundSelector, add func(string)) {
+		for _, sc := range c.subClasses {
+			if sc.idSelector != "" {
+				add(sc.idSelector)
+			}
+		}
+	})
+}
+
+// Classes returns the class names referenced by the selector, such as "foo"
+// for ".foo", sorted and without duplicates.
+func (s *Selector) Classes() []string {
+	return s.collect(func(c *compoundSelector, add func(string)) {
+		for _, sc := range c.subClasses {
+			if sc.classSelector != "" {
+				add(sc.classSelector)
+			}
+		}
+	})
+}
+
+// TagNames returns the element names referenced by type selectors, sorted and
+// without duplicates. The universal selector "*" isn't included.
+func (s *Selector) TagNames() []string {
+	return s.collect(func(c *compoundSelector, add func(string)) {
+		if t := c.typeSelector; t != nil && t.value != "*" {
+			add(t.value)
+		}
+	})
+}
+
+// AttributeNames returns the attribute names referenced by attribute
+// selectors, sorted and without duplicates.
+func (s *Selector) AttributeNames() []string {
+	return s.collect(func(c *compoundSelector, add func(string)) {
+		for _, sc := range c.subClasses {
+			if a := sc.attributeSelector; a != nil {
+				add(a.wqName.value)
+			}
+		}
+	})
+}
+
+// PseudoClasses returns the names of pseudo-classes used by the selector, such
+// as "first-child" or "nth-child", sorted and without duplicates.
+func (s *Selector) PseudoClasses() []string {
+	name := func(p *pseudoClassSelector) string {
+		if p.function != "" {
+			return strings.TrimSuffix(p.function, "(")
+		}
+		return p.ident
+	}
+	return s.collect(func(c *compoundSelector, add func(string)) {
+		for _, sc := range c.subClasses {
+			if p := sc.pseudoClassSelector; p != nil {
+				add(name(p))
+			}
+		}
+		for _, ps := range c.pseudoSelectors {
+			for i := range ps.classes {
+				add(name(&ps.classes[i]))
+			}
+		}
+	})
+}
+
+// collect calls fn for every compound selector in the list, returning the
+// sorted, unique set of values it adds. Selectors nested in the arguments of
+// functional pseudo-classes aren't visited.
+func (s *Selector) collect(fn func(c *compoundSelector, add func(string))) []string {
+	seen := map[string]bool{}
+	vals := []string{}
+	add := func(v string) {
+		if !seen[v] {
+			seen[v] = true
+			vals = append(vals, v)
+		}
+	}
+	for i := range s.list {
+		for curr := &s.list[i]; curr != nil; curr = curr.next {
+			fn(&curr.sel, add)
+		}
+	}
+	sort.Strings(vals)
+	return vals
+}
@@ -0,0 +1,32 @@
+package css
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIntrospection(t *testing.T) {
+	s := MustParse(`div#main > a.link.ext[href^="https"]:first-child, #main .link ~ *:nth-child(2n), p[title]`)
+
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"IDs", s.IDs(), []string{"main"}},
+		{"Classes", s.Classes(), []string{"ext", "link"}},
+		{"TagNames", s.TagNames(), []string{"a", "div", "p"}},
+		{"AttributeNames", s.AttributeNames(), []string{"href", "title"}},
+		{"PseudoClasses", s.PseudoClasses(), []string{"first-child", "nth-child"}},
+	}
+	for _, test := range tests {
+		if diff := cmp.Diff(test.want, test.got); diff != "" {
+			t.Errorf("%s() returned diff (-want, +got): %s", test.name, diff)
+		}
+	}
+
+	if got := MustParse("*").TagNames(); len(got) != 0 {
+		t.Errorf("TagNames() for universal selector returned %q, want none", got)
+	}
+}
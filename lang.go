@@ -0,0 +1,116 @@
+package css
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:lang
+// https://www.w3.org/TR/selectors-4/#the-lang-pseudo
+func (c *compiler) lang(s *pseudoClassSelector) func(n *html.Node) bool {
+	ranges, err := langRanges(s.args)
+	if err != nil {
+		pos, msg := argError(err, s.pos)
+		c.errorf(pos, "failed to parse :lang() argument: %s", msg)
+		return nil
+	}
+	return func(n *html.Node) bool {
+		tag, ok := elementLang(n)
+		if !ok || tag == "" {
+			return false
+		}
+		for _, r := range ranges {
+			if langMatches(r, tag) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// langRanges parses the argument of :lang(), a comma-separated list of
+// language ranges given as identifiers or strings, such as "en, 'de-*-CH'".
+func langRanges(args []token) ([]string, error) {
+	p := newParserFromTokens(args)
+	var ranges []string
+	for {
+		p.skipWhitespace()
+		t, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if t.typ != tokenIdent && t.typ != tokenString {
+			return nil, p.errorf(t, "expected language range, got %s", t)
+		}
+		if t.s == "" {
+			return nil, p.errorf(t, "empty language range")
+		}
+		ranges = append(ranges, t.s)
+
+		p.skipWhitespace()
+		t, err = p.next()
+		if err != nil {
+			return nil, err
+		}
+		switch t.typ {
+		case tokenEOF:
+			return ranges, nil
+		case tokenComma:
+		default:
+			return nil, p.errorf(t, "expected ',' or ')', got %s", t)
+		}
+	}
+}
+
+// elementLang returns the language of n, as declared by the lang attribute of
+// n or its nearest ancestor that has one. The lang attribute in the XML
+// namespace, set by xml:lang on SVG and MathML elements, takes precedence.
+// An empty value means the language is unknown.
+//
+// https://html.spec.whatwg.org/multipage/dom.html#the-lang-and-xml:lang-attributes
+func elementLang(n *html.Node) (string, bool) {
+	for e := n; e != nil && e.Type == html.ElementNode; e = e.Parent {
+		for _, a := range e.Attr {
+			if a.Namespace == "xml" && a.Key == "lang" {
+				return strings.TrimSpace(a.Val), true
+			}
+		}
+		if val, ok := attr(e, "lang"); ok {
+			return strings.TrimSpace(val), true
+		}
+	}
+	return "", false
+}
+
+// langMatches reports if the language tag matches the language range, using
+// extended filtering. Subtags are compared case-insensitively, a "*" subtag
+// matches any subtag, and a range matches tags with additional subtags, so
+// "en" matches "en-US" and "de-*-CH" matches "de-Latn-CH".
+//
+// https://www.rfc-editor.org/rfc/rfc4647#section-3.3.2
+func langMatches(r, tag string) bool {
+	ranges := strings.Split(r, "-")
+	subtags := strings.Split(tag, "-")
+	if ranges[0] != "*" && !strings.EqualFold(ranges[0], subtags[0]) {
+		return false
+	}
+	ranges, subtags = ranges[1:], subtags[1:]
+	for len(ranges) > 0 {
+		switch {
+		case ranges[0] == "*":
+			ranges = ranges[1:]
+		case len(subtags) == 0:
+			return false
+		case strings.EqualFold(ranges[0], subtags[0]):
+			ranges, subtags = ranges[1:], subtags[1:]
+		case len(subtags[0]) == 1:
+			// Singletons, such as the "x" of private use subtags, can't be
+			// skipped over.
+			return false
+		default:
+			subtags = subtags[1:]
+		}
+	}
+	return true
+}
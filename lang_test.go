@@ -0,0 +1,87 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestLang(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<html lang="en-US">
+<body>
+<p id="us"></p>
+<div lang="de-Latn-CH"><p id="swiss"></p><p id="unknown" lang=""></p></div>
+<p id="french" lang="FR"></p>
+<p id="private" lang="en-x-US"></p>
+<svg lang="en" xml:lang="zh-Hant"><g id="svg"></g></svg>
+</body>
+</html>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{"p:lang(en)", []string{"us", "private"}},
+		{"p:lang(EN-us)", []string{"us"}},
+		{"p:lang(fr)", []string{"french"}},
+		{"p:lang(de-CH)", []string{"swiss"}},
+		{`p:lang("de-*-CH")`, []string{"swiss"}},
+		{`p:lang("*-CH")`, []string{"swiss"}},
+		{"p:lang(en-US)", []string{"us"}},
+		{"p:lang(en-x)", []string{"private"}},
+		{"p:lang(fr, de)", []string{"swiss", "french"}},
+		{"p:lang(de-Latn-CH-1901)", nil},
+		{"p:lang(e)", nil},
+		{"g:lang(zh)", []string{"svg"}},
+		{"g:lang(en)", nil},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		var got []string
+		for _, n := range s.Select(root) {
+			id, _ := attr(n, "id")
+			got = append(got, id)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+
+	for _, sel := range []string{":lang()", ":lang(1)", ":lang(en fr)", ":lang(en,)", `:lang("")`} {
+		if _, err := Parse(sel); err == nil {
+			t.Errorf("Parse(%q) didn't return an error", sel)
+		}
+	}
+}
+
+func TestLangMatches(t *testing.T) {
+	tests := []struct {
+		r, tag string
+		want   bool
+	}{
+		{"en", "en", true},
+		{"en", "en-US", true},
+		{"en", "eng", false},
+		{"en-US", "en", false},
+		{"*", "fr", true},
+		{"de-CH", "de-Latn-CH", true},
+		{"de-*-CH", "de-CH", true},
+		{"de-CH", "de-x-CH", false},
+		{"zh-Hant", "ZH-hant-TW", true},
+	}
+	for _, test := range tests {
+		if got := langMatches(test.r, test.tag); got != test.want {
+			t.Errorf("langMatches(%q, %q) = %v, want %v", test.r, test.tag, got, test.want)
+		}
+	}
+}
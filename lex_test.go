@@ -1,7 +1,6 @@
 package css
 
 import (
-	"reflect"
 	"testing"
 )
 
@@ -60,10 +59,30 @@ func TestLexer(t *testing.T) {
 			` "\0a f" `,
 			[]token{
 				tok(tokenWhitespace, " "),
-				tok(tokenString, `"\0a f"`, "¯"),
+				tok(tokenString, `"\0a f"`, "\nf"),
 				tok(tokenWhitespace, " "),
 			},
 		},
+		{
+			`\31 23`,
+			[]token{
+				tok(tokenIdent, `\31 23`, "123"),
+			},
+		},
+		{
+			`\0`,
+			[]token{
+				tok(tokenIdent, `\0`, "\uFFFD"),
+			},
+		},
+		{
+			"--foo \uFFFD",
+			[]token{
+				tok(tokenIdent, "--foo"),
+				tok(tokenWhitespace, " "),
+				tok(tokenIdent, "\uFFFD"),
+			},
+		},
 		{
 			`# "foo"`,
 			[]token{
@@ -333,30 +352,3 @@ func TestLexerErr(t *testing.T) {
 		}
 	}
 }
-
-func TestLexerPop(t *testing.T) {
-	tests := []struct {
-		s    string
-		want []rune
-	}{
-		{
-			"hello, world!",
-			[]rune{'h', 'e', 'l', 'l', 'o', ',', ' ', 'w', 'o', 'r', 'l', 'd', '!'},
-		},
-		{
-			"hello, 世界!",
-			[]rune{'h', 'e', 'l', 'l', 'o', ',', ' ', '世', '界', '!'},
-		},
-	}
-
-	for _, test := range tests {
-		var got []rune
-		l := newLexer(test.s)
-		for l.peek() != eof {
-			got = append(got, l.pop())
-		}
-		if !reflect.DeepEqual(got, test.want) {
-			t.Errorf("lexer parsing code points for %q: got=%v, want=%v", test.s, got, test.want)
-		}
-	}
-}
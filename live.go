@@ -0,0 +1,210 @@
+package css
+
+import (
+	"golang.org/x/net/html"
+)
+
+// MutationType identifies the kind of change described by a Mutation.
+type MutationType int
+
+const (
+	// MutationChildList indicates children were added to or removed from
+	// the target.
+	MutationChildList MutationType = iota + 1
+	// MutationAttributes indicates an attribute of the target was set or
+	// removed.
+	MutationAttributes
+)
+
+// Mutation describes a single change made to a document, modeled after the
+// DOM's MutationRecord.
+//
+// https://dom.spec.whatwg.org/#interface-mutationrecord
+type Mutation struct {
+	Type MutationType
+	// Target is the parent of the added or removed nodes for child list
+	// mutations, and the element whose attribute changed for attribute
+	// mutations.
+	Target *html.Node
+	// Added and Removed hold the nodes added to or removed from Target.
+	Added   []*html.Node
+	Removed []*html.Node
+	// PrevSibling and NextSibling are the siblings of the added or removed
+	// nodes.
+	PrevSibling *html.Node
+	NextSibling *html.Node
+	// Attr is the key of the changed attribute.
+	Attr string
+}
+
+// Document wraps a parsed HTML document, routing mutations through methods
+// so that live queries against the document stay up to date.
+//
+// Document isn't safe for concurrent use, and live queries won't observe
+// changes made to the underlying nodes directly.
+type Document struct {
+	root    *html.Node
+	queries []*LiveQuery
+}
+
+// NewDocument wraps the tree rooted at n.
+func NewDocument(n *html.Node) *Document {
+	return &Document{root: n}
+}
+
+// Root returns the root of the document.
+func (d *Document) Root() *html.Node {
+	return d.root
+}
+
+// AppendChild adds child as the last child of parent.
+func (d *Document) AppendChild(parent, child *html.Node) {
+	d.InsertBefore(parent, child, nil)
+}
+
+// InsertBefore inserts child as a child of parent, immediately before the
+// existing child oldChild. If oldChild is nil, child is appended.
+func (d *Document) InsertBefore(parent, child, oldChild *html.Node) {
+	parent.InsertBefore(child, oldChild)
+	d.notify(&Mutation{
+		Type:        MutationChildList,
+		Target:      parent,
+		Added:       []*html.Node{child},
+		PrevSibling: child.PrevSibling,
+		NextSibling: child.NextSibling,
+	})
+}
+
+// RemoveChild removes child from parent.
+func (d *Document) RemoveChild(parent, child *html.Node) {
+	prev, next := child.PrevSibling, child.NextSibling
+	parent.RemoveChild(child)
+	d.notify(&Mutation{
+		Type:        MutationChildList,
+		Target:      parent,
+		Removed:     []*html.Node{child},
+		PrevSibling: prev,
+		NextSibling: next,
+	})
+}
+
+// SetAttr sets the value of an element's attribute, adding the attribute if
+// it isn't present.
+func (d *Document) SetAttr(n *html.Node, key, val string) {
+	found := false
+	for i, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			n.Attr[i].Val = val
+			found = true
+			break
+		}
+	}
+	if !found {
+		n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+	}
+	d.notify(&Mutation{Type: MutationAttributes, Target: n, Attr: key})
+}
+
+// RemoveAttr removes an attribute from an element.
+func (d *Document) RemoveAttr(n *html.Node, key string) {
+	for i, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			n.Attr = append(n.Attr[:i:i], n.Attr[i+1:]...)
+			break
+		}
+	}
+	d.notify(&Mutation{Type: MutationAttributes, Target: n, Attr: key})
+}
+
+func (d *Document) notify(m *Mutation) {
+	for _, q := range d.queries {
+		q.invalidate(m)
+	}
+}
+
+// LiveQuery returns a query whose results track mutations made through the
+// Document.
+func (d *Document) LiveQuery(sel *Selector) *LiveQuery {
+	q := &LiveQuery{d: d, sel: sel}
+	q.nodes = sel.Select(d.root)
+	d.queries = append(d.queries, q)
+	return q
+}
+
+// LiveQuery holds the results of a selector evaluated against a Document,
+// updated as the document changes.
+type LiveQuery struct {
+	d     *Document
+	sel   *Selector
+	nodes []*html.Node
+	// stale is set when the document has changed since nodes were computed.
+	stale    bool
+	onChange []func(added, removed []*html.Node)
+}
+
+// Nodes returns the current results of the query in document order.
+func (q *LiveQuery) Nodes() []*html.Node {
+	if q.stale {
+		q.nodes = q.sel.Select(q.d.root)
+		q.stale = false
+	}
+	return q.nodes
+}
+
+// OnChange registers a function to be called whenever a mutation changes the
+// results of the query. The function is passed the elements that started and
+// stopped matching, and is called synchronously by the mutating method.
+func (q *LiveQuery) OnChange(fn func(added, removed []*html.Node)) {
+	// Ensure results are current so later changes are diffed correctly.
+	q.Nodes()
+	q.onChange = append(q.onChange, fn)
+}
+
+// Close stops the query from tracking changes to the document.
+func (q *LiveQuery) Close() {
+	for i, other := range q.d.queries {
+		if other == q {
+			q.d.queries = append(q.d.queries[:i:i], q.d.queries[i+1:]...)
+			return
+		}
+	}
+}
+
+func (q *LiveQuery) invalidate(m *Mutation) {
+	if len(q.onChange) == 0 {
+		// Nobody is listening, defer evaluation until Nodes() is called.
+		q.stale = true
+		return
+	}
+	prev := q.Nodes()
+	q.nodes = q.sel.Update(q.d.root, prev, m)
+	added, removed := diffNodes(prev, q.nodes)
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	for _, fn := range q.onChange {
+		fn(added, removed)
+	}
+}
+
+// diffNodes returns the nodes in next that aren't in prev, and the nodes in
+// prev that aren't in next.
+func diffNodes(prev, next []*html.Node) (added, removed []*html.Node) {
+	inPrev := make(map[*html.Node]bool, len(prev))
+	for _, n := range prev {
+		inPrev[n] = true
+	}
+	inNext := make(map[*html.Node]bool, len(next))
+	for _, n := range next {
+		inNext[n] = true
+		if !inPrev[n] {
+			added = append(added, n)
+		}
+	}
+	for _, n := range prev {
+		if !inNext[n] {
+			removed = append(removed, n)
+		}
+	}
+	return added, removed
+}
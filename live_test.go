@@ -0,0 +1,138 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestLiveQuery(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<ul><li class="a">1</li><li>2</li></ul>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	d := NewDocument(root)
+	ul := MustParse("ul").Select(root)[0]
+	li := MustParse("li").Select(root)
+
+	active := d.LiveQuery(MustParse("li.a"))
+	last := d.LiveQuery(MustParse("li:last-child"))
+
+	var added, removed []string
+	active.OnChange(func(a, r []*html.Node) {
+		added = append(added, renderNodes(t, a)...)
+		removed = append(removed, renderNodes(t, r)...)
+	})
+
+	check := func(q *LiveQuery, want ...string) {
+		t.Helper()
+		if diff := cmp.Diff(want, renderNodes(t, q.Nodes())); diff != "" {
+			t.Errorf("Live query returned diff (-want, +got): %s", diff)
+		}
+	}
+	check(active, `<li class="a">1</li>`)
+	check(last, `<li>2</li>`)
+
+	d.SetAttr(li[1], "class", "a")
+	check(active, `<li class="a">1</li>`, `<li class="a">2</li>`)
+	if diff := cmp.Diff([]string{`<li class="a">2</li>`}, added); diff != "" {
+		t.Errorf("OnChange reported unexpected added elements (-want, +got): %s", diff)
+	}
+
+	d.RemoveAttr(li[0], "class")
+	check(active, `<li class="a">2</li>`)
+	if diff := cmp.Diff([]string{`<li>1</li>`}, removed); diff != "" {
+		t.Errorf("OnChange reported unexpected removed elements (-want, +got): %s", diff)
+	}
+
+	n := &html.Node{Type: html.ElementNode, Data: "li", DataAtom: atom.Li}
+	d.AppendChild(ul, n)
+	check(last, `<li></li>`)
+
+	d.RemoveChild(ul, n)
+	check(last, `<li class="a">2</li>`)
+
+	added, removed = nil, nil
+	active.Close()
+	d.SetAttr(li[0], "class", "a")
+	if len(added) != 0 || len(removed) != 0 {
+		t.Errorf("Closed query reported changes, added=%v, removed=%v", added, removed)
+	}
+}
+
+func TestLiveQueryContains(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<div><p><span></span></p></div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	d := NewDocument(root)
+	span := MustParse("span").Select(root)[0]
+
+	q := d.LiveQuery(MustParse("div:contains(foo)", WithExtensions()))
+	var added []string
+	// Registering a listener causes mutations to be applied with Update.
+	q.OnChange(func(a, r []*html.Node) {
+		added = append(added, renderNodes(t, a)...)
+	})
+	if got := len(q.Nodes()); got != 0 {
+		t.Fatalf("Live query returned %d elements before mutation, want 0", got)
+	}
+
+	// The text is added below the div's child, outside the subtree of the
+	// mutation's target parent.
+	d.AppendChild(span, &html.Node{Type: html.TextNode, Data: "foo"})
+	want := []string{`<div><p><span>foo</span></p></div>`}
+	if diff := cmp.Diff(want, renderNodes(t, q.Nodes())); diff != "" {
+		t.Errorf("Live query returned diff after mutating text (-want, +got): %s", diff)
+	}
+	if diff := cmp.Diff(want, added); diff != "" {
+		t.Errorf("OnChange reported unexpected added elements (-want, +got): %s", diff)
+	}
+}
+
+func TestLiveQueryChecked(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+		<form>
+			<p><input type="radio" name="a" id="r1" checked></p>
+			<p><input type="radio" name="a" id="r2"></p>
+		</form>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	d := NewDocument(root)
+	r2 := MustParse("#r2").Select(root)[0]
+
+	q := d.LiveQuery(MustParse(":checked"))
+	var removed []string
+	// Registering a listener causes mutations to be applied with Update.
+	q.OnChange(func(a, r []*html.Node) {
+		for _, n := range r {
+			id, _ := attr(n, "id")
+			removed = append(removed, id)
+		}
+	})
+	ids := func() []string {
+		var got []string
+		for _, n := range q.Nodes() {
+			id, _ := attr(n, "id")
+			got = append(got, id)
+		}
+		return got
+	}
+	if diff := cmp.Diff([]string{"r1"}, ids()); diff != "" {
+		t.Fatalf("Live query returned diff before mutation (-want, +got): %s", diff)
+	}
+
+	// Checking r2 unchecks r1, which is outside the subtree of the
+	// mutation's target parent.
+	d.SetAttr(r2, "checked", "")
+	if diff := cmp.Diff([]string{"r2"}, ids()); diff != "" {
+		t.Errorf("Live query returned diff after checking radio (-want, +got): %s", diff)
+	}
+	if diff := cmp.Diff([]string{"r1"}, removed); diff != "" {
+		t.Errorf("OnChange reported unexpected removed elements (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,487 @@
+package css
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Logical pseudo-classes take selectors as arguments. Following Selectors
+// Level 4, :is() and :where() take a forgiving selector list: selectors that are invalid
+// or unsupported are dropped with a warning, rather than invalidating the
+// entire selector. :not() and :has() take a strict list, where any invalid
+// selector is an error. In :is() and :has(), selectors with pseudo-elements
+// are valid but never match, while :not() can't represent pseudo-elements,
+// and rejects them.
+//
+// https://www.w3.org/TR/selectors-4/#logical-combination
+
+// compileArg compiles a complex selector passed as the argument of a logical
+// pseudo-class, returning the first error hit without recording it.
+//
+// A pseudo-element in an argument isn't an error, but the selector never
+// matches, since pseudo-elements don't represent the elements being matched.
+func (c *compiler) compileArg(cs *complexSelector) (*selector, error) {
+	for curr := cs; curr != nil; curr = curr.next {
+		if len(curr.sel.pseudoSelectors) != 0 {
+			last := curr
+			for last.next != nil {
+				last = last.next
+			}
+			return &selector{m: neverMatches(&last.sel)}, nil
+		}
+	}
+	sub := compiler{maxErrs: 1, opts: c.opts, src: c.src}
+	m := sub.compile(cs)
+	c.gaps = append(c.gaps, sub.gaps...)
+	if err := sub.err(); err != nil {
+		return nil, err
+	}
+	c.warnings = append(c.warnings, sub.warnings...)
+	if sub.relational {
+		c.relational = true
+	}
+	return m, nil
+}
+
+// neverMatches returns a matcher for the compound selector s that never
+// matches any element.
+func neverMatches(s *compoundSelector) *compoundSelectorMatcher {
+	return &compoundSelectorMatcher{
+		scm: []subclassSelectorMatcher{{
+			pseudoSelector: func(st *state, n *html.Node) bool { return false },
+			src:            &subclassSelector{pos: s.pos},
+		}},
+		src: s,
+	}
+}
+
+// argError returns the position and message of an error hit while parsing or
+// compiling a selector argument. pos is used for errors without a position.
+func argError(err error, pos int) (int, string) {
+	var perr *ParseError
+	if errors.As(parseError(err), &perr) {
+		return perr.Pos, perr.Msg
+	}
+	return pos, err.Error()
+}
+
+// is compiles :is() and :where(), which only differ in specificity.
+//
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:is
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:where
+func (c *compiler) is(s *pseudoClassSelector) matchFunc {
+	list, dropped, err := parseSelectorListArg(s.args, true)
+	if err != nil {
+		pos, msg := argError(err, s.pos)
+		c.errorf(pos, "invalid selector in :%s): %s", s.function, msg)
+		return nil
+	}
+	for _, err := range dropped {
+		pos, msg := argError(err, s.pos)
+		c.gapf(pos, GapArgument, pseudoClassFeature(s), "invalid selector in :%s) ignored: %s", s.function, msg)
+	}
+	list, _ = c.flattenIs(list)
+	var sels []*selector
+	for i := range list {
+		m, err := c.compileArg(&list[i])
+		if err != nil {
+			pos, msg := argError(err, s.pos)
+			c.gapf(pos, GapArgument, pseudoClassFeature(s), "unsupported selector in :%s) ignored: %s", s.function, msg)
+			continue
+		}
+		sels = append(sels, m)
+	}
+	return func(st *state, n *html.Node) bool {
+		for _, sel := range sels {
+			if sel.match(st, n) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:not
+func (c *compiler) not(s *pseudoClassSelector) matchFunc {
+	list, _, err := parseSelectorListArg(s.args, false)
+	if err != nil {
+		pos, msg := argError(err, s.pos)
+		c.errorf(pos, "invalid selector in :not(): %s", msg)
+		return nil
+	}
+	for i := range list {
+		if pos, ok := pseudoElementPos(&list[i]); ok {
+			c.errorf(pos, "invalid selector in :not(): pseudo-elements not allowed")
+			return nil
+		}
+	}
+	// :not(a, :is(b, c)) matches elements that match none of a, b and c, so
+	// nested :is() arguments can be inlined like those of :is(). Inlined
+	// arguments keep the forgiving handling of the :is() they came from.
+	// Nested :not() arguments cancel out, and are matched directly.
+	list, from := c.flattenIs(list)
+	var (
+		sels []*selector
+		// groups holds the arguments of nested :not() pseudo-classes. Each
+		// nested :not() matches if any of its arguments do, since
+		// :not(:not(a, b)) is :is(a, b).
+		groups [][]*selector
+	)
+	for i := range list {
+		if args, argsFrom, ok := c.doubleNegation(&list[i]); ok {
+			var group []*selector
+			for j := range args {
+				if argsFrom[j] == nil {
+					argsFrom[j] = from[i]
+				}
+				m, ok := c.notArg(s, &args[j], argsFrom[j])
+				if !ok {
+					return nil
+				}
+				if m != nil {
+					group = append(group, m)
+				}
+			}
+			groups = append(groups, group)
+			continue
+		}
+		m, ok := c.notArg(s, &list[i], from[i])
+		if !ok {
+			return nil
+		}
+		if m != nil {
+			sels = append(sels, m)
+		}
+	}
+	return func(st *state, n *html.Node) bool {
+		for _, sel := range sels {
+			if sel.match(st, n) {
+				return false
+			}
+		}
+	groups:
+		for _, group := range groups {
+			for _, sel := range group {
+				if sel.match(st, n) {
+					continue groups
+				}
+			}
+			return false
+		}
+		return true
+	}
+}
+
+// notArg compiles an argument of :not(). Selectors inlined from a nested
+// :is() or :where() are dropped with a warning if they can't be compiled,
+// returning a nil selector. Other errors are recorded and ok is false.
+func (c *compiler) notArg(s *pseudoClassSelector, cs *complexSelector, from *pseudoClassSelector) (m *selector, ok bool) {
+	m, err := c.compileArg(cs)
+	if err == nil {
+		return m, true
+	}
+	pos, msg := argError(err, s.pos)
+	if from != nil {
+		c.gapf(pos, GapArgument, pseudoClassFeature(from), "unsupported selector in :%s) ignored: %s", from.function, msg)
+		return nil, true
+	}
+	c.errorf(pos, "invalid selector in :not(): %s", msg)
+	return nil, false
+}
+
+// doubleNegation returns the arguments of a selector that's only a :not()
+// pseudo-class, when it's an argument of :not(), flattened by flattenIs.
+// Arguments that fail to parse or hold pseudo-elements aren't inlined, and are
+// reported when the nested :not() is compiled.
+func (c *compiler) doubleNegation(cs *complexSelector) (list []complexSelector, from []*pseudoClassSelector, ok bool) {
+	ps := lonePseudoClass(cs, "not(")
+	if ps == nil {
+		return nil, nil, false
+	}
+	list, _, err := parseSelectorListArg(ps.args, false)
+	if err != nil {
+		return nil, nil, false
+	}
+	for i := range list {
+		if _, ok := pseudoElementPos(&list[i]); ok {
+			return nil, nil, false
+		}
+	}
+	list, from = c.flattenIs(list)
+	return list, from, true
+}
+
+// pseudoElementPos returns the position of the first pseudo-element in cs, if
+// it has one.
+func pseudoElementPos(cs *complexSelector) (int, bool) {
+	for curr := cs; curr != nil; curr = curr.next {
+		if ps := curr.sel.pseudoSelectors; len(ps) != 0 {
+			return ps[0].pos, true
+		}
+	}
+	return 0, false
+}
+
+// flattenIs returns the selectors of an :is() argument list, with nested
+// :is() and :where() arguments inlined and duplicate selectors removed. A
+// selector that's only an :is() pseudo-class matches the same elements as its
+// arguments, so ":is(a, :is(b, :where(a, c)))" is compiled as ":is(a, b, c)",
+// avoiding a matcher for each level of nesting.
+//
+// from holds, for each returned selector, the nested :is() or :where() it was
+// inlined from, or nil. Selectors from a nested list are forgiving: callers
+// should ignore them if they're unsupported rather than fail.
+func (c *compiler) flattenIs(list []complexSelector) (flat []complexSelector, from []*pseudoClassSelector) {
+	var (
+		seen = map[string]bool{}
+		add  func(list []complexSelector, parent *pseudoClassSelector)
+	)
+	add = func(list []complexSelector, parent *pseudoClassSelector) {
+		for i := range list {
+			if ps := lonePseudoClass(&list[i], "is(", "where("); ps != nil {
+				args, dropped, err := parseSelectorListArg(ps.args, true)
+				if err == nil {
+					for _, err := range dropped {
+						pos, msg := argError(err, ps.pos)
+						c.gapf(pos, GapArgument, pseudoClassFeature(ps), "invalid selector in :%s) ignored: %s", ps.function, msg)
+					}
+					add(args, ps)
+					continue
+				}
+				// Errors are reported when the nested :is() is compiled.
+			}
+			var b strings.Builder
+			writeComplexSelector(&b, &list[i])
+			if seen[b.String()] {
+				continue
+			}
+			seen[b.String()] = true
+			flat = append(flat, list[i])
+			from = append(from, parent)
+		}
+	}
+	add(list, nil)
+	return flat, from
+}
+
+// lonePseudoClass returns the functional pseudo-class of a complex selector
+// that consists of nothing else, optionally preceded by "*", if it's one of
+// the given functions. Otherwise it returns nil.
+func lonePseudoClass(cs *complexSelector, functions ...string) *pseudoClassSelector {
+	c := &cs.sel
+	if cs.next != nil || len(c.subClasses) != 1 || len(c.pseudoSelectors) != 0 {
+		return nil
+	}
+	if t := c.typeSelector; t != nil && (t.hasPrefix || t.value != "*") {
+		return nil
+	}
+	ps := c.subClasses[0].pseudoClassSelector
+	if ps == nil {
+		return nil
+	}
+	for _, fn := range functions {
+		if ps.function == fn {
+			return ps
+		}
+	}
+	return nil
+}
+
+// anchorCompound is the compound selector relative selectors are anchored to.
+// It's serialized as :scope, which relative selectors are defined in terms of.
+var anchorCompound = &compoundSelector{
+	subClasses: []subclassSelector{{pseudoClassSelector: &pseudoClassSelector{ident: "scope"}}},
+}
+
+// anchorMatcher matches the element a :has() argument is being evaluated
+// against.
+var anchorMatcher = &compoundSelectorMatcher{
+	scm: []subclassSelectorMatcher{{
+		pseudoSelector: func(s *state, n *html.Node) bool { return n == s.anchor },
+		src:            &anchorCompound.subClasses[0],
+	}},
+	src: anchorCompound,
+}
+
+// relativeMatcher is a compiled relative selector. The anchor is matched by
+// the selector's final combinator.
+type relativeMatcher struct {
+	sel *selector
+	// combinator is the leading combinator of the relative selector.
+	combinator string
+	// cached is set if whether an element's subtree holds a match doesn't
+	// depend on the anchor, so results can be shared between anchors.
+	cached bool
+	// deep is set if matches can be descendants of the elements related to
+	// the anchor by the leading combinator, rather than only those elements.
+	deep bool
+	// siblings is set if the relative selector continues through sibling
+	// combinators, so matches can be any following sibling of the anchor,
+	// even for the next-sibling combinator.
+	siblings bool
+}
+
+// reach reports which elements can match a relative selector. Matches of
+// ":has(> a + b)" are children of the anchor, while matches of ":has(> a b)"
+// can be any of its descendants.
+func reach(r *relativeSelector) (deep, siblings bool) {
+	switch r.combinator {
+	case ">", "+", "~":
+	default:
+		deep = true
+	}
+	for curr := &r.sel; curr.next != nil; curr = curr.next {
+		switch curr.combinator {
+		case "+", "~":
+			siblings = true
+		default:
+			deep = true
+		}
+	}
+	return deep, siblings
+}
+
+// cacheable reports if matches of the relative selector r can be found
+// without regard to the anchor, because the relative selector is a single
+// compound selector that's a descendant of the anchor, such as :has(a).
+// Whether an element's subtree holds a match is then the same for every
+// anchor it's a descendant of.
+func cacheable(r *relativeSelector) bool {
+	if r.combinator != "" || r.sel.next != nil {
+		return false
+	}
+	for _, sc := range r.sel.sel.subClasses {
+		if ps := sc.pseudoClassSelector; ps != nil && ps.function == "" && strings.EqualFold(ps.ident, "scope") {
+			return false
+		}
+	}
+	return true
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:has
+func (c *compiler) has(s *pseudoClassSelector) matchFunc {
+	list, err := parseRelativeSelectorListArg(s.args)
+	if err != nil {
+		pos, msg := argError(err, s.pos)
+		c.errorf(pos, "invalid selector in :has(): %s", msg)
+		return nil
+	}
+	var sels []relativeMatcher
+	for i := range list {
+		m, err := c.compileArg(&list[i].sel)
+		if err != nil {
+			pos, msg := argError(err, s.pos)
+			c.errorf(pos, "invalid selector in :has(): %s", msg)
+			return nil
+		}
+		anchor := c.combinator(list[i].sel.pos, list[i].combinator, anchorMatcher)
+		if anchor == nil {
+			return nil
+		}
+		m.combinators = append(m.combinators, anchor)
+		deep, siblings := reach(&list[i])
+		sels = append(sels, relativeMatcher{
+			sel:        m,
+			combinator: list[i].combinator,
+			cached:     cacheable(&list[i]),
+			deep:       deep,
+			siblings:   siblings,
+		})
+	}
+	// Matches depend on descendants and following siblings, so incremental
+	// updates can't be scoped.
+	c.relational = true
+	return func(st *state, n *html.Node) bool {
+		prev := st.anchor
+		st.anchor = n
+		defer func() { st.anchor = prev }()
+		for i := range sels {
+			if sels[i].match(st, n) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// match reports if any element related to the anchor n matches the relative
+// selector. Only the elements that can match are tested: the children of n
+// for descendant and child combinators, and the following siblings of n for
+// sibling combinators, along with their descendants if the relative selector
+// can reach them.
+func (r *relativeMatcher) match(st *state, n *html.Node) bool {
+	if r.cached {
+		return r.contains(st, n)
+	}
+	switch r.combinator {
+	case "+", "~":
+		for next := st.nextElementSibling(n); next != nil; next = st.nextElementSibling(next) {
+			if r.matchRelated(st, next) {
+				return true
+			}
+			if r.combinator == "+" && !r.siblings {
+				return false
+			}
+		}
+		return false
+	}
+	for child := firstElementChild(n); child != nil; child = nextElementSibling(child) {
+		if r.matchRelated(st, child) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRelated reports if n, an element related to the anchor, or any of its
+// descendants the selector can reach match the selector.
+func (r *relativeMatcher) matchRelated(st *state, n *html.Node) bool {
+	if !r.deep {
+		return r.sel.match(st, n)
+	}
+	return r.matchSubtree(st, n)
+}
+
+// matchSubtree reports if n or any of its descendants match the selector.
+func (r *relativeMatcher) matchSubtree(st *state, n *html.Node) bool {
+	if r.sel.match(st, n) {
+		return true
+	}
+	for child := firstElementChild(n); child != nil; child = nextElementSibling(child) {
+		if r.matchSubtree(st, child) {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports if any descendant of n matches the relative selector's
+// compound selector. Results are cached for the duration of the selection,
+// so evaluating :has(a) against every element in a document visits each
+// element once, rather than once for each of its ancestors.
+func (r *relativeMatcher) contains(st *state, n *html.Node) bool {
+	cache := st.subtrees[r.sel]
+	if cache == nil {
+		if st.subtrees == nil {
+			st.subtrees = map[*selector]map[*html.Node]bool{}
+		}
+		cache = map[*html.Node]bool{}
+		st.subtrees[r.sel] = cache
+	}
+	if found, ok := cache[n]; ok {
+		return found
+	}
+	found := false
+	for child := firstElementChild(n); child != nil; child = nextElementSibling(child) {
+		// Every descendant of the anchor satisfies the anchor's combinator,
+		// so only the compound selector is matched.
+		if st.matchCompound(r.sel.m, "", child) || r.contains(st, child) {
+			found = true
+			break
+		}
+	}
+	cache[n] = found
+	return found
+}
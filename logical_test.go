@@ -0,0 +1,343 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestLogicalPseudoClasses(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<div id="a"><p>1</p></div>
+<div id="b"><span><p>2</p></span></div>
+<div id="c"><img></div>
+<h2 id="d">3</h2><p id="e">4</p>
+<section id="f"><p class="x">5</p></section>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{":is(#a, #c)", []string{`#a`, `#c`}},
+		{"div:is(:nth-child(2), #c)", []string{`#b`, `#c`}},
+		{":is(div, section) > p", []string{`1`, `5`}},
+		{"p:is(section .x, span > p)", []string{`2`, `5`}},
+		// Forgiving lists drop invalid and unsupported selectors.
+		{":is(#a, !!, :unknown, #c)", []string{`#a`, `#c`}},
+		{"div:is()", nil},
+		// Pseudo-elements in arguments never match.
+		{":is(#a, #c::before)", []string{`#a`}},
+		{"div:has(p::part(x))", nil},
+		{"div:has(p)", []string{`#a`, `#b`}},
+		{"div:has(> p)", []string{`#a`}},
+		{"div:has(> span p)", []string{`#b`}},
+		{"div:has(img, > span)", []string{`#b`, `#c`}},
+		{"h2:has(+ p)", []string{`#d`}},
+		{"h2:has(+ section)", nil},
+		{"div:has(~ section .x)", []string{`#a`, `#b`, `#c`}},
+		{"body > :has(p):is(section)", []string{`#f`}},
+		{"div:has(+ div ~ h2)", []string{`#a`, `#b`}},
+		{"h2:has(+ p ~ section .x)", []string{`#d`}},
+		{"div:has(> span > p)", []string{`#b`}},
+		{"div:has(> span + p)", nil},
+		{":has(:has(img))", []string{`html`, `body`}},
+		{"div:not(#a)", []string{`#b`, `#c`}},
+		{"div:not(#a, :has(img))", []string{`#b`}},
+		{"body > :not(div, h2)", []string{`#e`, `#f`}},
+		{"p:not(div > p, .x)", []string{`2`, `#e`}},
+		{"div:not(:is(#a, :is(#b)))", []string{`#c`}},
+		{":not(html, head, body, div, div *, section, section *)", []string{`#d`, `#e`}},
+		{"div:not(:not(#c))", []string{`#c`}},
+		{"div:not(#a, :not(#a, #b))", []string{`#b`}},
+		{"div:not(:not(#a, #b), :not(#b, #c))", []string{`#b`}},
+		{":where(#a, #c)", []string{`#a`, `#c`}},
+		{":where(div, section) > p", []string{`1`, `5`}},
+		{":where(#a, !!, :unknown)", []string{`#a`}},
+		{"div:where()", nil},
+		{":is(#a, :where(#b, :is(#c)))", []string{`#a`, `#b`, `#c`}},
+		{"div:not(:where(#a, #b))", []string{`#c`}},
+		// Nested :is() arguments stay forgiving inside :not().
+		{"div:not(:is(#a, :foo))", []string{`#b`, `#c`}},
+		{"div:not(:is(#a, :hover))", []string{`#b`, `#c`}},
+		{"div:not(:where(#a, !!))", []string{`#b`, `#c`}},
+		// :not() of a selector that never matches matches every element.
+		{"div:not(:is(#a::before))", []string{`#a`, `#b`, `#c`}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		var got []string
+		for _, n := range s.Select(root) {
+			switch {
+			case n.Data == "html" || n.Data == "body":
+				got = append(got, n.Data)
+			case hasID(n):
+				id, _ := attr(n, "id")
+				got = append(got, "#"+id)
+			default:
+				got = append(got, n.FirstChild.Data)
+			}
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+}
+
+func hasID(n *html.Node) bool {
+	_, ok := attr(n, "id")
+	return ok
+}
+
+func TestLogicalPseudoClassErrors(t *testing.T) {
+	tests := []struct {
+		sel      string
+		warnings int
+		wantErr  bool
+	}{
+		{":is(a, !!)", 1, false},
+		{":is(a, :unknown)", 1, false},
+		{":is(a, ::part(x))", 0, false},
+		{":is(a::before)", 0, false},
+		{":host(::before)", 0, false},
+		{":is()", 0, false},
+		{":where(a, !!)", 1, false},
+		{":where(a, :unknown)", 1, false},
+		{":has(a, !!)", 0, true},
+		{":has(:unknown)", 0, true},
+		{":has()", 0, true},
+		{":has(>)", 0, true},
+		{":has(::part(x))", 0, false},
+		{":not(a, !!)", 0, true},
+		{":not(:unknown)", 0, true},
+		{":not()", 0, true},
+		{":not(a::before)", 0, true},
+		{":not(a, :is(b, !!))", 1, false},
+		{":not(:not(a, :unknown))", 0, true},
+		{":not(:not(a::before))", 0, true},
+		{":not(:not(:is(a, :unknown)))", 1, false},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) succeeded, want error", test.sel)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		if got := len(s.Warnings()); got != test.warnings {
+			t.Errorf("Parse(%q) returned %d warnings, want %d: %v", test.sel, got, test.warnings, s.Warnings())
+		}
+		if test.warnings > 0 {
+			if _, err := Parse(test.sel, WithStrict()); err == nil {
+				t.Errorf("Parse(%q, WithStrict()) succeeded, want error", test.sel)
+			}
+		}
+	}
+}
+
+func TestHasUpdate(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<div><p id="p"></p></div><div></div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	s := MustParse("div:has(.x)")
+	prev := s.Select(root)
+	if len(prev) != 0 {
+		t.Fatalf("Select() returned %d elements, want 0", len(prev))
+	}
+	p := MustParse("#p").Select(root)[0]
+	p.Attr = append(p.Attr, html.Attribute{Key: "class", Val: "x"})
+	got := s.Update(root, prev, &Mutation{Target: p})
+	if len(got) != 1 || got[0] != p.Parent {
+		t.Errorf("Update() returned %v, want the parent of the mutated element", got)
+	}
+}
+
+func TestHasCachesSubtrees(t *testing.T) {
+	const depth = 200
+	doc := strings.Repeat(`<div data-x="1">`, depth) + strings.Repeat(`</div>`, depth)
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	calls := 0
+	count := func(arg string) (func(val string) bool, error) {
+		return func(val string) bool {
+			calls++
+			return arg == "match" && val == "1"
+		}, nil
+	}
+	tests := []struct {
+		sel  string
+		want int
+	}{
+		{"div:has([data-x:count(none)])", 0},
+		{"div:has([data-x:count(match)])", depth - 1},
+	}
+	for _, test := range tests {
+		calls = 0
+		sel := MustParse(test.sel, WithComparator("count", count))
+		if got := len(sel.Select(root)); got != test.want {
+			t.Errorf("Parse(%q).Select() returned %d elements, want %d", test.sel, got, test.want)
+		}
+		// Without caching, every element's subtree is searched again for
+		// each of its ancestors.
+		if calls > 2*depth {
+			t.Errorf("Parse(%q).Select() tested %d elements, want at most %d", test.sel, calls, 2*depth)
+		}
+	}
+}
+
+func TestHasRelatedElements(t *testing.T) {
+	const depth = 200
+	doc := `<div data-x="1"></div>` + strings.Repeat(`<div data-x="1">`, depth) + strings.Repeat(`</div>`, depth)
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	calls := 0
+	count := func(arg string) (func(val string) bool, error) {
+		return func(val string) bool {
+			calls++
+			return arg == "match" && val == "1"
+		}, nil
+	}
+	// Child and sibling combinators only test the children or siblings of
+	// the anchor, rather than searching their subtrees.
+	tests := []struct {
+		sel      string
+		want     int
+		maxCalls int
+	}{
+		{"div:has(> [data-x:count(none)])", 0, depth},
+		{"div:has(> [data-x:count(match)])", depth - 1, depth},
+		{"div:has(> [data-x] + [data-x:count(none)])", 0, depth},
+		{"div:has(+ [data-x:count(none)])", 0, 1},
+		{"div:has(+ [data-x:count(match)])", 1, 1},
+		{"div:has(~ [data-x:count(none)])", 0, 1},
+	}
+	for _, test := range tests {
+		calls = 0
+		sel := MustParse(test.sel, WithComparator("count", count))
+		if got := len(sel.Select(root)); got != test.want {
+			t.Errorf("Parse(%q).Select() returned %d elements, want %d", test.sel, got, test.want)
+		}
+		if calls > test.maxCalls {
+			t.Errorf("Parse(%q).Select() tested %d elements, want at most %d", test.sel, calls, test.maxCalls)
+		}
+	}
+}
+
+func TestHasCachedMatchesSubtrees(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<div id="a"><p class="x"><span></span></p><p></p></div>
+<div id="b"><div id="c"><em></em></div><p class="x"></p></div>
+<section id="d"><div id="e"><p></p></div></section>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	// Each selector is compared against searching the descendants of every
+	// element for the argument.
+	tests := []struct {
+		sel, arg string
+	}{
+		{":has(.x)", ".x"},
+		{":has(p)", "p"},
+		{":has(em, span)", "em, span"},
+		{":has(:has(em))", ":has(em)"},
+		{":has(p:is(.x))", "p.x"},
+	}
+	for _, test := range tests {
+		arg := MustParse(test.arg)
+		var want []*html.Node
+		MustParse("*").SelectReverse(root, func(n *html.Node) bool {
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if len(arg.Select(c)) > 0 {
+					want = append([]*html.Node{n}, want...)
+					break
+				}
+			}
+			return true
+		})
+		got := MustParse(test.sel).Select(root)
+		if diff := cmp.Diff(renderNodes(t, want), renderNodes(t, got)); diff != "" {
+			t.Errorf("Parse(%q).Select() returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+}
+
+func TestIsFlattening(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<a data-x="1"></a><b data-x="2"></b><i data-x="3"></i>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	calls := 0
+	count := func(arg string) (func(val string) bool, error) {
+		return func(val string) bool {
+			calls++
+			return val == arg
+		}, nil
+	}
+	tests := []struct {
+		sel       string
+		want      []string
+		wantCalls int
+		warnings  int
+	}{
+		// Each of the three elements is tested once, rather than once for
+		// each level of nesting.
+		{":is([data-x:count(1)], :is([data-x:count(1)], :is([data-x:count(1)])))", []string{`1`}, 3, 0},
+		{":is([data-x:count(0)], *:is([data-x:count(0)]))", nil, 3, 0},
+		{":where([data-x:count(1)], :is([data-x:count(1)], :where([data-x:count(1)])))", []string{`1`}, 3, 0},
+		// Each distinct argument is tested against each element until one
+		// matches.
+		{":is([data-x:count(1)], :is([data-x:count(2)], :is([data-x:count(1)])))", []string{`1`, `2`}, 5, 0},
+		// Invalid selectors in nested lists are still dropped with a warning.
+		{":is(:is(!!, [data-x:count(3)]))", []string{`3`}, 3, 1},
+		// Unsupported selectors inlined into :not() are dropped with a
+		// warning, as they would be by the nested :is().
+		{"[data-x]:not(:is([data-x:count(1)], :foo))", []string{`2`, `3`}, 3, 1},
+		// :not(:not(a)) is compiled as :is(a).
+		{"[data-x]:not(:not([data-x:count(1)]))", []string{`1`}, 3, 0},
+		{"[data-x]:not([data-x:count(2)], :not([data-x:count(1)], :is([data-x:count(2)])))", []string{`1`}, 6, 0},
+		// Compound selectors with more than an :is() aren't flattened.
+		{":is(b:is([data-x:count(2)]))", []string{`2`}, 1, 0},
+	}
+	for _, test := range tests {
+		sel, err := Parse(test.sel, WithComparator("count", count))
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		if got := len(sel.Warnings()); got != test.warnings {
+			t.Errorf("Parse(%q) returned %d warnings, want %d", test.sel, got, test.warnings)
+		}
+		calls = 0
+		var got []string
+		for _, n := range sel.Select(root) {
+			v, _ := attr(n, "data-x")
+			got = append(got, v)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q).Select() returned diff (-want, +got): %s", test.sel, diff)
+		}
+		if calls != test.wantCalls {
+			t.Errorf("Parse(%q).Select() called comparator %d times, want %d", test.sel, calls, test.wantCalls)
+		}
+	}
+}
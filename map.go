@@ -0,0 +1,52 @@
+package css
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/net/html"
+)
+
+// MapError records a failure to extract a value from an element.
+type MapError struct {
+	// Index is the position of the element within the selection.
+	Index int
+	Node  *html.Node
+	Err   error
+}
+
+// Error returns a formatted version of the error.
+func (e *MapError) Error() string {
+	return fmt.Sprintf("css: element %d %s: %v", e.Index, describeNode(e.Node), e.Err)
+}
+
+// Unwrap returns the error returned by the mapping function.
+func (e *MapError) Unwrap() error {
+	return e.Err
+}
+
+// Map calls fn for each element selected from root, returning the values
+// extracted from the elements in document order:
+//
+//	prices, err := css.Map(doc, css.MustParse(".price"), func(n *html.Node) (float64, error) {
+//		return strconv.ParseFloat(textContent(n), 64)
+//	})
+//
+// Every element is visited, even if fn fails. Errors are aggregated into a
+// single error, joining a *MapError for each failure, and the returned values
+// hold the results of the calls that succeeded.
+func Map[T any](root *html.Node, sel *Selector, fn func(n *html.Node) (T, error)) ([]T, error) {
+	var (
+		vals []T
+		errs []error
+	)
+	for i, n := range sel.Select(root) {
+		v, err := fn(n)
+		if err != nil {
+			errs = append(errs, &MapError{Index: i, Node: n, Err: err})
+			continue
+		}
+		vals = append(vals, v)
+	}
+	return vals, errors.Join(errs...)
+}
@@ -0,0 +1,55 @@
+package css
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestMap(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<li data-price="1.5"></li>
+<li data-price="free"></li>
+<li data-price="3"></li>
+<li data-price=""></li>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	price := func(n *html.Node) (float64, error) {
+		v, _ := attr(n, "data-price")
+		return strconv.ParseFloat(v, 64)
+	}
+
+	got, err := Map(root, MustParse("li"), price)
+	if diff := cmp.Diff([]float64{1.5, 3}, got); diff != "" {
+		t.Errorf("Map() returned diff (-want, +got): %s", diff)
+	}
+	if err == nil {
+		t.Fatalf("Map() didn't return an error")
+	}
+	var merr *MapError
+	if !errors.As(err, &merr) || merr.Index != 1 {
+		t.Errorf("Map() returned error %v, want *MapError for element 1", err)
+	}
+	var nerr *strconv.NumError
+	if !errors.As(err, &nerr) {
+		t.Errorf("Map() returned error that doesn't wrap *strconv.NumError: %v", err)
+	}
+	if n := strings.Count(err.Error(), "css: element"); n != 2 {
+		t.Errorf("Map() returned %d errors, want 2: %v", n, err)
+	}
+
+	texts, err := Map(root, MustParse("li:first-child"), func(n *html.Node) (string, error) {
+		return n.Data, nil
+	})
+	if err != nil {
+		t.Errorf("Map() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"li"}, texts); diff != "" {
+		t.Errorf("Map() returned diff (-want, +got): %s", diff)
+	}
+}
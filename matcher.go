@@ -0,0 +1,92 @@
+package css
+
+import (
+	"golang.org/x/net/html"
+)
+
+// Matcher reports if an element satisfies a condition. *Selector implements
+// Matcher, and hand-written predicates can be adapted with MatcherFunc, so
+// compiled selectors and programmatic constraints can be combined:
+//
+//	inStock := css.MatcherFunc(func(n *html.Node) bool {
+//		// ...
+//	})
+//	m := css.AndMatcher(css.MustParse("li.product"), inStock)
+//	products := css.SelectMatcher(doc, m)
+type Matcher interface {
+	Match(n *html.Node) bool
+}
+
+// MatcherFunc adapts a function to the Matcher interface.
+type MatcherFunc func(n *html.Node) bool
+
+// Match calls f(n).
+func (f MatcherFunc) Match(n *html.Node) bool {
+	return f(n)
+}
+
+// Match reports if the element n matches the selector. Like Matches,
+// combinators are evaluated against the entire tree containing n, and
+// selectors with pseudo-elements never match.
+func (s *Selector) Match(n *html.Node) bool {
+	if n == nil || !isElement(n) {
+		return false
+	}
+	return s.matchElement(s.newState(treeRoot(n), &MatchContext{}), n)
+}
+
+// AndMatcher returns a matcher that matches elements matched by every one of
+// ms. Matchers are evaluated in order, stopping at the first that doesn't
+// match.
+func AndMatcher(ms ...Matcher) Matcher {
+	return MatcherFunc(func(n *html.Node) bool {
+		for _, m := range ms {
+			if !m.Match(n) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// OrMatcher returns a matcher that matches elements matched by any of ms.
+// Matchers are evaluated in order, stopping at the first that matches.
+func OrMatcher(ms ...Matcher) Matcher {
+	return MatcherFunc(func(n *html.Node) bool {
+		for _, m := range ms {
+			if m.Match(n) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// NotMatcher returns a matcher that matches elements not matched by m.
+func NotMatcher(m Matcher) Matcher {
+	return MatcherFunc(func(n *html.Node) bool {
+		return !m.Match(n)
+	})
+}
+
+// SelectMatcher returns the elements in the tree rooted at n matched by m, in
+// document order, including n itself if it matches. Unlike Select, a
+// Selector passed as m evaluates combinators against the entire tree
+// containing n.
+func SelectMatcher(n *html.Node, m Matcher) []*html.Node {
+	selected := []*html.Node{}
+	if !validRoot(n) {
+		return selected
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if isElement(n) && m.Match(n) {
+			selected = append(selected, n)
+		}
+		for c := firstElementChild(n); c != nil; c = nextElementSibling(c) {
+			walk(c)
+		}
+	}
+	walk(n)
+	return selected
+}
@@ -0,0 +1,72 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestMatcher(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<ul>
+	<li class="product" data-stock="3">a</li>
+	<li class="product" data-stock="0">b</li>
+	<li class="ad">c</li>
+</ul>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	inStock := MatcherFunc(func(n *html.Node) bool {
+		v, ok := attr(n, "data-stock")
+		return ok && v != "0"
+	})
+
+	tests := []struct {
+		name string
+		m    Matcher
+		want []string
+	}{
+		{"selector", MustParse("ul > .product"), []string{
+			`<li class="product" data-stock="3">a</li>`,
+			`<li class="product" data-stock="0">b</li>`,
+		}},
+		{"and", AndMatcher(MustParse("li.product"), inStock), []string{
+			`<li class="product" data-stock="3">a</li>`,
+		}},
+		{"or", OrMatcher(MustParse(".ad"), inStock), []string{
+			`<li class="product" data-stock="3">a</li>`,
+			`<li class="ad">c</li>`,
+		}},
+		{"not", AndMatcher(MustParse("li"), NotMatcher(MustParse(".ad"))), []string{
+			`<li class="product" data-stock="3">a</li>`,
+			`<li class="product" data-stock="0">b</li>`,
+		}},
+		{"empty and", AndMatcher(MustParse("li:first-child")), []string{
+			`<li class="product" data-stock="3">a</li>`,
+		}},
+		{"empty or", OrMatcher(), []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := renderNodes(t, SelectMatcher(root, test.m))
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("SelectMatcher() returned diff (-want, +got): %s", diff)
+			}
+		})
+	}
+
+	// Selectors evaluate combinators against the whole tree, even when
+	// selecting from a subtree.
+	ul := MustParse("ul").Select(root)[0]
+	if got := SelectMatcher(ul, MustParse("body li.ad")); len(got) != 1 {
+		t.Errorf("SelectMatcher(ul, \"body li.ad\") returned %d elements, want 1", len(got))
+	}
+	if MustParse("li").Match(nil) {
+		t.Errorf("Selector.Match(nil) returned true")
+	}
+	if got := SelectMatcher(nil, inStock); got == nil || len(got) != 0 {
+		t.Errorf("SelectMatcher(nil) = %v, want empty slice", got)
+	}
+}
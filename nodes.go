@@ -0,0 +1,106 @@
+package css
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Selectors only ever match element nodes. When a matcher considers the
+// parent, siblings, or children of an element, such as combinators,
+// :first-child, or :nth-of-type(), only elements are counted. Text, comment,
+// and doctype nodes are skipped as if they weren't in the tree.
+//
+// The exception is :empty, which tests an element's content rather than its
+// structure. Elements and text nodes are content, but following Selectors
+// Level 4, comments and whitespace-only text aren't. "<p> <!-- note --></p>"
+// is empty, while "<p>note</p>" isn't.
+//
+// Matchers should use the helpers below, rather than walking html.Node links
+// directly, so every pseudo-class treats non-element nodes the same way.
+//
+// https://www.w3.org/TR/selectors-4/#structural-pseudos
+
+// isElement reports if n is an element node.
+func isElement(n *html.Node) bool {
+	return n.Type == html.ElementNode
+}
+
+// isContent reports if n counts as content of its parent for :empty.
+func isContent(n *html.Node) bool {
+	switch n.Type {
+	case html.ElementNode:
+		return true
+	case html.TextNode:
+		return strings.TrimLeft(n.Data, " \t\n\r\f") != ""
+	}
+	return false
+}
+
+// firstElementChild returns the first child of n that's an element.
+func firstElementChild(n *html.Node) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if isElement(c) {
+			return c
+		}
+	}
+	return nil
+}
+
+// lastElementChild returns the last child of n that's an element.
+func lastElementChild(n *html.Node) *html.Node {
+	for c := n.LastChild; c != nil; c = c.PrevSibling {
+		if isElement(c) {
+			return c
+		}
+	}
+	return nil
+}
+
+// nextElementSibling returns the next sibling of n that's an element.
+func nextElementSibling(n *html.Node) *html.Node {
+	for s := n.NextSibling; s != nil; s = s.NextSibling {
+		if isElement(s) {
+			return s
+		}
+	}
+	return nil
+}
+
+// prevElementSibling returns the previous sibling of n that's an element.
+func prevElementSibling(n *html.Node) *html.Node {
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if isElement(s) {
+			return s
+		}
+	}
+	return nil
+}
+
+// elementType identifies the type of an element for pseudo-classes such as
+// :first-of-type. Elements with the same tag name in different namespaces are
+// different types, and custom elements, which have no atom, are compared by
+// name.
+type elementType struct {
+	name      string
+	namespace string
+}
+
+func typeOf(n *html.Node) elementType {
+	return elementType{n.Data, n.Namespace}
+}
+
+// sameType reports if a and b are elements of the same type.
+func sameType(a, b *html.Node) bool {
+	return typeOf(a) == typeOf(b)
+}
+
+// attr returns the value of an element's attribute.
+func attr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
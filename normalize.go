@@ -0,0 +1,50 @@
+package css
+
+import (
+	"golang.org/x/net/html"
+	"golang.org/x/text/unicode/norm"
+)
+
+// WithNFC causes ids, classes, and attribute values to be compared after
+// Unicode NFC normalization, both in the selector and in the document. For
+// example, "[title=café]" matches an element whose title uses a combining
+// acute accent (NFD), even if the selector uses a precomposed "é".
+//
+// Documents scraped from different sources often mix normalization forms,
+// but normalizing adds overhead to every comparison, so it's opt-in. Element
+// and attribute names aren't normalized.
+//
+// https://unicode.org/reports/tr15/
+func WithNFC() Option {
+	return func(o *options) {
+		o.nfc = true
+	}
+}
+
+// nfc returns the NFC normalization of s.
+func nfc(s string) string {
+	if norm.NFC.IsNormalString(s) {
+		return s
+	}
+	return norm.NFC.String(s)
+}
+
+// nfcAttrs returns attrs with values normalized to NFC, copying the slice
+// only if a value changes.
+func nfcAttrs(attrs []html.Attribute) []html.Attribute {
+	var normalized []html.Attribute
+	for i, a := range attrs {
+		val := nfc(a.Val)
+		if val == a.Val {
+			continue
+		}
+		if normalized == nil {
+			normalized = append([]html.Attribute(nil), attrs...)
+		}
+		normalized[i].Val = val
+	}
+	if normalized == nil {
+		return attrs
+	}
+	return normalized
+}
@@ -0,0 +1,64 @@
+package css
+
+import (
+	"strconv"
+)
+
+// Nth is an An+B value, as accepted by :nth-child() and related
+// pseudo-classes. It represents the positions An+B for every non-negative
+// integer n.
+//
+// https://drafts.csswg.org/css-syntax-3/#anb-microsyntax
+type Nth struct {
+	A int
+	B int
+}
+
+// ParseNth parses the An+B microsyntax, such as "2n+1", "-n+3", or "odd".
+func ParseNth(s string) (Nth, error) {
+	p := newParser(s)
+	nth, err := p.aNPlusB()
+	if err != nil {
+		return Nth{}, parseError(err)
+	}
+	if err := p.expectWhitespaceOrEOF(); err != nil {
+		return Nth{}, parseError(err)
+	}
+	return *nth, nil
+}
+
+// Matches reports if the 1-based position i is represented by the An+B value.
+func (nth Nth) Matches(i int) bool {
+	// Is there a value for "n" given "An+B=i" where "n" is non-negative?
+
+	// An + B = i
+	// An = i - B
+	// n = (i - B) / A
+	if nth.A == 0 {
+		return i == nth.B
+	}
+	return (i-nth.B)%nth.A == 0 && (i-nth.B)/nth.A >= 0
+}
+
+// String returns the canonical form of the An+B value, such as "2n+1".
+func (nth Nth) String() string {
+	if nth.A == 0 {
+		return strconv.Itoa(nth.B)
+	}
+	var s string
+	switch nth.A {
+	case 1:
+		s = "n"
+	case -1:
+		s = "-n"
+	default:
+		s = strconv.Itoa(nth.A) + "n"
+	}
+	switch {
+	case nth.B > 0:
+		s += "+" + strconv.Itoa(nth.B)
+	case nth.B < 0:
+		s += strconv.Itoa(nth.B)
+	}
+	return s
+}
@@ -0,0 +1,51 @@
+package css
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseNth(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Nth
+		str     string
+		matches []int
+	}{
+		{"odd", Nth{2, 1}, "2n+1", []int{1, 3, 5, 7, 9}},
+		{"even", Nth{2, 0}, "2n", []int{2, 4, 6, 8, 10}},
+		{" 3 ", Nth{0, 3}, "3", []int{3}},
+		{"-n+3", Nth{-1, 3}, "-n+3", []int{1, 2, 3}},
+		{"n", Nth{1, 0}, "n", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{"3n - 2", Nth{3, -2}, "3n-2", []int{1, 4, 7, 10}},
+	}
+	for _, test := range tests {
+		got, err := ParseNth(test.s)
+		if err != nil {
+			t.Errorf("ParseNth(%q) failed: %v", test.s, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseNth(%q) = %#v, want %#v", test.s, got, test.want)
+		}
+		if s := got.String(); s != test.str {
+			t.Errorf("ParseNth(%q).String() = %q, want %q", test.s, s, test.str)
+		}
+		var matches []int
+		for i := 1; i <= 10; i++ {
+			if got.Matches(i) {
+				matches = append(matches, i)
+			}
+		}
+		if diff := cmp.Diff(test.matches, matches); diff != "" {
+			t.Errorf("ParseNth(%q) matched positions diff (-want, +got): %s", test.s, diff)
+		}
+	}
+
+	for _, s := range []string{"", "foo", "2n+", "odd even"} {
+		if _, err := ParseNth(s); err == nil {
+			t.Errorf("ParseNth(%q) didn't return an error", s)
+		}
+	}
+}
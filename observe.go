@@ -0,0 +1,150 @@
+package css
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// Stats holds statistics about a single selection.
+type Stats struct {
+	// Visited is the number of elements tested against the selector.
+	Visited int
+	// Matched is the number of elements returned.
+	Matched int
+	// Duration is the total time spent selecting.
+	Duration time.Duration
+	// Stages holds statistics for each compound selector, ordered by
+	// selector, then from the subject of the selector to its leftmost
+	// compound selector.
+	Stages []StageStats
+}
+
+// StageStats holds statistics for evaluating a compound selector within a
+// complex selector.
+type StageStats struct {
+	// Selector is the index of the complex selector within the list.
+	Selector int
+	// Compound is the serialized compound selector.
+	Compound string
+	// Combinator relates elements tested by this stage to elements matched by
+	// the previous stage: " ", ">", "+", "~", or a non-standard combinator
+	// such as "^". It's empty for the subject of the selector.
+	Combinator string
+	// Evaluated and Matched are the number of elements tested by the stage,
+	// and the number that matched.
+	Evaluated int
+	Matched   int
+	// Duration is the time spent testing elements, excluding later stages.
+	Duration time.Duration
+}
+
+// Observer receives statistics about selections performed by a Selector. It's
+// intended for monitoring which selectors are expensive in production.
+type Observer interface {
+	ObserveSelect(s *Stats)
+}
+
+// ObserverFunc adapts a function to the Observer interface.
+type ObserverFunc func(s *Stats)
+
+// ObserveSelect calls f(s).
+func (f ObserverFunc) ObserveSelect(s *Stats) {
+	f(s)
+}
+
+// WithObserver causes the selector to report statistics for each call to
+// Select, SelectWithContext, or Session.Select. Observing selection adds
+// overhead, including timing each compound selector evaluation, and
+// selections without an observer aren't affected.
+//
+// ObserveSelect is called synchronously, after selection completes. If the
+// selector is used concurrently, the observer must be safe for concurrent use.
+func WithObserver(o Observer) Option {
+	return func(opts *options) {
+		opts.observer = o
+	}
+}
+
+// SelectWithStats is like Select, but also returns statistics about the
+// selection, for ad-hoc profiling and logging without configuring an
+// Observer:
+//
+//	nodes, stats := sel.SelectWithStats(root)
+//	log.Printf("visited %d elements in %s", stats.Visited, stats.Duration)
+//
+// Collecting statistics adds the same overhead as WithObserver. If the
+// selector has an observer or logger, the selection is reported to them as
+// well.
+func (s *Selector) SelectWithStats(n *html.Node) ([]*html.Node, Stats) {
+	st := s.newState(n, &MatchContext{})
+	nodes := s.observeSelect(st, n)
+	return nodes, *st.stats
+}
+
+// observeSelect performs a selection, collecting statistics in st and
+// reporting them to any observer and logger.
+func (s *Selector) observeSelect(st *state, n *html.Node) []*html.Node {
+	st.stats = &Stats{}
+	st.stages = map[*compoundSelector]int{}
+	for i := range s.list {
+		var compounds []*complexSelector
+		for curr := &s.list[i]; curr != nil; curr = curr.next {
+			compounds = append(compounds, curr)
+		}
+		for j := len(compounds) - 1; j >= 0; j-- {
+			combinator := ""
+			if j < len(compounds)-1 {
+				combinator = compounds[j].combinator
+				if combinator == "" {
+					combinator = " "
+				}
+			}
+			var b strings.Builder
+			writeCompoundSelector(&b, &compounds[j].sel)
+			st.stages[&compounds[j].sel] = len(st.stats.Stages)
+			st.stats.Stages = append(st.stats.Stages, StageStats{
+				Selector:   i,
+				Compound:   b.String(),
+				Combinator: combinator,
+			})
+		}
+	}
+
+	start := time.Now()
+	nodes := s.selectState(st, n)
+	st.stats.Duration = time.Since(start)
+	st.stats.Matched = len(nodes)
+	if s.observer != nil {
+		s.observer.ObserveSelect(st.stats)
+	}
+	if s.logger != nil {
+		s.logger.Debug("css: selected", "selector", s.String(), "visited", st.stats.Visited, "matched", st.stats.Matched, "duration", st.stats.Duration)
+	}
+	return nodes
+}
+
+// observeCompound matches a compound selector against n, recording the
+// result for any trace or observer.
+func (s *state) observeCompound(m *compoundSelectorMatcher, combinator string, n *html.Node) bool {
+	var start time.Time
+	if s.stats != nil {
+		start = time.Now()
+	}
+	ok := m.match(s, n)
+	if s.stats != nil {
+		if i, found := s.stages[m.src]; found {
+			stage := &s.stats.Stages[i]
+			stage.Duration += time.Since(start)
+			stage.Evaluated++
+			if ok {
+				stage.Matched++
+			}
+		}
+	}
+	if s.trace != nil {
+		s.record(m, combinator, n, ok)
+	}
+	return ok
+}
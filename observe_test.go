@@ -0,0 +1,96 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/net/html"
+)
+
+func TestObserver(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<div class="a"><p>1</p><p>2</p></div><p>3</p>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	var got []*Stats
+	o := ObserverFunc(func(s *Stats) { got = append(got, s) })
+	sel := MustParse("div.a > p, span", WithObserver(o))
+	nodes := sel.Select(root)
+	if len(nodes) != 2 {
+		t.Fatalf("Select() returned %d nodes, want 2", len(nodes))
+	}
+	if len(got) != 1 {
+		t.Fatalf("observer called %d times, want 1", len(got))
+	}
+
+	// html, head, body, div, p, p, p
+	want := &Stats{
+		Visited: 7,
+		Matched: 2,
+		Stages: []StageStats{
+			{Selector: 0, Compound: "p", Evaluated: 7, Matched: 3},
+			{Selector: 0, Compound: "div.a", Combinator: ">", Evaluated: 3, Matched: 2},
+			// Elements matched by the first selector aren't tested again.
+			{Selector: 1, Compound: "span", Evaluated: 5},
+		},
+	}
+	opts := cmpopts.IgnoreFields(Stats{}, "Duration")
+	stageOpts := cmpopts.IgnoreFields(StageStats{}, "Duration")
+	if diff := cmp.Diff(want, got[0], opts, stageOpts); diff != "" {
+		t.Errorf("observer returned diff (-want, +got): %s", diff)
+	}
+	if got[0].Duration <= 0 {
+		t.Errorf("observer reported duration %s, want positive duration", got[0].Duration)
+	}
+
+	NewSession(root).Select(sel)
+	if len(got) != 2 {
+		t.Errorf("observer not called for Session.Select()")
+	}
+}
+
+func TestSelectWithStats(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<div class="a"><p>1</p><p>2</p></div><p>3</p>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	sel := MustParse("div.a > p")
+	nodes, stats := sel.SelectWithStats(root)
+	if diff := cmp.Diff(renderNodes(t, sel.Select(root)), renderNodes(t, nodes)); diff != "" {
+		t.Errorf("SelectWithStats() returned different nodes than Select() (-want, +got): %s", diff)
+	}
+	want := Stats{
+		Visited: 7,
+		Matched: 2,
+		Stages: []StageStats{
+			{Selector: 0, Compound: "p", Evaluated: 7, Matched: 3},
+			{Selector: 0, Compound: "div.a", Combinator: ">", Evaluated: 3, Matched: 2},
+		},
+	}
+	opts := cmpopts.IgnoreFields(Stats{}, "Duration")
+	stageOpts := cmpopts.IgnoreFields(StageStats{}, "Duration")
+	if diff := cmp.Diff(want, stats, opts, stageOpts); diff != "" {
+		t.Errorf("SelectWithStats() returned diff (-want, +got): %s", diff)
+	}
+	if stats.Duration <= 0 {
+		t.Errorf("SelectWithStats() reported duration %s, want positive duration", stats.Duration)
+	}
+
+	var observed int
+	sel = MustParse("p", WithObserver(ObserverFunc(func(s *Stats) { observed++ })))
+	if _, stats := sel.SelectWithStats(root); stats.Matched != 3 {
+		t.Errorf("SelectWithStats() reported %d matches, want 3", stats.Matched)
+	}
+	if observed != 1 {
+		t.Errorf("observer called %d times by SelectWithStats(), want 1", observed)
+	}
+
+	nodes, stats = sel.SelectWithStats(nil)
+	if len(nodes) != 0 || stats.Visited != 0 || stats.Matched != 0 {
+		t.Errorf("SelectWithStats(nil) returned %d nodes and stats %+v", len(nodes), stats)
+	}
+}
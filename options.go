@@ -0,0 +1,188 @@
+package css
+
+// Option configures how Parse compiles a selector.
+type Option func(o *options)
+
+type options struct {
+	unknownPseudo UnknownPseudoPolicy
+	exclude       *Selector
+	strict        bool
+	selectors3    bool
+	aria          bool
+	extensions    bool
+	observer      Observer
+	logger        Logger
+	nfc           bool
+	caseSensitive bool
+	comparators   map[string]Comparator
+	combinators   map[string]Combinator
+	order         Order
+	interner      *Interner
+	report        *Report
+	elementState  ElementState
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// UnknownPseudoPolicy determines how Parse handles pseudo-classes that this
+// package doesn't support, such as ":hover".
+type UnknownPseudoPolicy int
+
+const (
+	// UnknownPseudoError causes Parse to return an error. This is the
+	// default.
+	UnknownPseudoError UnknownPseudoPolicy = iota
+	// UnknownPseudoNeverMatch compiles unknown pseudo-classes to match no
+	// elements, similar to how a browser treats a state that never applies.
+	UnknownPseudoNeverMatch
+	// UnknownPseudoAlwaysMatch compiles unknown pseudo-classes to match every
+	// element, effectively ignoring them. This is useful when scraping with
+	// selectors written for browsers.
+	UnknownPseudoAlwaysMatch
+)
+
+// WithUnknownPseudo sets the policy for handling unsupported pseudo-classes.
+// Pseudo-classes that are supported but used incorrectly, such as
+// ":nth-child(foo)", are always reported as errors.
+//
+// The policy also applies to unsupported pseudo-elements, such as ::before,
+// including the legacy single-colon forms :before, :after, :first-line and
+// :first-letter. Ignoring a pseudo-element with UnknownPseudoAlwaysMatch
+// selects its originating element, so "a::before" selects a elements.
+func WithUnknownPseudo(p UnknownPseudoPolicy) Option {
+	return func(o *options) {
+		o.unknownPseudo = p
+	}
+}
+
+// WithExclude causes selection to skip elements matching exclude, along with
+// all of their descendants. Excluded subtrees aren't traversed, which is
+// cheaper than filtering results afterwards. For example, to ignore
+// navigation and scripts:
+//
+//	sel, err := css.Parse("a[href]", css.WithExclude(css.MustParse("nav, footer, script")))
+//
+// Excluded elements can still be matched by combinators, such as "nav + p".
+func WithExclude(exclude *Selector) Option {
+	return func(o *options) {
+		o.exclude = exclude
+	}
+}
+
+// WithStrict causes Parse to return an error for problems that would
+// otherwise be reported through Selector.Warnings.
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithSelectors3 causes Parse to return an error for selectors that use
+// features not defined by Selectors Level 3, such as the column combinator,
+// the "i" attribute modifier, :is(), :where(), :has(), and the "of S" form of
+// :nth-child(). It's intended for selectors that must remain portable to
+// older engines. Features that aren't part of any Selectors specification,
+// such as ::part() or extensions, are also rejected. Use Features to list the
+// features a selector uses.
+func WithSelectors3() Option {
+	return func(o *options) {
+		o.selectors3 = true
+	}
+}
+
+// WithARIA causes pseudo-classes that describe the state of form controls to
+// also consider the equivalent ARIA attributes. For example, ":checked"
+// matches elements with aria-checked="true", and ":disabled" matches elements
+// with aria-disabled="true". This is useful for accessibility tooling, where
+// the effective state of an element matters more than its native attributes.
+//
+// https://www.w3.org/TR/wai-aria-1.2/#global_states
+func WithARIA() Option {
+	return func(o *options) {
+		o.aria = true
+	}
+}
+
+// WithExtensions enables selectors that aren't part of any CSS specification,
+// but are supported by other selector engines or are useful when processing
+// documents outside of a browser:
+//
+//	:role(name)         elements with the given explicit or implicit ARIA role
+//	:contains("text")   elements whose text, including descendants, contains text
+//	:header             heading elements, h1 through h6
+//	:input              input, textarea, select and button elements
+//	:button             button elements and inputs of type button
+//	:checkbox           inputs of type checkbox
+//	:radio              inputs of type radio
+//	:submit             submit buttons, including buttons without a type
+//	:hidden             elements that are hidden, judged from the document alone
+//	:visible            elements that aren't hidden
+//	[attr%="pattern"]   elements whose attribute value matches a regular expression
+//	a ^ b               b elements that contain an a element, the reverse of "b a"
+//	::first-letter      elements with a first letter, whose text is returned by SelectRanges
+//	::first-line        elements with text, whose approximate first line is returned by SelectRanges
+//
+// An element is hidden if it, or an ancestor, has the hidden attribute, is a
+// hidden input, is never rendered, such as a script or the head element, or
+// has "display: none" in its style attribute. An element is also hidden if
+// its style attribute, or the nearest ancestor's that sets the property,
+// declares "visibility: hidden". Stylesheets and layout aren't considered.
+//
+// Regular expressions use the syntax of package regexp, and are compiled once
+// by Parse. Like other attribute matchers, the "i" modifier makes the match
+// case-insensitive. Values are usually quoted, since patterns rarely form a
+// valid identifier. Building with the css_noregexp tag removes the dependency
+// on package regexp, and %= is always an error.
+//
+// Without this option, extension pseudo-classes are handled like any other
+// unsupported pseudo-class, and other extensions are an error.
+func WithExtensions() Option {
+	return func(o *options) {
+		o.extensions = true
+	}
+}
+
+// WithCaseSensitiveAttributes causes attribute names in selectors to be
+// compared case-sensitively against every element, for documents where names
+// aren't lowercased by the parser, such as XML. By default, names in
+// selectors like "[HREF]" are lowercased when matching HTML elements, since
+// the HTML parser lowercases attribute names, while foreign elements such as
+// SVG keep names like "viewBox" that are matched as written.
+func WithCaseSensitiveAttributes() Option {
+	return func(o *options) {
+		o.caseSensitive = true
+	}
+}
+
+// Logger receives messages logged by selectors created with WithLogger. Its
+// methods take a message followed by alternating keys and values, and are
+// satisfied by *slog.Logger.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+}
+
+// WithLogger logs decisions made while compiling the selector, such as the
+// order tests are evaluated in and any components that were ignored, along with
+// a summary of each selection. Messages are logged at the debug level, except
+// for warnings.
+func WithLogger(l Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithInterner causes Parse to intern the names and values held by the
+// selector using in, so selectors parsed with the same Interner share storage
+// for the names they have in common.
+func WithInterner(in *Interner) Option {
+	return func(o *options) {
+		o.interner = in
+	}
+}
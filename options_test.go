@@ -0,0 +1,288 @@
+package css
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestUnknownPseudoPolicy(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<a>1</a><a class="b">2</a>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	tests := []struct {
+		sel    string
+		policy UnknownPseudoPolicy
+		want   []string
+	}{
+		{"a:hover", UnknownPseudoNeverMatch, []string{}},
+		{"a:hover, .b", UnknownPseudoNeverMatch, []string{`<a class="b">2</a>`}},
+		{"a:hover", UnknownPseudoAlwaysMatch, []string{`<a>1</a>`, `<a class="b">2</a>`}},
+		{"a:unknown-func(foo)", UnknownPseudoAlwaysMatch, []string{`<a>1</a>`, `<a class="b">2</a>`}},
+		{"a:first-child", UnknownPseudoNeverMatch, []string{`<a>1</a>`}},
+		{"a::before", UnknownPseudoNeverMatch, []string{}},
+		{"a::before, .b", UnknownPseudoNeverMatch, []string{`<a class="b">2</a>`}},
+		{".b::after", UnknownPseudoAlwaysMatch, []string{`<a class="b">2</a>`}},
+		// Legacy pseudo-elements are pseudo-elements, not pseudo-classes.
+		{"a:before", UnknownPseudoNeverMatch, []string{}},
+		{".b:first-line", UnknownPseudoAlwaysMatch, []string{`<a class="b">2</a>`}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel, WithUnknownPseudo(test.policy))
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		got := renderNodes(t, s.Select(root))
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) with policy %d returned diff (-want, +got): %s", test.sel, test.policy, diff)
+		}
+	}
+
+	for _, sel := range []string{"a:hover", "a:nth-child(foo)"} {
+		if _, err := Parse(sel, WithUnknownPseudo(UnknownPseudoError)); err == nil {
+			t.Errorf("Parse(%q) didn't return an error", sel)
+		}
+	}
+	if _, err := Parse("a:nth-child(foo)", WithUnknownPseudo(UnknownPseudoAlwaysMatch)); err == nil {
+		t.Errorf("Parse() with invalid arguments to a known pseudo-class didn't return an error")
+	}
+}
+
+func TestExclude(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<nav><a>1</a><ul><li><a>2</a></li></ul></nav><p><a>3</a></p><footer><a>4</a></footer><a>5</a>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	exclude := MustParse("nav, footer")
+	sel := MustParse("a", WithExclude(exclude))
+
+	want := []string{`<a>3</a>`, `<a>5</a>`}
+	if diff := cmp.Diff(want, renderNodes(t, sel.Select(root))); diff != "" {
+		t.Errorf("Select() returned diff (-want, +got): %s", diff)
+	}
+	if got := sel.SelectLast(root); got == nil || got.FirstChild.Data != "5" {
+		t.Errorf("SelectLast() returned %v, want <a>5</a>", got)
+	}
+
+	// Excluded elements may still be matched by combinators.
+	sel = MustParse("nav + p > a", WithExclude(exclude))
+	if diff := cmp.Diff([]string{`<a>3</a>`}, renderNodes(t, sel.Select(root))); diff != "" {
+		t.Errorf("Select() returned diff (-want, +got): %s", diff)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		sel  string
+		opts []Option
+		want []Warning
+	}{
+		{"a", nil, nil},
+		{"svg|rect, [xlink|href]", nil, nil},
+		{"foo|a", nil, []Warning{{0, `namespace prefix "foo" unresolved and will never match`}}},
+		{"a[foo|href]", nil, []Warning{{1, `namespace prefix "foo" unresolved and will never match`}}},
+		{
+			"a:hover",
+			[]Option{WithUnknownPseudo(UnknownPseudoAlwaysMatch)},
+			[]Warning{{1, "unsupported pseudo-class selector ignored: hover"}},
+		},
+		{
+			"a:hover",
+			[]Option{WithUnknownPseudo(UnknownPseudoNeverMatch)},
+			[]Warning{{1, "unsupported pseudo-class selector never matches: hover"}},
+		},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel, test.opts...)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		if diff := cmp.Diff(test.want, s.Warnings()); diff != "" {
+			t.Errorf("Parse(%q) returned warnings diff (-want, +got): %s", test.sel, diff)
+		}
+		if len(test.want) == 0 {
+			continue
+		}
+		opts := append(test.opts, WithStrict())
+		if _, err := Parse(test.sel, opts...); err == nil {
+			t.Errorf("Parse(%q) with WithStrict() didn't return an error", test.sel)
+		}
+	}
+}
+
+func TestARIA(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<input type="checkbox" checked>
+<div role="checkbox" aria-checked="true"></div>
+<div role="checkbox" aria-checked="false"></div>
+<button aria-disabled="true"></button>
+<button></button>
+<div role="button" aria-disabled="TRUE"></div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{":checked", []string{
+			`<input type="checkbox" checked=""/>`,
+			`<div role="checkbox" aria-checked="true"></div>`,
+		}},
+		{":disabled", []string{
+			`<button aria-disabled="true"></button>`,
+			`<div role="button" aria-disabled="TRUE"></div>`,
+		}},
+		{":enabled", []string{
+			`<input type="checkbox" checked=""/>`,
+			`<button></button>`,
+		}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel, WithARIA())
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		got := renderNodes(t, s.Select(root))
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) with WithARIA() returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+}
+
+func TestNFC(t *testing.T) {
+	// "caf\u00e9" is NFC, "cafe\u0301" is NFD.
+	root, err := html.Parse(strings.NewReader(
+		"<p id=\"cafe\u0301\">1</p>" +
+			"<p class=\"x cafe\u0301\">2</p>" +
+			"<p title=\"caf\u00e9 menu\">3</p>" +
+			"<p title=\"CAFE\u0301\">4</p>"))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{"#caf\u00e9", []string{"<p id=\"cafe\u0301\">1</p>"}},
+		{".caf\u00e9", []string{"<p class=\"x cafe\u0301\">2</p>"}},
+		{"[title^=\"cafe\u0301\"]", []string{"<p title=\"caf\u00e9 menu\">3</p>"}},
+		{"[title=\"caf\u00e9\" i]", []string{"<p title=\"CAFE\u0301\">4</p>"}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		if got := s.Select(root); len(got) != 0 {
+			t.Errorf("Parse(%q) without WithNFC() matched %d elements, want 0", test.sel, len(got))
+		}
+
+		s, err = Parse(test.sel, WithNFC())
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		got := renderNodes(t, s.Select(root))
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) with WithNFC() returned diff (-want, +got): %s", test.sel, diff)
+		}
+		got = renderNodes(t, NewSession(root).Select(s))
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Session.Select(%q) with WithNFC() returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+}
+
+func TestCaseSensitiveAttributes(t *testing.T) {
+	// Trees built by hand, such as from an XML decoder, may have attribute
+	// names that aren't lowercased.
+	root := &html.Node{Type: html.DocumentNode}
+	for _, key := range []string{"dataFoo", "datafoo"} {
+		root.AppendChild(&html.Node{
+			Type: html.ElementNode,
+			Data: "item",
+			Attr: []html.Attribute{{Key: key, Val: key}},
+		})
+	}
+
+	tests := []struct {
+		sel  string
+		opts []Option
+		want []string
+	}{
+		{"[dataFoo]", nil, []string{`<item datafoo="datafoo"></item>`}},
+		{"[dataFoo]", []Option{WithCaseSensitiveAttributes()}, []string{`<item dataFoo="dataFoo"></item>`}},
+		{"[datafoo]", []Option{WithCaseSensitiveAttributes()}, []string{`<item datafoo="datafoo"></item>`}},
+		{"[DATAFOO=datafoo i]", []Option{WithCaseSensitiveAttributes()}, []string{`<item dataFoo="dataFoo"></item>`, `<item datafoo="datafoo"></item>`}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel, test.opts...)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		got := renderNodes(t, s.Select(root))
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+
+	// The HTML parser preserves the case of attribute names on SVG elements,
+	// so they're matched as written even by default.
+	doc, err := html.Parse(strings.NewReader(`<svg viewBox="0 0 1 1"></svg><div viewbox></div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	want := []string{`<svg viewBox="0 0 1 1"></svg>`, `<div viewbox=""></div>`}
+	got := renderNodes(t, MustParse("[viewBox], [VIEWBOX]").Select(doc))
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Selecting SVG attributes returned diff (-want, +got): %s", diff)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<a id="x"></a><a></a>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	var b bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	s, err := Parse("a#x, a:hover", WithLogger(l), WithUnknownPseudo(UnknownPseudoNeverMatch))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	s.Select(root)
+
+	want := []string{
+		`level=DEBUG msg="css: compiled selector" selector=a#x match=a#x`,
+		`level=WARN msg="css: unsupported pseudo-class selector never matches: hover" pos=6`,
+		`level=DEBUG msg="css: compiled selector" selector=a:hover match=a:hover`,
+		`level=DEBUG msg="css: selected" selector="a#x, a:hover" visited=5 matched=1`,
+	}
+	got := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("WithLogger() logged diff (-want, +got): %s", diff)
+	}
+}
@@ -0,0 +1,188 @@
+package css
+
+import (
+	"sort"
+
+	"golang.org/x/net/html"
+)
+
+// Order determines the order of the elements returned by a selection.
+type Order int
+
+const (
+	// DocumentOrder returns elements in document order, the order their
+	// start tags appear in the document, with ancestors before their
+	// descendants. This is the default.
+	DocumentOrder Order = iota
+	// PostOrder returns descendants before their ancestors, and otherwise
+	// orders elements in document order. It's useful for passes that modify
+	// or remove matched elements, since processing an element doesn't
+	// detach elements that are yet to be processed.
+	PostOrder
+	// MatchOrder returns elements in the order they were matched, avoiding
+	// the cost of sorting. It's the same as DocumentOrder, except for
+	// selectors with pseudo-elements, which can represent elements anywhere
+	// in the tree.
+	MatchOrder
+)
+
+// WithOrder sets the order of the elements returned by Select,
+// SelectWithContext, and Session.Select.
+func WithOrder(o Order) Option {
+	return func(opts *options) {
+		opts.order = o
+	}
+}
+
+// sortNodes sorts nodes in document order. The contents of shadow trees are
+// ordered after their host, but before the host's children.
+func (s *state) sortNodes(nodes []*html.Node) {
+	s.sortNodesBy(nodes, comparePaths)
+}
+
+// sortNodesPostOrder sorts nodes in post-order, where descendants are ordered
+// before their ancestors.
+func (s *state) sortNodesPostOrder(nodes []*html.Node) {
+	s.sortNodesBy(nodes, comparePostOrderPaths)
+}
+
+func (s *state) sortNodesBy(nodes []*html.Node, cmp func(a, b []int) int) {
+	paths := make(map[*html.Node][]int, len(nodes))
+	for _, n := range nodes {
+		paths[n] = s.path(n)
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return cmp(paths[nodes[i]], paths[nodes[j]]) < 0
+	})
+}
+
+// path returns the position of n in its tree as a list of child indexes,
+// starting from the top of the tree. Shadow roots are given an index of -1
+// within their host.
+func (s *state) path(n *html.Node) []int {
+	var path []int
+	for n != nil {
+		p := n.Parent
+		if p == nil {
+			host, ok := s.hosts[n]
+			if !ok {
+				break
+			}
+			path = append(path, -1)
+			n = host
+			continue
+		}
+		i := 0
+		for c := p.FirstChild; c != n; c = c.NextSibling {
+			i++
+		}
+		path = append(path, i)
+		n = p
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// comparePaths compares two paths returned by path, returning a negative
+// number if a is ordered before b, and a positive number if it's after.
+func comparePaths(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+	return len(a) - len(b)
+}
+
+// comparePostOrderPaths is like comparePaths, but orders descendants before
+// their ancestors.
+func comparePostOrderPaths(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+	return len(b) - len(a)
+}
+
+// CompareDocumentPosition compares the position of two nodes in a document,
+// returning a negative number if a comes before b in document order, a
+// positive number if a comes after b, and zero if they're the same node.
+// Ancestors come before their descendants.
+//
+// The result is unspecified if a and b aren't part of the same tree.
+func CompareDocumentPosition(a, b *html.Node) int {
+	if a == b {
+		return 0
+	}
+	s := &state{}
+	if c := comparePaths(s.path(a), s.path(b)); c < 0 {
+		return -1
+	}
+	return 1
+}
+
+// SortNodes sorts nodes in document order and removes duplicates, returning
+// the shortened slice. This is the same ordering used for the results of
+// Select, and can be used to merge the results of multiple selectors.
+//
+// The order of nodes from different trees is unspecified.
+func SortNodes(nodes []*html.Node) []*html.Node {
+	s := &state{}
+	s.sortNodes(nodes)
+	out := nodes[:0]
+	for i, n := range nodes {
+		if i > 0 && n == nodes[i-1] {
+			continue
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// CommonAncestor returns the deepest node that's an ancestor of, or the same
+// as, every node in nodes, such as the container enclosing a set of matches.
+// It returns nil if nodes is empty, holds a nil node, or spans different
+// trees.
+func CommonAncestor(nodes []*html.Node) *html.Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	common := nodes[0]
+	for _, n := range nodes[1:] {
+		if common == nil {
+			break
+		}
+		common = commonAncestor(common, n)
+	}
+	return common
+}
+
+// commonAncestor returns the deepest inclusive ancestor shared by a and b.
+func commonAncestor(a, b *html.Node) *html.Node {
+	if a == nil || b == nil {
+		return nil
+	}
+	da, db := depth(a), depth(b)
+	for ; da > db; da-- {
+		a = a.Parent
+	}
+	for ; db > da; db-- {
+		b = b.Parent
+	}
+	for a != b {
+		a, b = a.Parent, b.Parent
+	}
+	return a
+}
+
+// depth returns the number of ancestors of n.
+func depth(n *html.Node) int {
+	d := 0
+	for p := n.Parent; p != nil; p = p.Parent {
+		d++
+	}
+	return d
+}
@@ -0,0 +1,143 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestSortNodes(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<div id="a"><p id="b"><span id="c"></span></p><p id="d"></p></div><div id="e"></div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	byID := func(id string) *html.Node {
+		return MustParse("#" + id).Select(root)[0]
+	}
+	a, b, c, d, e := byID("a"), byID("b"), byID("c"), byID("d"), byID("e")
+
+	tests := []struct {
+		a, b *html.Node
+		want int
+	}{
+		{a, a, 0},
+		{a, b, -1},
+		{b, a, 1},
+		{c, d, -1},
+		{d, c, 1},
+		{e, c, 1},
+	}
+	for _, test := range tests {
+		if got := CompareDocumentPosition(test.a, test.b); got != test.want {
+			t.Errorf("CompareDocumentPosition(%s, %s) = %d, want %d", renderNodes(t, []*html.Node{test.a}), renderNodes(t, []*html.Node{test.b}), got, test.want)
+		}
+	}
+
+	got := SortNodes([]*html.Node{e, c, a, d, c, b, e})
+	want := []*html.Node{a, b, c, d, e}
+	if diff := cmp.Diff(renderNodes(t, want), renderNodes(t, got)); diff != "" {
+		t.Errorf("SortNodes() returned diff (-want, +got): %s", diff)
+	}
+}
+
+func TestOrder(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<div id="a"><p id="b"><span id="c"></span></p><p id="d"></p></div><div id="e"></div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	ids := func(nodes []*html.Node) []string {
+		var ids []string
+		for _, n := range nodes {
+			id, _ := attr(n, "id")
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		order Order
+		want  []string
+	}{
+		{DocumentOrder, []string{"a", "b", "c", "d", "e"}},
+		{PostOrder, []string{"c", "b", "d", "a", "e"}},
+		{MatchOrder, []string{"a", "b", "c", "d", "e"}},
+	}
+	for _, test := range tests {
+		s := MustParse("div, p, span", WithOrder(test.order))
+		if diff := cmp.Diff(test.want, ids(s.Select(root))); diff != "" {
+			t.Errorf("Select() with order %d returned diff (-want, +got): %s", test.order, diff)
+		}
+		if diff := cmp.Diff(test.want, ids(NewSession(root).Select(s))); diff != "" {
+			t.Errorf("Session.Select() with order %d returned diff (-want, +got): %s", test.order, diff)
+		}
+	}
+
+	// Pseudo-elements are returned as they're matched, rather than sorted.
+	doc, err := html.Parse(strings.NewReader(`<x-host></x-host>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	host := MustParse("x-host").Select(doc)[0]
+	ctx := MatchContext{ShadowRoots: map[*html.Node]*html.Node{
+		host: parseShadowTree(t, `<i id="a" part="a"><b id="b" part="b"></b></i>`),
+	}}
+	tests = []struct {
+		order Order
+		want  []string
+	}{
+		{DocumentOrder, []string{"a", "b"}},
+		{PostOrder, []string{"b", "a"}},
+		{MatchOrder, []string{"b", "a"}},
+	}
+	for _, test := range tests {
+		s := MustParse("::part(b), ::part(a)", WithOrder(test.order))
+		if diff := cmp.Diff(test.want, ids(s.SelectWithContext(doc, ctx))); diff != "" {
+			t.Errorf("SelectWithContext() with order %d returned diff (-want, +got): %s", test.order, diff)
+		}
+	}
+}
+
+func TestCommonAncestor(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<div id="a"><p id="b"><span id="c"></span></p><p id="d"></p></div><div id="e"></div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	byID := func(id string) *html.Node {
+		return MustParse("#" + id).Select(root)[0]
+	}
+	a, b, c, d, e := byID("a"), byID("b"), byID("c"), byID("d"), byID("e")
+	body := e.Parent
+	other, err := html.Parse(strings.NewReader(`<p></p>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		nodes []*html.Node
+		want  *html.Node
+	}{
+		{"Empty", nil, nil},
+		{"Single", []*html.Node{c}, c},
+		{"Siblings", []*html.Node{b, d}, a},
+		{"Cousins", []*html.Node{c, d}, a},
+		{"Ancestor", []*html.Node{c, b}, b},
+		{"Many", []*html.Node{c, d, e}, body},
+		{"Document", []*html.Node{c, root}, root},
+		{"Nil", []*html.Node{c, nil}, nil},
+		{"DifferentTrees", []*html.Node{c, other}, nil},
+	}
+	describe := func(n *html.Node) string {
+		if n == nil {
+			return "nil"
+		}
+		return describeNode(n)
+	}
+	for _, test := range tests {
+		if got := CommonAncestor(test.nodes); got != test.want {
+			t.Errorf("%s: CommonAncestor() returned %s, want %s", test.name, describe(got), describe(test.want))
+		}
+	}
+}
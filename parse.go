@@ -25,6 +25,13 @@ type parser struct {
 	// err is set whenever a lex error occurs. When set, all subsequent calls to
 	// next(), peek(), and peekN() will fail.
 	err error
+	// end is the offset immediately after the last non-whitespace token
+	// consumed, and is used to record where each component ends.
+	end int
+	// arena, if non-nil, allocates the complex selectors of the AST.
+	arena *arena
+	// interner, if non-nil, interns identifiers and values held by the AST.
+	interner *Interner
 }
 
 type tokens struct {
@@ -53,7 +60,21 @@ func newParserFromTokens(t []token) *parser {
 }
 
 func newParser(s string) *parser {
-	return &parser{l: newLexer(s), peekQueue: newQueue(2)}
+	return &parser{l: newLexer(s), peekQueue: newQueue(2), arena: &arena{}}
+}
+
+// reset clears the parser's state after its lexer has been reset, so it can
+// parse another selector. The arena and interner are kept.
+func (p *parser) reset() {
+	p.peekQueue.reset()
+	p.err = nil
+	p.end = 0
+}
+
+// intern returns s, or an equal string shared with previous parses if the
+// parser interns strings.
+func (p *parser) intern(s string) string {
+	return p.interner.intern(s)
 }
 
 func (p *parser) peek() (token, error) {
@@ -80,16 +101,25 @@ func (p *parser) next() (token, error) {
 		return token{}, p.err
 	}
 	if p.peekQueue.len() > 0 {
-		return p.peekQueue.pop(), nil
+		t := p.peekQueue.pop()
+		p.consumed(t)
+		return t, nil
 	}
 	t, err := p.l.next()
 	if err != nil {
 		p.err = err
 		return t, err
 	}
+	p.consumed(t)
 	return t, nil
 }
 
+func (p *parser) consumed(t token) {
+	if t.typ != tokenWhitespace && t.typ != tokenEOF {
+		p.end = t.pos + len(t.raw)
+	}
+}
+
 func (p *parser) errorf(t token, msg string, v ...interface{}) error {
 	return &parseErr{fmt.Sprintf(msg, v...), t}
 }
@@ -98,11 +128,10 @@ func (p *parser) parse() ([]complexSelector, error) {
 	var sels []complexSelector
 	p.skipWhitespace()
 	for {
-		cs, err := p.complexSelector()
-		if err != nil {
+		sels = append(sels, complexSelector{})
+		if err := p.complexSelectorInto(&sels[len(sels)-1]); err != nil {
 			return nil, err
 		}
-		sels = append(sels, *cs)
 		p.skipWhitespace()
 		t, err := p.next()
 		if err != nil {
@@ -120,21 +149,33 @@ func (p *parser) parse() ([]complexSelector, error) {
 
 type complexSelector struct {
 	pos        int
+	end        int
 	sel        compoundSelector
 	combinator string
 	next       *complexSelector
 }
 
 func (p *parser) complexSelector() (*complexSelector, error) {
+	sel := p.arena.newComplexSelector()
+	if err := p.complexSelectorInto(sel); err != nil {
+		return nil, err
+	}
+	return sel, nil
+}
+
+// complexSelectorInto is like complexSelector, but parses into sel, which
+// must be the zero value. Only the compound selectors following sel are
+// allocated.
+func (p *parser) complexSelectorInto(sel *complexSelector) error {
 	t, err := p.peek() // peek the first token for creating errors.
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	sel := &complexSelector{pos: t.pos}
-	cs, ok, err := p.compoundSelector()
+	sel.pos = t.pos
+	ok, err := p.compoundSelectorInto(&sel.sel)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if !ok {
 		//  <compound-selector> can start with:
@@ -147,30 +188,50 @@ func (p *parser) complexSelector() (*complexSelector, error) {
 		//  | |-- <attribute-selector> = '[' ...
 		//  | \-- <pseudo-class-selector> = ':' ...
 		//  \-- <pseudo-element-selector> = ':' ...
-		return nil, p.errorf(t, "expected identifier, '#', '*', '.', '|', '[', ':'")
+		return p.errorf(t, "expected identifier, '#', '*', '.', '|', '[', ':'")
 	}
-	sel.sel = *cs
 
 	last := sel
 	for {
 		p.skipWhitespace()
 		t, err = p.peek()
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if t.typ == tokenDelim {
 			switch t.s {
-			case ">", "+", "~":
+			case ">", "+", "~", "^":
 				p.next()
 				p.skipWhitespace()
 				last.combinator = t.s
 				if t, err = p.peek(); err != nil {
-					return nil, err
+					return err
+				}
+			case "/":
+				// Non-standard custom combinator, such as "/for/".
+				p.next()
+				name, err := p.next()
+				if err != nil {
+					return err
+				}
+				if name.typ != tokenIdent {
+					return p.errorf(name, "expected combinator name")
+				}
+				if t, err = p.next(); err != nil {
+					return err
+				}
+				if !t.isDelim("/") {
+					return p.errorf(t, "expected '/'")
+				}
+				p.skipWhitespace()
+				last.combinator = "/" + name.s + "/"
+				if t, err = p.peek(); err != nil {
+					return err
 				}
 			case "|":
 				t, err = p.peekN(1)
 				if err != nil {
-					return nil, err
+					return err
 				}
 				if t.isDelim("|") {
 					p.next()
@@ -178,22 +239,27 @@ func (p *parser) complexSelector() (*complexSelector, error) {
 					p.skipWhitespace()
 					last.combinator = "||"
 					if t, err = p.peek(); err != nil {
-						return nil, err
+						return err
 					}
 				}
 			}
 		}
-		s, ok, err := p.compoundSelector()
+		next := p.arena.newComplexSelector()
+		ok, err := p.compoundSelectorInto(&next.sel)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if !ok {
+			p.arena.free(next)
 			if last.combinator != "" {
-				return nil, p.errorf(t, "expected identifier, '#', '*', '.', '|', '[', ':'")
+				return p.errorf(t, "expected identifier, '#', '*', '.', '|', '[', ':'")
+			}
+			for curr := sel; curr != nil; curr = curr.next {
+				curr.end = p.end
 			}
-			return sel, nil
+			return nil
 		}
-		next := &complexSelector{pos: s.pos, sel: *s}
+		next.pos = next.sel.pos
 		last.next = next
 		last = next
 	}
@@ -201,6 +267,7 @@ func (p *parser) complexSelector() (*complexSelector, error) {
 
 type compoundSelector struct {
 	pos             int
+	end             int
 	typeSelector    *typeSelector // may be nil
 	subClasses      []subclassSelector
 	pseudoSelectors []pseudoSelector
@@ -211,15 +278,27 @@ type compoundSelector struct {
 //
 // Whitespace is disallowed between top level elements.
 func (p *parser) compoundSelector() (*compoundSelector, bool, error) {
+	cs := &compoundSelector{}
+	ok, err := p.compoundSelectorInto(cs)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	return cs, true, nil
+}
+
+// compoundSelectorInto is like compoundSelector, but parses into cs, which
+// must be the zero value, so complex selectors can hold their compound
+// selectors without a separate allocation.
+func (p *parser) compoundSelectorInto(cs *compoundSelector) (bool, error) {
 	t, err := p.peek()
 	if err != nil {
-		return nil, false, err
+		return false, err
 	}
 	found := false
-	cs := &compoundSelector{pos: t.pos}
+	cs.pos = t.pos
 	ts, ok, err := p.typeSelector()
 	if err != nil {
-		return nil, false, err
+		return false, err
 	}
 	if ok {
 		found = true
@@ -228,7 +307,7 @@ func (p *parser) compoundSelector() (*compoundSelector, bool, error) {
 	for {
 		sc, ok, err := p.subclassSelector()
 		if err != nil {
-			return nil, false, err
+			return false, err
 		}
 		if !ok {
 			break
@@ -239,7 +318,7 @@ func (p *parser) compoundSelector() (*compoundSelector, bool, error) {
 	for {
 		ps, ok, err := p.pseudoSelector()
 		if err != nil {
-			return nil, false, err
+			return false, err
 		}
 		if !ok {
 			break
@@ -248,14 +327,21 @@ func (p *parser) compoundSelector() (*compoundSelector, bool, error) {
 		cs.pseudoSelectors = append(cs.pseudoSelectors, *ps)
 	}
 	if !found {
-		return nil, false, nil
+		return false, nil
 	}
-	return cs, true, nil
+	cs.end = p.end
+	return true, nil
 }
 
 type pseudoSelector struct {
+	// pos is the offset of the first ':', while element records the position
+	// of the second.
+	pos     int
 	element pseudoClassSelector
 	classes []pseudoClassSelector
+	// end is the offset after the pseudo-element and any pseudo-classes
+	// following it.
+	end int
 }
 
 // Implements a subset of the <compound-selector> logic.
@@ -269,20 +355,25 @@ func (p *parser) pseudoSelector() (*pseudoSelector, bool, error) {
 	if t.typ != tokenColon {
 		return nil, false, nil
 	}
+	pos := t.pos
 	t, err = p.peekN(1)
 	if err != nil {
 		return nil, false, err
 	}
-	if t.typ != tokenColon {
+	switch {
+	case t.typ == tokenColon:
+		p.next()
+	case isLegacyPseudoElement(t):
+		// Parsed with a single colon, like a pseudo-class.
+	default:
 		return nil, false, nil
 	}
-	p.next()
 
 	ele, err := p.pseudoClassSelector()
 	if err != nil {
 		return nil, false, err
 	}
-	ps := &pseudoSelector{element: *ele}
+	ps := &pseudoSelector{pos: pos, element: *ele}
 	for {
 		p.skipWhitespace()
 		t, err := p.peek()
@@ -290,6 +381,7 @@ func (p *parser) pseudoSelector() (*pseudoSelector, bool, error) {
 			return nil, false, err
 		}
 		if t.typ != tokenColon {
+			ps.end = p.end
 			return ps, true, nil
 		}
 		cs, err := p.pseudoClassSelector()
@@ -302,11 +394,29 @@ func (p *parser) pseudoSelector() (*pseudoSelector, bool, error) {
 
 type typeSelector struct {
 	pos       int
+	end       int
 	hasPrefix bool
 	prefix    string
 	value     string
 }
 
+// legacyPseudoElements may be written with a single colon, for compatibility
+// with CSS 2.
+//
+// https://www.w3.org/TR/selectors-4/#pseudo-element-syntax
+var legacyPseudoElements = map[string]bool{
+	"after":        true,
+	"before":       true,
+	"first-letter": true,
+	"first-line":   true,
+}
+
+// isLegacyPseudoElement reports if t is the name of a pseudo-element that can
+// be written with a single colon.
+func isLegacyPseudoElement(t token) bool {
+	return t.typ == tokenIdent && legacyPseudoElements[strings.ToLower(t.s)]
+}
+
 // <type-selector> = <wq-name> | <ns-prefix>? '*'
 // <wq-name> = <ns-prefix>? <ident-token>
 // <ns-prefix> = [ <ident-token> | '*' ]? '|'
@@ -327,6 +437,7 @@ func (p *parser) typeSelector() (*typeSelector, bool, error) {
 	}
 	return &typeSelector{
 		pos:       t.pos,
+		end:       p.end,
 		hasPrefix: name.hasPrefix,
 		prefix:    name.prefix,
 		value:     name.value,
@@ -335,6 +446,7 @@ func (p *parser) typeSelector() (*typeSelector, bool, error) {
 
 type subclassSelector struct {
 	pos                 int
+	end                 int
 	idSelector          string
 	classSelector       string
 	attributeSelector   *attributeSelector
@@ -353,7 +465,8 @@ func (p *parser) subclassSelector() (*subclassSelector, bool, error) {
 	// <id-selector> = <hash-token>
 	if t.typ == tokenHash {
 		p.next()
-		ss.idSelector = strings.TrimPrefix(t.s, "#")
+		ss.idSelector = p.intern(strings.TrimPrefix(t.s, "#"))
+		ss.end = p.end
 		return ss, true, nil
 	}
 
@@ -367,7 +480,8 @@ func (p *parser) subclassSelector() (*subclassSelector, bool, error) {
 		if t.typ != tokenIdent {
 			return nil, false, p.errorf(t, "expected identifier")
 		}
-		ss.classSelector = strings.TrimPrefix(t.s, ".")
+		ss.classSelector = p.intern(t.s)
+		ss.end = p.end
 		return ss, true, nil
 	}
 
@@ -378,6 +492,7 @@ func (p *parser) subclassSelector() (*subclassSelector, bool, error) {
 			return nil, false, err
 		}
 		ss.attributeSelector = a
+		ss.end = p.end
 		return ss, true, nil
 	}
 
@@ -396,7 +511,7 @@ func (p *parser) subclassSelector() (*subclassSelector, bool, error) {
 	if err != nil {
 		return nil, false, err
 	}
-	if pt.typ == tokenColon {
+	if pt.typ == tokenColon || isLegacyPseudoElement(pt) {
 		// Found a <pseudo-element-selector>.
 		return nil, false, nil
 	}
@@ -405,11 +520,13 @@ func (p *parser) subclassSelector() (*subclassSelector, bool, error) {
 		return nil, false, err
 	}
 	ss.pseudoClassSelector = pcs
+	ss.end = p.end
 	return ss, true, nil
 }
 
 type pseudoClassSelector struct {
 	pos      int
+	end      int
 	ident    string
 	function string
 	args     []token
@@ -431,7 +548,7 @@ func (p *parser) pseudoClassSelector() (*pseudoClassSelector, error) {
 		return nil, err
 	}
 	if t.typ == tokenIdent {
-		return &pseudoClassSelector{pos: pos, ident: t.s}, nil
+		return &pseudoClassSelector{pos: pos, end: p.end, ident: p.intern(t.s)}, nil
 	}
 	if t.typ != tokenFunction {
 		return nil, p.errorf(t, "expected identifier or function")
@@ -449,7 +566,124 @@ func (p *parser) pseudoClassSelector() (*pseudoClassSelector, error) {
 	if c.typ != tokenParenClose {
 		return nil, p.errorf(t, "expected ')'")
 	}
-	return &pseudoClassSelector{pos: pos, function: t.s, args: args}, nil
+	return &pseudoClassSelector{pos: pos, end: p.end, function: p.intern(t.s), args: args}, nil
+}
+
+// relativeSelector is a complex selector anchored to an element by a leading
+// combinator, such as "> img" in "a:has(> img)". The combinator is empty for
+// the descendant combinator.
+//
+// https://www.w3.org/TR/selectors-4/#relative
+type relativeSelector struct {
+	combinator string
+	sel        complexSelector
+}
+
+// splitArgs splits the arguments of a functional pseudo-class at commas that
+// aren't nested within a function or block.
+func splitArgs(args []token) [][]token {
+	var (
+		parts [][]token
+		depth int
+		start int
+	)
+	for i, t := range args {
+		switch t.typ {
+		case tokenFunction, tokenParenOpen, tokenBracketOpen, tokenCurlyOpen:
+			depth++
+		case tokenParenClose, tokenBracketClose, tokenCurlyClose:
+			depth--
+		case tokenComma:
+			if depth == 0 {
+				parts = append(parts, args[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, args[start:])
+}
+
+// parseSelectorListArg parses the argument of a functional pseudo-class that
+// accepts a selector list.
+//
+// :is() and :where() take a forgiving selector list, where a selector that
+// fails to parse is dropped instead of invalidating the list, so
+// ":is(a, !, b)" is equivalent to ":is(a, b)" and ":is()" matches nothing.
+// Errors for dropped selectors are returned alongside the list. :not() takes
+// a strict list, where any invalid selector is an error.
+//
+// https://www.w3.org/TR/selectors-4/#typedef-forgiving-selector-list
+func parseSelectorListArg(args []token, forgiving bool) ([]complexSelector, []error, error) {
+	if forgiving && onlyWhitespace(args) {
+		return nil, nil, nil
+	}
+	var (
+		sels    []complexSelector
+		dropped []error
+	)
+	for _, part := range splitArgs(args) {
+		cs, err := parseComplexArg(newParserFromTokens(part))
+		if err != nil {
+			if !forgiving {
+				return nil, nil, err
+			}
+			dropped = append(dropped, err)
+			continue
+		}
+		sels = append(sels, *cs)
+	}
+	return sels, dropped, nil
+}
+
+// onlyWhitespace reports if a list of tokens is empty or only whitespace.
+func onlyWhitespace(tokens []token) bool {
+	for _, t := range tokens {
+		if t.typ != tokenWhitespace {
+			return false
+		}
+	}
+	return true
+}
+
+// parseRelativeSelectorListArg parses the argument of :has(), a strict list
+// of relative selectors.
+//
+// https://www.w3.org/TR/selectors-4/#typedef-relative-selector-list
+func parseRelativeSelectorListArg(args []token) ([]relativeSelector, error) {
+	var sels []relativeSelector
+	for _, part := range splitArgs(args) {
+		p := newParserFromTokens(part)
+		p.skipWhitespace()
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		var combinator string
+		if t.isDelim(">") || t.isDelim("+") || t.isDelim("~") {
+			p.next()
+			combinator = t.s
+		}
+		cs, err := parseComplexArg(p)
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, relativeSelector{combinator, *cs})
+	}
+	return sels, nil
+}
+
+// parseComplexArg parses a single complex selector that must consume the
+// rest of p.
+func parseComplexArg(p *parser) (*complexSelector, error) {
+	p.skipWhitespace()
+	cs, err := p.complexSelector()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectWhitespaceOrEOF(); err != nil {
+		return nil, err
+	}
+	return cs, nil
 }
 
 // https://drafts.csswg.org/css-syntax-3/#typedef-any-value
@@ -480,7 +714,7 @@ func (p *parser) any(until tokenType) ([]token, error) {
 			wantClosing = append(wantClosing, tokenBracketClose)
 		case tokenCurlyOpen:
 			wantClosing = append(wantClosing, tokenCurlyClose)
-		case tokenParenOpen:
+		case tokenParenOpen, tokenFunction:
 			wantClosing = append(wantClosing, tokenParenClose)
 		case tokenBracketClose, tokenCurlyClose, tokenParenClose:
 			if len(wantClosing) == 0 || wantClosing[len(wantClosing)-1] != t.typ {
@@ -517,15 +751,18 @@ func (p *parser) expectWhitespaceOrEOF() error {
 }
 
 // <attribute-selector> = '[' <wq-name> ']' |
-//                        '[' <wq-name> <attr-matcher> [ <string-token> | <ident-token> ] <attr-modifier>? ']'
-// <attr-matcher> = [ '~' | '|' | '^' | '$' | '*' ]? '='
+//                        '[' <wq-name> <attr-matcher> [ <string-token> | <ident-token> ] <attr-modifier>? ']' |
+//                        '[' <wq-name> <attr-comparator> <attr-modifier>? ']'
+// <attr-matcher> = [ '~' | '|' | '^' | '$' | '*' | '%' ]? '='
 // <attr-modifier> = i
+// <attr-comparator> = ':' <function-token> <any-value>? ')'
 // <wq-name> = <ns-prefix>? <ident-token>
 // <ns-prefix> = [ <ident-token> | '*' ]? '|'
 //
 // https://www.w3.org/TR/selectors-4/#typedef-attribute-selector
 type attributeSelector struct {
 	pos      int
+	end      int
 	wqName   *wqName
 	matcher  string
 	val      string
@@ -558,17 +795,67 @@ func (p *parser) attributeSelector() (*attributeSelector, error) {
 	}
 	if t.typ == tokenBracketClose {
 		// Found ']', we're done.
+		at.end = p.end
 		return at, nil
 	}
 
-	// <attr-matcher> = [ '~' | '|' | '^' | '$' | '*' ]? '='
+	if t.typ == tokenColon {
+		// Non-standard named comparator, such as "[data-price:gt(100)]". The
+		// arguments are kept as written for the comparator to interpret.
+		fn, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if fn.typ != tokenFunction {
+			return nil, p.errorf(fn, "expected comparator function")
+		}
+		args, err := p.any(tokenParenClose)
+		if err != nil {
+			return nil, err
+		}
+		if t, err = p.next(); err != nil {
+			return nil, err
+		}
+		if t.typ != tokenParenClose {
+			return nil, p.errorf(t, "expected ')'")
+		}
+		var raw strings.Builder
+		for _, a := range args {
+			raw.WriteString(a.raw)
+		}
+		at.matcher = ":" + strings.TrimSuffix(fn.s, "(")
+		at.val = strings.TrimSpace(raw.String())
+
+		p.skipWhitespace()
+		if t, err = p.next(); err != nil {
+			return nil, err
+		}
+		if t.s == "i" {
+			at.modifier = true
+			p.skipWhitespace()
+
+			if t, err = p.next(); err != nil {
+				return nil, err
+			}
+		}
+		if t.typ != tokenBracketClose {
+			return nil, p.errorf(t, "expected ']'")
+		}
+		at.end = p.end
+		return at, nil
+	}
+
+	// <attr-matcher> = [ '~' | '|' | '^' | '$' | '*' | '%' ]? '='
+	//
+	// '%=' is a non-standard regular expression matcher, which is only
+	// compiled when extensions are enabled.
 	if t.typ != tokenDelim {
-		return nil, p.errorf(t, "expected '~', '|', '^', '$', '*' or '='")
+		return nil, p.errorf(t, "expected '~', '|', '^', '$', '*', '%%' or '='")
 	}
 	switch t.s {
-	case "~", "|", "^", "$", "*", "=":
+	case "~", "|", "^", "$", "*", "%", "=":
 	default:
-		return nil, p.errorf(t, "expected '~', '|', '^', '$', '*' or '='")
+		return nil, p.errorf(t, "expected '~', '|', '^', '$', '*', '%%' or '='")
 	}
 	at.matcher = "="
 	if t.s != "=" {
@@ -595,7 +882,7 @@ func (p *parser) attributeSelector() (*attributeSelector, error) {
 	if !(strOrIdent.typ == tokenString || strOrIdent.typ == tokenIdent) {
 		return nil, p.errorf(strOrIdent, "expected identifier or string")
 	}
-	at.val = strOrIdent.s
+	at.val = p.intern(strOrIdent.s)
 
 	p.skipWhitespace()
 
@@ -616,6 +903,7 @@ func (p *parser) attributeSelector() (*attributeSelector, error) {
 	if t.typ != tokenBracketClose {
 		return nil, p.errorf(t, "expected ']'")
 	}
+	at.end = p.end
 	return at, nil
 }
 
@@ -654,7 +942,10 @@ func (p *parser) parseName(allowStar bool) (*wqName, error) {
 		if t.typ != tokenIdent {
 			return nil, p.errorf(t, "expected identifier")
 		}
-		return &wqName{true, "", t.s}, nil
+		if allowStar && escapedStar(t) {
+			return nil, p.errorf(t, "escaped '*' can't be used as an element name")
+		}
+		return &wqName{true, "", p.intern(t.s)}, nil
 	}
 	if t.isDelim("*") {
 		delim, err := p.peek()
@@ -678,7 +969,10 @@ func (p *parser) parseName(allowStar bool) (*wqName, error) {
 		if !(ident.typ == tokenIdent || (allowStar && ident.isDelim("*"))) {
 			return nil, p.errorf(ident, "expected identifier")
 		}
-		return &wqName{true, t.s, ident.s}, nil
+		if allowStar && escapedStar(ident) {
+			return nil, p.errorf(ident, "escaped '*' can't be used as an element name")
+		}
+		return &wqName{true, p.intern(t.s), p.intern(ident.s)}, nil
 	}
 	if t.typ != tokenIdent {
 		return nil, p.errorf(t, "expected identifier")
@@ -690,19 +984,38 @@ func (p *parser) parseName(allowStar bool) (*wqName, error) {
 		return nil, err
 	}
 	if !delim.isDelim("|") {
-		return &wqName{false, "", t.s}, nil
+		if allowStar && escapedStar(t) {
+			return nil, p.errorf(t, "escaped '*' can't be used as an element name")
+		}
+		return &wqName{false, "", p.intern(t.s)}, nil
 	}
 	ident, err := p.peekN(1)
 	if err != nil {
 		return nil, err
 	}
 	if !(ident.typ == tokenIdent || (allowStar && ident.isDelim("*"))) {
-		return &wqName{false, "", t.s}, nil
+		if allowStar && escapedStar(t) {
+			return nil, p.errorf(t, "escaped '*' can't be used as an element name")
+		}
+		return &wqName{false, "", p.intern(t.s)}, nil
+	}
+	if escapedStar(t) {
+		return nil, p.errorf(t, "escaped '*' can't be used as a namespace prefix")
+	}
+	if allowStar && escapedStar(ident) {
+		return nil, p.errorf(ident, "escaped '*' can't be used as an element name")
 	}
 	// Consume peeked tokens.
 	p.next()
 	p.next()
-	return &wqName{true, t.s, ident.s}, nil
+	return &wqName{true, p.intern(t.s), p.intern(ident.s)}, nil
+}
+
+// escapedStar reports if t is an identifier holding an escaped '*', such as
+// "\*". Unlike the '*' delimiter, which is the universal selector, the
+// identifier is a name, and documents can't hold elements named "*".
+func escapedStar(t token) bool {
+	return t.typ == tokenIdent && t.s == "*"
 }
 
 // https://drafts.csswg.org/css-syntax-3/#typedef-n-dimension
@@ -762,12 +1075,13 @@ func isSignlessInteger(t token) bool {
 	return isInteger(t) && strings.IndexFunc(t.s, isDigit) == 0
 }
 
-func parseInt(s string) (int64, error) {
-	return strconv.ParseInt(s, 10, 64)
+func parseInt(s string) (int, error) {
+	n, err := strconv.ParseInt(s, 10, 0)
+	return int(n), err
 }
 
 // b parses the common pattern of <signed-integer> | ['+' | '-'] <signless-integer>
-func (p *parser) b() (int64, error) {
+func (p *parser) b() (int, error) {
 	p.skipWhitespace()
 	t, err := p.next()
 	if err != nil {
@@ -809,24 +1123,24 @@ func (p *parser) b() (int64, error) {
 }
 
 // https://drafts.csswg.org/css-syntax-3/#the-anb-type
-func (p *parser) aNPlusB() (*nth, error) {
+func (p *parser) aNPlusB() (*Nth, error) {
 	p.skipWhitespace()
 	t, err := p.next()
 	if err != nil {
 		return nil, err
 	}
 	if t.isIdent("even") {
-		return &nth{a: 2}, nil
+		return &Nth{A: 2}, nil
 	}
 	if t.isIdent("odd") {
-		return &nth{a: 2, b: 1}, nil
+		return &Nth{A: 2, B: 1}, nil
 	}
 	if isInteger(t) {
 		b, err := parseInt(t.s)
 		if err != nil {
 			return nil, p.errorf(t, "parsing value as integer: %v", err)
 		}
-		return &nth{b: b}, nil
+		return &Nth{B: b}, nil
 	}
 
 	if isNDimension(t) {
@@ -838,7 +1152,7 @@ func (p *parser) aNPlusB() (*nth, error) {
 		if err != nil {
 			return nil, err
 		}
-		return &nth{a: a, b: b}, nil
+		return &Nth{A: a, B: b}, nil
 	}
 
 	if isNDashDigitDimension(t) {
@@ -852,7 +1166,7 @@ func (p *parser) aNPlusB() (*nth, error) {
 		if err != nil {
 			return nil, p.errorf(t, "parsing dimension as integer: %v", err)
 		}
-		return &nth{a: a, b: b}, nil
+		return &Nth{A: a, B: b}, nil
 	}
 
 	if isDashNDashDigitIdent(t) {
@@ -861,7 +1175,7 @@ func (p *parser) aNPlusB() (*nth, error) {
 		if err != nil {
 			return nil, p.errorf(t, "parsing b as integer: %v", err)
 		}
-		return &nth{a: -1, b: b}, nil
+		return &Nth{A: -1, B: b}, nil
 	}
 
 	if isNDashDimension(t) {
@@ -882,7 +1196,7 @@ func (p *parser) aNPlusB() (*nth, error) {
 		if err != nil {
 			return nil, p.errorf(t, "parsing value as integer: %v", err)
 		}
-		return &nth{a: a, b: 0 - n}, nil
+		return &Nth{A: a, B: 0 - n}, nil
 	}
 
 	if t.isIdent("-n-") {
@@ -899,7 +1213,7 @@ func (p *parser) aNPlusB() (*nth, error) {
 		if err != nil {
 			return nil, p.errorf(t, "parsing value as integer: %v", err)
 		}
-		return &nth{a: -1, b: 0 - n}, nil
+		return &Nth{A: -1, B: 0 - n}, nil
 	}
 
 	if t.isIdent("-n") {
@@ -907,7 +1221,7 @@ func (p *parser) aNPlusB() (*nth, error) {
 		if err != nil {
 			return nil, err
 		}
-		return &nth{a: -1, b: b}, nil
+		return &Nth{A: -1, B: b}, nil
 	}
 
 	if t.isDelim("+") {
@@ -924,7 +1238,7 @@ func (p *parser) aNPlusB() (*nth, error) {
 		if err != nil {
 			return nil, err
 		}
-		return &nth{a: 1, b: b}, nil
+		return &Nth{A: 1, B: b}, nil
 	}
 
 	if t.isIdent("n-") {
@@ -941,7 +1255,7 @@ func (p *parser) aNPlusB() (*nth, error) {
 		if err != nil {
 			return nil, p.errorf(t, "parsing value as integer: %v", err)
 		}
-		return &nth{a: 1, b: 0 - n}, nil
+		return &Nth{A: 1, B: 0 - n}, nil
 	}
 	return nil, p.errorf(t, "expected 'even', 'odd', or integer type")
 }
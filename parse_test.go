@@ -8,8 +8,15 @@ import (
 	"github.com/google/go-cmp/cmp"
 )
 
-func cmpDiff(x, y interface{}) string {
-	return cmp.Diff(x, y, cmp.AllowUnexported(
+// ignoreEnd ignores the end offsets of parsed components. These are tested by
+// TestSpans.
+var ignoreEnd = cmp.FilterPath(func(p cmp.Path) bool {
+	f, ok := p.Last().(cmp.StructField)
+	return ok && f.Name() == "end"
+}, cmp.Ignore())
+
+func cmpDiff(x, y interface{}, opts ...cmp.Option) string {
+	return cmp.Diff(x, y, append(opts, cmp.AllowUnexported(
 		attributeSelector{},
 		complexSelector{},
 		compoundSelector{},
@@ -19,7 +26,7 @@ func cmpDiff(x, y interface{}) string {
 		token{},
 		typeSelector{},
 		wqName{},
-	))
+	))...)
 }
 
 func TestParse(t *testing.T) {
@@ -201,6 +208,7 @@ func TestParse(t *testing.T) {
 					typeSelector: &typeSelector{pos: 0, value: "foo"},
 					pseudoSelectors: []pseudoSelector{
 						{
+							pos:     3,
 							element: pseudoClassSelector{pos: 4, ident: "bar"},
 						},
 					},
@@ -213,6 +221,7 @@ func TestParse(t *testing.T) {
 					typeSelector: &typeSelector{pos: 0, value: "foo"},
 					pseudoSelectors: []pseudoSelector{
 						{
+							pos:     3,
 							element: pseudoClassSelector{pos: 4, ident: "bar"},
 							classes: []pseudoClassSelector{{pos: 9, ident: "spam"}, {pos: 15, ident: "biz"}},
 						},
@@ -226,6 +235,7 @@ func TestParse(t *testing.T) {
 					typeSelector: &typeSelector{pos: 0, value: "foo"},
 					pseudoSelectors: []pseudoSelector{
 						{
+							pos: 3,
 							element: pseudoClassSelector{
 								pos:      4,
 								function: "myfunc(",
@@ -293,7 +303,7 @@ func TestParse(t *testing.T) {
 			t.Errorf("parsing %q: %v", test.s, err)
 			continue
 		}
-		if diff := cmpDiff(test.want, got); diff != "" {
+		if diff := cmpDiff(test.want, got, ignoreEnd); diff != "" {
 			t.Errorf("parsing %q returned diff (-want +got) %s", test.s, diff)
 		}
 	}
@@ -356,13 +366,13 @@ func TestSubParser(t *testing.T) {
 		want       interface{}
 		wantErrPos int
 	}{
-		{parsePseudoClass, ":foo", &pseudoClassSelector{0, "foo", "", nil}, -1},
+		{parsePseudoClass, ":foo", &pseudoClassSelector{0, 4, "foo", "", nil}, -1},
 		{parsePseudoClass, ": foo", nil, 1}, // https://www.w3.org/TR/selectors-4/#white-space
-		{parsePseudoClass, ":foo()", &pseudoClassSelector{0, "", "foo(", nil}, -1},
-		{parsePseudoClass, ":foo(a)", &pseudoClassSelector{0, "", "foo(", []token{
+		{parsePseudoClass, ":foo()", &pseudoClassSelector{0, 6, "", "foo(", nil}, -1},
+		{parsePseudoClass, ":foo(a)", &pseudoClassSelector{0, 7, "", "foo(", []token{
 			token{tokenIdent, "a", "a", 5, 0, ""},
 		}}, -1},
-		{parsePseudoClass, ":foo(a, b)", &pseudoClassSelector{0, "", "foo(", []token{
+		{parsePseudoClass, ":foo(a, b)", &pseudoClassSelector{0, 10, "", "foo(", []token{
 			token{tokenIdent, "a", "a", 5, 0, ""},
 			token{tokenComma, ",", ",", 6, 0, ""},
 			token{tokenWhitespace, " ", " ", 7, 0, ""},
@@ -377,45 +387,47 @@ func TestSubParser(t *testing.T) {
 		{parseWQName, "*foo", nil, 1},
 		{parseWQName, "foo |bar", &wqName{false, "", "foo"}, -1}, // Whitespace ignored
 		{parseWQName, "foo| bar", &wqName{false, "", "foo"}, -1}, // Whitespace ignored
-		{parseTypeSel, "foo", &typeSelector{0, false, "", "foo"}, -1},
-		{parseTypeSel, "foo|bar", &typeSelector{0, true, "foo", "bar"}, -1},
-		{parseTypeSel, "|bar", &typeSelector{0, true, "", "bar"}, -1},
-		{parseTypeSel, "*|bar", &typeSelector{0, true, "*", "bar"}, -1},
-		{parseTypeSel, "foo|*", &typeSelector{0, true, "foo", "*"}, -1},
-		{parseTypeSel, "*|*", &typeSelector{0, true, "*", "*"}, -1},
-		{parseTypeSel, "*foo", &typeSelector{0, false, "", "*"}, -1},
-		{parseTypeSel, "foo |bar", &typeSelector{0, false, "", "foo"}, -1}, // Whitespace ignored
-		{parseTypeSel, "foo| bar", &typeSelector{0, false, "", "foo"}, -1}, // Whitespace ignored
+		{parseTypeSel, "foo", &typeSelector{0, 3, false, "", "foo"}, -1},
+		{parseTypeSel, "foo|bar", &typeSelector{0, 7, true, "foo", "bar"}, -1},
+		{parseTypeSel, "|bar", &typeSelector{0, 4, true, "", "bar"}, -1},
+		{parseTypeSel, "*|bar", &typeSelector{0, 5, true, "*", "bar"}, -1},
+		{parseTypeSel, "foo|*", &typeSelector{0, 5, true, "foo", "*"}, -1},
+		{parseTypeSel, "*|*", &typeSelector{0, 3, true, "*", "*"}, -1},
+		{parseTypeSel, "*foo", &typeSelector{0, 1, false, "", "*"}, -1},
+		{parseTypeSel, "foo |bar", &typeSelector{0, 3, false, "", "foo"}, -1}, // Whitespace ignored
+		{parseTypeSel, "foo| bar", &typeSelector{0, 3, false, "", "foo"}, -1}, // Whitespace ignored
 		{parseAttrSel, "[foo]", &attributeSelector{
-			0, &wqName{false, "", "foo"}, "", "", false,
+			0, 5, &wqName{false, "", "foo"}, "", "", false,
 		}, -1},
 		{parseAttrSel, "[ foo = \"bar\" ]", &attributeSelector{
-			0, &wqName{false, "", "foo"}, "=", "bar", false,
+			0, 15, &wqName{false, "", "foo"}, "=", "bar", false,
 		}, -1},
 		{parseAttrSel, "[foo=\"bar\"]", &attributeSelector{
-			0, &wqName{false, "", "foo"}, "=", "bar", false,
+			0, 11, &wqName{false, "", "foo"}, "=", "bar", false,
 		}, -1},
 		{parseAttrSel, "[*|foo=\"bar\"]", &attributeSelector{
-			0, &wqName{true, "*", "foo"}, "=", "bar", false,
+			0, 13, &wqName{true, "*", "foo"}, "=", "bar", false,
 		}, -1},
 		{parseAttrSel, "[*|foo=bar]", &attributeSelector{
-			0, &wqName{true, "*", "foo"}, "=", "bar", false,
+			0, 11, &wqName{true, "*", "foo"}, "=", "bar", false,
 		}, -1},
 		{parseAttrSel, "[*|foo=bar i]", &attributeSelector{
-			0, &wqName{true, "*", "foo"}, "=", "bar", true,
+			0, 13, &wqName{true, "*", "foo"}, "=", "bar", true,
 		}, -1},
 		{parseAttrSel, "[foo^=bar]", &attributeSelector{
-			0, &wqName{false, "", "foo"}, "^=", "bar", false,
+			0, 10, &wqName{false, "", "foo"}, "^=", "bar", false,
 		}, -1},
 		{parseSubclassSel, "", false, -1},
-		{parseSubclassSel, "#foo", &subclassSelector{idSelector: "foo"}, -1},
-		{parseSubclassSel, ".foo", &subclassSelector{classSelector: "foo"}, -1},
+		{parseSubclassSel, "#foo", &subclassSelector{end: 4, idSelector: "foo"}, -1},
+		{parseSubclassSel, ".foo", &subclassSelector{end: 4, classSelector: "foo"}, -1},
 		{parseSubclassSel, ".foo()", nil, 1},
 		{parseSubclassSel, "[foo=bar]", &subclassSelector{
-			attributeSelector: &attributeSelector{0, &wqName{false, "", "foo"}, "=", "bar", false},
+			end:               9,
+			attributeSelector: &attributeSelector{0, 9, &wqName{false, "", "foo"}, "=", "bar", false},
 		}, -1},
 		{parseSubclassSel, ":foo", &subclassSelector{
-			pseudoClassSelector: &pseudoClassSelector{0, "foo", "", nil},
+			end:                 4,
+			pseudoClassSelector: &pseudoClassSelector{0, 4, "foo", "", nil},
 		}, -1},
 		{parseSubclassSel, "::foo", false, -1},
 		{parseWQName, "foo", &wqName{false, "", "foo"}, -1},
@@ -459,8 +471,8 @@ func TestSubParser(t *testing.T) {
 func TestANPlusB(t *testing.T) {
 	tests := []struct {
 		s       string
-		a       int64
-		b       int64
+		a       int
+		b       int
 		wantErr bool
 	}{
 		{"even", 2, 0, false},
@@ -514,8 +526,8 @@ func TestANPlusB(t *testing.T) {
 			t.Errorf("Expected error parsing %s: %v", test.s, err)
 			continue
 		}
-		if test.a != got.a || test.b != got.b {
-			t.Errorf("Parsing failed for %s, got a=%d, b=%d, want a=%d, b=%d", test.s, got.a, got.b, test.a, test.b)
+		if test.a != got.A || test.b != got.B {
+			t.Errorf("Parsing failed for %s, got a=%d, b=%d, want a=%d, b=%d", test.s, got.A, got.B, test.a, test.b)
 		}
 	}
 }
@@ -0,0 +1,130 @@
+package css
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Path returns a CSS path that identifies n within its document, similar to
+// the "copy selector" feature of browser developer tools:
+//
+//	html > body > div#main > ul > li:nth-child(3)
+//
+// The path has a compound selector for n and each of its element ancestors,
+// joined by child combinators. Each compound selector holds the element's tag
+// name and id, if any. When an element has siblings with the same tag name,
+// it's distinguished by a class that none of those siblings have, falling
+// back to :nth-child().
+//
+// Ids are assumed to be unique, so paths through elements that share an id
+// may match more than one element. Path returns an empty string if n isn't
+// an element.
+func Path(n *html.Node) string {
+	if n == nil || !isElement(n) {
+		return ""
+	}
+	var steps []string
+	for e := n; e != nil && isElement(e); e = e.Parent {
+		steps = append(steps, pathStep(e))
+	}
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return strings.Join(steps, " > ")
+}
+
+// Path returns the CSS path of the matched element. See the Path function.
+func (m Match) Path() string {
+	return Path(m.Node)
+}
+
+// pathStep returns the compound selector identifying n among its siblings.
+func pathStep(n *html.Node) string {
+	var b strings.Builder
+	writeIdent(&b, n.Data)
+	id, hasID := attr(n, "id")
+	if hasID && id != "" {
+		b.WriteString("#")
+		writeIdent(&b, id)
+		return b.String()
+	}
+
+	var siblings []*html.Node
+	if n.Parent != nil {
+		for c := firstElementChild(n.Parent); c != nil; c = nextElementSibling(c) {
+			if c != n && c.Data == n.Data {
+				siblings = append(siblings, c)
+			}
+		}
+	}
+	if len(siblings) == 0 {
+		return b.String()
+	}
+
+	val, _ := attr(n, "class")
+	for _, class := range strings.Fields(val) {
+		unique := true
+		for _, s := range siblings {
+			v, _ := attr(s, "class")
+			if containsAll(strings.Fields(v), []string{class}) {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			b.WriteString(".")
+			writeIdent(&b, class)
+			return b.String()
+		}
+	}
+	b.WriteString(":nth-child(" + strconv.Itoa((&state{}).computeSiblingIndex(n).child) + ")")
+	return b.String()
+}
+
+// Breadcrumb returns a readable description of n and its element ancestors,
+// starting from the body or root element, for logs and reports:
+//
+//	body > div#content > article.post > h2
+//
+// Each element is described by its tag name, id and first class. Unlike
+// Path, the result isn't escaped and needn't identify n, so it's not
+// intended to be parsed as a selector. Breadcrumb returns an empty string if
+// n isn't an element.
+func Breadcrumb(n *html.Node) string {
+	if n == nil || !isElement(n) {
+		return ""
+	}
+	var steps []string
+	for e := n; e != nil && isElement(e); e = e.Parent {
+		if e.DataAtom == atom.Html && e != n && e.Parent != nil && e.Parent.Type == html.DocumentNode {
+			break
+		}
+		steps = append(steps, breadcrumbStep(e))
+	}
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return strings.Join(steps, " > ")
+}
+
+// Breadcrumb returns the breadcrumb of the matched element. See the
+// Breadcrumb function.
+func (m Match) Breadcrumb() string {
+	return Breadcrumb(m.Node)
+}
+
+func breadcrumbStep(n *html.Node) string {
+	step := n.Data
+	if id, _ := attr(n, "id"); id != "" {
+		step += "#" + id
+	}
+	if class, _ := attr(n, "class"); class != "" {
+		if fields := strings.Fields(class); len(fields) > 0 {
+			step += "." + fields[0]
+		}
+	}
+	return step
+}
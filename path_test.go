@@ -0,0 +1,106 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestPath(t *testing.T) {
+	const doc = `<div id="main">
+<ul>
+  <li>one</li>
+  <li class="x">two</li>
+  <li class="x selected">three</li>
+  <!-- comment -->
+  <li>four</li>
+</ul>
+<p>text</p>
+<svg><circle></circle></svg>
+</div>`
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("parsing html: %v", err)
+	}
+	tests := []struct {
+		sel  string
+		want string
+	}{
+		{"html", "html"},
+		{"#main", "html > body > div#main"},
+		{"li:nth-child(1)", "html > body > div#main > ul > li:nth-child(1)"},
+		{"li:nth-child(2)", "html > body > div#main > ul > li:nth-child(2)"},
+		{"li.selected", "html > body > div#main > ul > li.selected"},
+		{"li:nth-child(4)", "html > body > div#main > ul > li:nth-child(4)"},
+		{"p", "html > body > div#main > p"},
+		{"circle", "html > body > div#main > svg > circle"},
+	}
+	for _, test := range tests {
+		n := MustParse(test.sel).Select(root)[0]
+		got := Path(n)
+		if got != test.want {
+			t.Errorf("Path(%s) = %q, want %q", test.sel, got, test.want)
+			continue
+		}
+		matched := MustParse(got).Select(root)
+		if len(matched) != 1 || matched[0] != n {
+			t.Errorf("Path(%s) = %q, which matched %d elements", test.sel, got, len(matched))
+		}
+	}
+
+	if got := Path(root); got != "" {
+		t.Errorf("Path(document) = %q, want empty string", got)
+	}
+	if got := Path(nil); got != "" {
+		t.Errorf("Path(nil) = %q, want empty string", got)
+	}
+
+	m := MustParse("p").SelectDetailed(root)[0]
+	if got, want := m.Path(), "html > body > div#main > p"; got != want {
+		t.Errorf("Match.Path() = %q, want %q", got, want)
+	}
+}
+
+func TestBreadcrumb(t *testing.T) {
+	const doc = `<div id="content" class="main wide">
+<article class="post featured"><h2>Title</h2><p class="a&amp;b">text</p></article>
+</div>`
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("parsing html: %v", err)
+	}
+	tests := []struct {
+		sel  string
+		want string
+	}{
+		{"html", "html"},
+		{"body", "body"},
+		{"h2", "body > div#content.main > article.post > h2"},
+		{"article p", "body > div#content.main > article.post > p.a&b"},
+	}
+	for _, test := range tests {
+		n := MustParse(test.sel).Select(root)[0]
+		if got := Breadcrumb(n); got != test.want {
+			t.Errorf("Breadcrumb(%s) = %q, want %q", test.sel, got, test.want)
+		}
+	}
+
+	if got := Breadcrumb(root); got != "" {
+		t.Errorf("Breadcrumb(document) = %q, want empty string", got)
+	}
+	m := MustParse("h2").SelectDetailed(root)[0]
+	if got, want := m.Breadcrumb(), "body > div#content.main > article.post > h2"; got != want {
+		t.Errorf("Match.Breadcrumb() = %q, want %q", got, want)
+	}
+
+	// Detached elements are described up to their furthest ancestor.
+	frag, err := html.ParseFragment(strings.NewReader(`<section><b>x</b></section>`), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		t.Fatalf("parsing fragment: %v", err)
+	}
+	if got, want := Breadcrumb(frag[0].FirstChild), "section > b"; got != want {
+		t.Errorf("Breadcrumb(fragment) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+package css
+
+import (
+	"golang.org/x/net/html"
+)
+
+// Pipeline chains selectors, evaluating each selector relative to the
+// results of the one before it. Pipelines are created by Selector.Then:
+//
+//	titles := css.MustParse(".card").Then(css.MustParse("h2")).Select(doc)
+//
+// Like a Selector, a Pipeline is immutable and safe for concurrent use.
+type Pipeline struct {
+	stages []*Selector
+}
+
+// Then returns a pipeline that selects elements matching next within each
+// element matched by s.
+func (s *Selector) Then(next *Selector) *Pipeline {
+	return &Pipeline{stages: []*Selector{s, next}}
+}
+
+// Then returns a new pipeline that selects elements matching next within each
+// element selected by p. p isn't modified.
+func (p *Pipeline) Then(next *Selector) *Pipeline {
+	stages := make([]*Selector, len(p.stages), len(p.stages)+1)
+	copy(stages, p.stages)
+	return &Pipeline{stages: append(stages, next)}
+}
+
+// Select returns the elements selected by the final stage of the pipeline, in
+// document order and without duplicates.
+//
+// The first stage selects from n in the same way as Selector.Select. Each
+// following stage is scoped to each element selected by the previous stage,
+// like Selector.Select, except that the scoping element itself is never
+// returned. Combinators aren't evaluated past the scoping element, so in
+// ".card" then "body h2", the second stage never matches.
+func (p *Pipeline) Select(n *html.Node) []*html.Node {
+	selected := p.stages[0].Select(n)
+	for _, s := range p.stages[1:] {
+		var next []*html.Node
+		seen := map[*html.Node]bool{}
+		for _, scope := range selected {
+			for _, e := range s.Select(scope) {
+				if e != scope && !seen[e] {
+					seen[e] = true
+					next = append(next, e)
+				}
+			}
+		}
+		selected = SortNodes(next)
+	}
+	if selected == nil {
+		selected = []*html.Node{}
+	}
+	return selected
+}
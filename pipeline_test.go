@@ -0,0 +1,71 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestPipeline(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<div class="card"><h2>A</h2><div class="card"><h2>B</h2></div></div>
+<div class="card"><section><h2>C</h2></section></div>
+<h2>D</h2>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		p    *Pipeline
+		want []string
+	}{
+		{
+			"nested",
+			MustParse(".card").Then(MustParse("h2")),
+			[]string{"<h2>A</h2>", "<h2>B</h2>", "<h2>C</h2>"},
+		},
+		{
+			"combinators",
+			MustParse(".card").Then(MustParse(".card > h2")),
+			[]string{"<h2>A</h2>", "<h2>B</h2>"},
+		},
+		{
+			"scoped combinators",
+			MustParse(".card").Then(MustParse("body h2")),
+			[]string{},
+		},
+		{
+			"descendants only",
+			MustParse(".card").Then(MustParse(".card")),
+			[]string{`<div class="card"><h2>B</h2></div>`},
+		},
+		{
+			"three stages",
+			MustParse(".card").Then(MustParse("section")).Then(MustParse("h2")),
+			[]string{"<h2>C</h2>"},
+		},
+		{
+			"no matches",
+			MustParse("table").Then(MustParse("h2")),
+			[]string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := renderNodes(t, test.p.Select(root))
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Pipeline.Select() returned diff (-want, +got): %s", diff)
+			}
+		})
+	}
+
+	// Extending a pipeline doesn't modify it.
+	base := MustParse("div").Then(MustParse("section"))
+	base.Then(MustParse("h2"))
+	if got := base.Select(root); len(got) != 1 || got[0].Data != "section" {
+		t.Errorf("Pipeline.Then() modified the original pipeline, got %v", renderNodes(t, got))
+	}
+}
@@ -0,0 +1,94 @@
+package css
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// orderSubclasses sorts the tests of a compound selector so the cheapest and
+// most selective are evaluated first. Every element within the root is
+// matched against the subject of a selector, so this is the order most
+// elements are rejected in.
+func orderSubclasses(m *compoundSelectorMatcher) {
+	sort.SliceStable(m.scm, func(i, j int) bool {
+		return m.scm[i].cost() < m.scm[j].cost()
+	})
+}
+
+// evaluationOrder serializes a compiled compound selector with its tests in
+// the order they're evaluated. Components that were ignored while compiling
+// are left out.
+func evaluationOrder(m *compoundSelectorMatcher) string {
+	cs := compoundSelector{
+		typeSelector:    m.src.typeSelector,
+		pseudoSelectors: m.src.pseudoSelectors,
+	}
+	for _, scm := range m.scm {
+		cs.subClasses = append(cs.subClasses, *scm.src)
+	}
+	var b strings.Builder
+	writeCompoundSelector(&b, &cs)
+	return b.String()
+}
+
+// cost estimates the relative expense of evaluating a subclass selector.
+// Cheaper tests are also the most likely to reject an element.
+func (s *subclassSelectorMatcher) cost() int {
+	switch {
+	case s.idSelector != "":
+		return 0
+	case s.classSelector != "":
+		return 1
+	case s.attributeSelector != nil:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Explain returns a human readable description of how the selector is
+// evaluated, with one entry for each selector in the list. Every element
+// within the root is matched against the subject, the last compound selector,
+// with cheap and selective tests such as IDs and classes evaluated first.
+// Elements matching the subject are then related to the rest of the selector,
+// from right to left. It's intended for debugging, and the format may change.
+func (s *Selector) Explain() string {
+	var b strings.Builder
+	for i, sel := range s.s {
+		cs := &s.list[i]
+		writeComplexSelector(&b, cs)
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "\tmatch: %s\n", evaluationOrder(sel.m))
+
+		var compounds []*complexSelector
+		for curr := cs; curr != nil; curr = curr.next {
+			compounds = append(compounds, curr)
+		}
+		var sub strings.Builder
+		for j := len(compounds) - 2; j >= 0; j-- {
+			sub.Reset()
+			writeCompoundSelector(&sub, &compounds[j].sel)
+			fmt.Fprintf(&b, "\tthen: %s %s\n", combinatorRelation(compounds[j].combinator), sub.String())
+		}
+	}
+	return b.String()
+}
+
+func combinatorRelation(combinator string) string {
+	switch combinator {
+	case ">":
+		return "parent"
+	case "+":
+		return "previous sibling"
+	case "~":
+		return "any previous sibling"
+	case "^":
+		return "any descendant"
+	default:
+		if isCustomCombinator(combinator) {
+			return "related by " + combinator
+		}
+		return "any ancestor"
+	}
+}
@@ -0,0 +1,46 @@
+package css
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		sel  string
+		want string
+	}{
+		{
+			"a",
+			"a\n\tmatch: a\n",
+		},
+		{
+			// Classes are tested before attributes.
+			"[href].foo",
+			"[href].foo\n\tmatch: .foo[href]\n",
+		},
+		{
+			// IDs are tested first, after the type.
+			"#main > li:checked.item#x",
+			"#main > li:checked.item#x\n" +
+				"\tmatch: li#x.item:checked\n" +
+				"\tthen: parent #main\n",
+		},
+		{
+			"div p + *, :first-child",
+			"div p + *\n" +
+				"\tmatch: *\n" +
+				"\tthen: previous sibling p\n" +
+				"\tthen: any ancestor div\n" +
+				":first-child\n" +
+				"\tmatch: :first-child\n",
+		},
+	}
+	for _, test := range tests {
+		got := MustParse(test.sel).Explain()
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Explain(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+}
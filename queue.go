@@ -47,6 +47,12 @@ func (q *queue) push(t token) {
 	q.n++
 }
 
+// reset removes all elements from the queue.
+func (q *queue) reset() {
+	q.start = 0
+	q.n = 0
+}
+
 // pop dequeues an element. It panics if the queue is empty.
 func (q *queue) pop() token {
 	if q.n == 0 {
@@ -0,0 +1,224 @@
+package css
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Recipe describes how to extract structured data from a document, mapping
+// output field names to the elements that hold their values. Recipes are
+// plain data, and can be loaded from configuration rather than written in Go:
+//
+//	{
+//		"title": {"selector": "h1"},
+//		"links": {"selector": "a[href]", "action": "attr:href", "list": true},
+//		"items": {
+//			"selector": ".item",
+//			"list": true,
+//			"fields": {
+//				"name":  {"selector": ".name"},
+//				"price": {"selector": ".price"}
+//			}
+//		}
+//	}
+//
+// Fields only use JSON struct tags. YAML can be decoded using a package that
+// converts YAML to JSON before unmarshaling, such as sigs.k8s.io/yaml.
+type Recipe map[string]*Field
+
+// Field describes how to extract a single value of a Recipe.
+type Field struct {
+	// Selector selects the elements holding the value. For nested fields,
+	// the selector is scoped to the element matched by the parent field, and
+	// the parent element itself is never selected.
+	Selector string `json:"selector"`
+	// Action is the value extracted from each selected element:
+	//
+	//	"text"       the element's text, with whitespace collapsed (default)
+	//	"html"       the element's inner HTML
+	//	"attr:name"  the value of the named attribute
+	//
+	// Action must be empty if Fields is set.
+	Action string `json:"action,omitempty"`
+	// List causes the field to hold a value for every selected element,
+	// rather than only the first.
+	List bool `json:"list,omitempty"`
+	// Fields, if set, extracts an object from each selected element, using
+	// the elements as the scope for a nested recipe.
+	Fields Recipe `json:"fields,omitempty"`
+}
+
+// Runner executes a compiled Recipe against documents. Like a Selector, a
+// Runner is immutable and safe for concurrent use.
+type Runner struct {
+	fields []*recipeField
+}
+
+type recipeField struct {
+	name    string
+	sel     *Selector
+	nested  bool
+	list    bool
+	extract func(n *html.Node) (string, bool, error)
+	fields  []*recipeField
+}
+
+// NewRunner compiles the selectors of a recipe, passing the provided options
+// to Parse. An error is returned if any selector or action is invalid.
+func NewRunner(r Recipe, opts ...Option) (*Runner, error) {
+	fields, err := compileRecipe(r, "", opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{fields: fields}, nil
+}
+
+func compileRecipe(r Recipe, prefix string, opts []Option) ([]*recipeField, error) {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]*recipeField, 0, len(names))
+	for _, name := range names {
+		f := r[name]
+		path := prefix + name
+		if f == nil {
+			return nil, fmt.Errorf("css: recipe field %q: no field provided", path)
+		}
+		sel, err := Parse(f.Selector, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("css: recipe field %q: %w", path, err)
+		}
+		rf := &recipeField{name: name, sel: sel, list: f.List}
+		if f.Fields != nil {
+			if f.Action != "" {
+				return nil, fmt.Errorf("css: recipe field %q: action %q can't be used with nested fields", path, f.Action)
+			}
+			rf.nested = true
+			rf.fields, err = compileRecipe(f.Fields, path+".", opts)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			rf.extract, err = recipeAction(f.Action)
+			if err != nil {
+				return nil, fmt.Errorf("css: recipe field %q: %w", path, err)
+			}
+		}
+		fields = append(fields, rf)
+	}
+	return fields, nil
+}
+
+// recipeAction returns a function that extracts a value from an element. The
+// function reports false if the element doesn't have a value, such as a
+// missing attribute.
+func recipeAction(action string) (func(n *html.Node) (string, bool, error), error) {
+	switch {
+	case action == "" || action == "text":
+		return func(n *html.Node) (string, bool, error) {
+			return strings.Join(strings.Fields(textContent(n)), " "), true, nil
+		}, nil
+	case action == "html":
+		return func(n *html.Node) (string, bool, error) {
+			var b strings.Builder
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if err := html.Render(&b, c); err != nil {
+					return "", false, err
+				}
+			}
+			return b.String(), true, nil
+		}, nil
+	case strings.HasPrefix(action, "attr:"):
+		key := strings.TrimPrefix(action, "attr:")
+		if key == "" {
+			return nil, fmt.Errorf("no attribute name provided")
+		}
+		return func(n *html.Node) (string, bool, error) {
+			val, ok := attr(n, key)
+			return val, ok, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown action %q", action)
+}
+
+// Run extracts the fields of the recipe from the document rooted at root.
+//
+// Values of fields are strings, or map[string]any for fields with nested
+// fields. List fields hold a []any with a value for each selected element,
+// skipping elements without a value, such as a missing attribute. Non-list
+// fields hold the first value found, or nil if no selected element has a
+// value. The result can be passed to json.Marshal, or decoded into a
+// struct by round tripping through JSON.
+//
+// Run returns a *RootError if root isn't a document or element node.
+func (r *Runner) Run(root *html.Node) (map[string]any, error) {
+	if !validRoot(root) {
+		return nil, &RootError{Node: root}
+	}
+	return runRecipe(r.fields, root)
+}
+
+func runRecipe(fields []*recipeField, scope *html.Node) (map[string]any, error) {
+	out := make(map[string]any, len(fields))
+	for _, f := range fields {
+		var vals []any
+		for _, n := range f.sel.Select(scope) {
+			if n == scope {
+				continue
+			}
+			var (
+				val any
+				ok  = true
+				err error
+			)
+			if f.nested {
+				if val, err = runRecipe(f.fields, n); err != nil {
+					return nil, err
+				}
+			} else if val, ok, err = f.extract(n); err != nil {
+				return nil, fmt.Errorf("css: recipe field %q: %w", f.name, err)
+			}
+			if !ok {
+				continue
+			}
+			vals = append(vals, val)
+			if !f.list {
+				break
+			}
+		}
+		switch {
+		case f.list:
+			if vals == nil {
+				vals = []any{}
+			}
+			out[f.name] = vals
+		case len(vals) > 0:
+			out[f.name] = vals[0]
+		default:
+			out[f.name] = nil
+		}
+	}
+	return out, nil
+}
+
+// textContent returns the concatenated text nodes of n and its descendants.
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package css
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestRunner(t *testing.T) {
+	const doc = `
+<h1>  Products
+  list</h1>
+<a href="/a">A</a> <a>none</a> <a href="/b">B</a>
+<div class="item"><span class="name">Widget</span><span class="price">1.50</span></div>
+<div class="item"><span class="name">Gadget</span><b>new</b></div>
+`
+	const recipe = `{
+		"title": {"selector": "h1"},
+		"links": {"selector": "a", "action": "attr:href", "list": true},
+		"missing": {"selector": "table"},
+		"none": {"selector": "table", "list": true},
+		"items": {
+			"selector": ".item",
+			"list": true,
+			"fields": {
+				"name":  {"selector": ".name"},
+				"price": {"selector": ".price"},
+				"html":  {"selector": "b", "action": "html"}
+			}
+		}
+	}`
+	var r Recipe
+	if err := json.Unmarshal([]byte(recipe), &r); err != nil {
+		t.Fatalf("unmarshal recipe: %v", err)
+	}
+	runner, err := NewRunner(r)
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("parse html: %v", err)
+	}
+	got, err := runner.Run(root)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := map[string]any{
+		"title":   "Products list",
+		"links":   []any{"/a", "/b"},
+		"missing": nil,
+		"none":    []any{},
+		"items": []any{
+			map[string]any{"name": "Widget", "price": "1.50", "html": nil},
+			map[string]any{"name": "Gadget", "price": nil, "html": "new"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Run returned diff (-want, +got): %s", diff)
+	}
+
+	if _, err := runner.Run(nil); !errors.As(err, new(*RootError)) {
+		t.Errorf("Run(nil) returned %v, want *RootError", err)
+	}
+}
+
+func TestRunnerErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		recipe Recipe
+		want   string
+	}{
+		{"invalid selector", Recipe{"a": {Selector: "a["}}, `recipe field "a"`},
+		{"unknown action", Recipe{"a": {Selector: "a", Action: "href"}}, `unknown action "href"`},
+		{"empty attribute", Recipe{"a": {Selector: "a", Action: "attr:"}}, "no attribute name"},
+		{"nil field", Recipe{"a": nil}, "no field provided"},
+		{
+			"action with fields",
+			Recipe{"a": {Selector: "a", Action: "text", Fields: Recipe{"b": {Selector: "b"}}}},
+			"can't be used with nested fields",
+		},
+		{
+			"nested",
+			Recipe{"a": {Selector: "a", Fields: Recipe{"b": {Selector: "b["}}}},
+			`recipe field "a.b"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewRunner(test.recipe)
+			if err == nil {
+				t.Fatalf("NewRunner succeeded, want error containing %q", test.want)
+			}
+			if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("NewRunner returned %q, want error containing %q", err, test.want)
+			}
+		})
+	}
+}
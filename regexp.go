@@ -0,0 +1,20 @@
+//go:build !css_noregexp
+
+package css
+
+import (
+	"regexp"
+)
+
+// compileRegexp compiles the pattern of a %= attribute matcher.
+//
+// Package regexp adds significantly to the size of binaries, particularly
+// for TinyGo and WebAssembly. Building with the css_noregexp tag removes the
+// dependency, and causes selectors using %= to fail to compile.
+func compileRegexp(pattern string) (func(s string) bool, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
@@ -0,0 +1,13 @@
+//go:build css_noregexp
+
+package css
+
+import (
+	"errors"
+)
+
+// compileRegexp reports an error, since the package was built with the
+// css_noregexp tag.
+func compileRegexp(pattern string) (func(s string) bool, error) {
+	return nil, errors.New("regular expressions are disabled by the css_noregexp build tag")
+}
@@ -0,0 +1,18 @@
+//go:build css_noregexp
+
+package css
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegexpDisabled(t *testing.T) {
+	_, err := Parse(`a[href%="^https:"]`, WithExtensions())
+	if err == nil {
+		t.Fatalf("Parse() succeeded, want error when built with css_noregexp")
+	}
+	if !strings.Contains(err.Error(), "css_noregexp") {
+		t.Errorf("Parse() returned %v, want error mentioning css_noregexp", err)
+	}
+}
@@ -0,0 +1,64 @@
+//go:build !css_noregexp
+
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestRegexpAttribute(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<a href="https://example.com">1</a>
+<a href="http://example.com">2</a>
+<a href="HTTPS://EXAMPLE.COM">3</a>
+<a href="ftp://example.com">4</a>
+<div data-sku="AB-1234">5</div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{`a[href%="^https?:"]`, []string{
+			`<a href="https://example.com">1</a>`,
+			`<a href="http://example.com">2</a>`,
+		}},
+		{`a[href%="^https:" i]`, []string{
+			`<a href="https://example.com">1</a>`,
+			`<a href="HTTPS://EXAMPLE.COM">3</a>`,
+		}},
+		{`[data-sku%="^[A-Z]{2}-\\d+$"]`, []string{
+			`<div data-sku="AB-1234">5</div>`,
+		}},
+		{`[href%=ftp]`, []string{
+			`<a href="ftp://example.com">4</a>`,
+		}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel, WithExtensions())
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		got := renderNodes(t, s.Select(root))
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+		if _, err := Parse(s.String(), WithExtensions()); err != nil {
+			t.Errorf("Parse(%q) failed to reparse serialized selector %q: %v", test.sel, s.String(), err)
+		}
+	}
+
+	if _, err := Parse(`a[href%="^https"]`); err == nil {
+		t.Errorf("Parse() without WithExtensions() accepted a regular expression matcher")
+	}
+	if _, err := Parse(`a[href%="("]`, WithExtensions()); err == nil {
+		t.Errorf("Parse() accepted an invalid regular expression")
+	}
+}
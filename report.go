@@ -0,0 +1,148 @@
+package css
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// GapKind classifies a feature recorded in a Report.
+type GapKind string
+
+const (
+	// GapPseudoClass is an unsupported pseudo-class, such as ":hover".
+	GapPseudoClass GapKind = "pseudo-class"
+	// GapPseudoElement is an unsupported pseudo-element, such as "::before",
+	// or a pseudo-element used in an unsupported position.
+	GapPseudoElement GapKind = "pseudo-element"
+	// GapNamespace is a namespace prefix that can't be resolved, such as
+	// "foo|a".
+	GapNamespace GapKind = "namespace"
+	// GapCombinator is an unsupported combinator, such as "||".
+	GapCombinator GapKind = "combinator"
+	// GapArgument is a selector dropped from the forgiving argument list of a
+	// pseudo-class, such as ":is(a, ::before)".
+	GapArgument GapKind = "argument"
+)
+
+// Gap records a feature that Parse ignored or downgraded to produce a best
+// effort Selector.
+type Gap struct {
+	// Selector is the selector being parsed, and Pos is the offset of the
+	// feature within it.
+	Selector string  `json:"selector"`
+	Pos      int     `json:"pos"`
+	Kind     GapKind `json:"kind"`
+	// Feature names the feature, such as ":hover", ":dir()", "::before",
+	// "foo|", or "||".
+	Feature string `json:"feature"`
+	// Msg describes how the feature was handled, and is the same as the
+	// message of the corresponding Warning.
+	Msg string `json:"msg"`
+}
+
+// Report collects the features Parse ignored or downgraded across any number
+// of selectors. It's used with WithReport to inventory the gaps in a corpus
+// of selectors, such as when migrating selectors written for a browser.
+//
+// The zero value is an empty report. A Report is safe for concurrent use.
+type Report struct {
+	mu   sync.Mutex
+	gaps []Gap
+}
+
+// Gaps returns the recorded gaps, in the order they were found.
+func (r *Report) Gaps() []Gap {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Gap(nil), r.gaps...)
+}
+
+// Counts returns the number of times each feature was recorded, keyed by the
+// feature's name.
+func (r *Report) Counts() map[string]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := map[string]int{}
+	for _, g := range r.gaps {
+		counts[g.Feature]++
+	}
+	return counts
+}
+
+func (r *Report) add(gaps []Gap) {
+	if len(gaps) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gaps = append(r.gaps, gaps...)
+}
+
+// WithReport causes Parse to produce a best effort Selector, rather than an
+// error, when a selector uses a feature this package doesn't support, and to
+// record each such feature in r:
+//
+//	var r css.Report
+//	for _, s := range corpus {
+//		css.Parse(s, css.WithReport(&r))
+//	}
+//	fmt.Println(r.Counts()) // map[:hover:12 ::before:3]
+//
+// Unsupported pseudo-classes and pseudo-elements are handled according to
+// the UnknownPseudoPolicy, except that the default policy is treated as
+// UnknownPseudoNeverMatch. Unsupported combinators, and pseudo-elements in
+// unsupported positions, never match. Unresolved namespace prefixes and
+// selectors dropped from :is() are recorded as well. Every gap is also
+// reported as a Warning, so combining WithReport with WithStrict records the
+// gaps and returns an error.
+//
+// Other errors, such as invalid syntax, still cause Parse to fail. Gaps found
+// before the error are recorded.
+func WithReport(r *Report) Option {
+	return func(o *options) {
+		o.report = r
+	}
+}
+
+// gapf reports a feature that was ignored or downgraded as a warning, also
+// recording it for the Report if one was provided.
+func (c *compiler) gapf(pos int, kind GapKind, feature string, msg string, v ...interface{}) {
+	c.warnf(pos, msg, v...)
+	if c.opts.report != nil {
+		c.gaps = append(c.gaps, Gap{
+			Selector: c.src,
+			Pos:      pos,
+			Kind:     kind,
+			Feature:  feature,
+			Msg:      fmt.Sprintf(msg, v...),
+		})
+	}
+}
+
+// unknownPseudoPolicy returns the policy for handling unsupported
+// pseudo-classes and pseudo-elements.
+func (c *compiler) unknownPseudoPolicy() UnknownPseudoPolicy {
+	if c.opts.unknownPseudo == UnknownPseudoError && c.opts.report != nil {
+		return UnknownPseudoNeverMatch
+	}
+	return c.opts.unknownPseudo
+}
+
+// pseudoClassFeature returns the name of a pseudo-class for a Gap, such as
+// ":hover" or ":dir()".
+func pseudoClassFeature(s *pseudoClassSelector) string {
+	if s.function != "" {
+		return ":" + s.function + ")"
+	}
+	return ":" + s.ident
+}
+
+// neverCombinator is used in place of an unsupported combinator, and never
+// matches.
+type neverCombinator struct{}
+
+func (neverCombinator) match(s *state, n *html.Node, next func(n *html.Node) bool) bool {
+	return false
+}
@@ -0,0 +1,117 @@
+package css
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestReport(t *testing.T) {
+	var r Report
+	sels := []string{
+		"a:hover",
+		"p::before, p",
+		"foo|a, b:dir(ltr)",
+		"col || td, td",
+		":is(a, b:hover, 1)",
+		"a::before:hover, a:playing",
+		"svg|circle",
+	}
+	for _, s := range sels {
+		if _, err := Parse(s, WithReport(&r)); err != nil {
+			t.Errorf("Parse(%q, WithReport()) failed: %v", s, err)
+		}
+	}
+
+	var got []string
+	for _, g := range r.Gaps() {
+		got = append(got, g.Selector+" "+string(g.Kind)+" "+g.Feature)
+		if g.Kind == GapPseudoClass && !strings.HasPrefix(g.Selector[g.Pos:], g.Feature[:len(g.Feature)-1]) {
+			t.Errorf("Gap %+v has unexpected position", g)
+		}
+	}
+	want := []string{
+		"a:hover pseudo-class :hover",
+		"p::before, p pseudo-element ::before",
+		"foo|a, b:dir(ltr) namespace foo|",
+		"foo|a, b:dir(ltr) pseudo-class :dir()",
+		"col || td, td combinator ||",
+		":is(a, b:hover, 1) argument :is()",
+		":is(a, b:hover, 1) pseudo-class :hover",
+		"a::before:hover, a:playing pseudo-element :hover",
+		"a::before:hover, a:playing pseudo-class :playing",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Report.Gaps() returned diff (-want, +got): %s", diff)
+	}
+
+	wantCounts := map[string]int{
+		":hover": 3, "::before": 1, "foo|": 1, ":dir()": 1, "||": 1, ":is()": 1, ":playing": 1,
+	}
+	if diff := cmp.Diff(wantCounts, r.Counts()); diff != "" {
+		t.Errorf("Report.Counts() returned diff (-want, +got): %s", diff)
+	}
+
+	b, err := json.Marshal(r.Gaps()[0])
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+	wantJSON := `{"selector":"a:hover","pos":1,"kind":"pseudo-class","feature":":hover","msg":"unsupported pseudo-class selector never matches: hover"}`
+	if string(b) != wantJSON {
+		t.Errorf("json.Marshal(Gap) = %s, want %s", b, wantJSON)
+	}
+}
+
+func TestReportBestEffort(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<table><col><tr><td>a</td></tr></table><a>b</a>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	tests := []struct {
+		sel  string
+		opts []Option
+		want []string
+	}{
+		{"col || td, td", nil, []string{"<td>a</td>"}},
+		{"a:hover", nil, []string{}},
+		{"a:hover", []Option{WithUnknownPseudo(UnknownPseudoAlwaysMatch)}, []string{"<a>b</a>"}},
+		{"a::before:hover, a", nil, []string{"<a>b</a>"}},
+	}
+	for _, test := range tests {
+		if _, err := Parse(test.sel, test.opts...); err == nil && len(test.opts) == 0 {
+			t.Errorf("Parse(%q) succeeded without WithReport()", test.sel)
+		}
+		var r Report
+		sel, err := Parse(test.sel, append(test.opts, WithReport(&r))...)
+		if err != nil {
+			t.Errorf("Parse(%q, WithReport()) failed: %v", test.sel, err)
+			continue
+		}
+		if diff := cmp.Diff(test.want, renderNodes(t, sel.Select(root))); diff != "" {
+			t.Errorf("Parse(%q, WithReport()) returned diff (-want, +got): %s", test.sel, diff)
+		}
+		if len(sel.Warnings()) != len(r.Gaps()) {
+			t.Errorf("Parse(%q, WithReport()) returned %d warnings and %d gaps", test.sel, len(sel.Warnings()), len(r.Gaps()))
+		}
+	}
+
+	// Gaps are recorded even when an error prevents a selector from being
+	// returned.
+	var r Report
+	if _, err := Parse("a:hover, b:nth-child(x)", WithReport(&r)); err == nil {
+		t.Errorf("Parse() of invalid selector succeeded")
+	}
+	if len(r.Gaps()) != 1 {
+		t.Errorf("Parse() of invalid selector recorded %d gaps, want 1", len(r.Gaps()))
+	}
+	r = Report{}
+	if _, err := Parse("a:hover", WithReport(&r), WithStrict()); err == nil {
+		t.Errorf("Parse() with WithStrict() succeeded")
+	}
+	if len(r.Gaps()) != 1 {
+		t.Errorf("Parse() with WithStrict() recorded %d gaps, want 1", len(r.Gaps()))
+	}
+}
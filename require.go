@@ -0,0 +1,80 @@
+package css
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	// ErrNoMatches is wrapped by errors returned when a selector that must
+	// match doesn't match any elements.
+	ErrNoMatches = errors.New("css: no matches")
+	// ErrMultipleMatches is wrapped by errors returned by SelectOne when the
+	// selector matches more than one element.
+	ErrMultipleMatches = errors.New("css: multiple matches")
+)
+
+// MatchCountError is returned when a selector doesn't match the required
+// number of elements. It wraps ErrNoMatches or ErrMultipleMatches.
+type MatchCountError struct {
+	// Selector is the serialized selector.
+	Selector string
+	// Count is the number of elements matched.
+	Count int
+}
+
+// Error returns a formatted version of the error.
+func (e *MatchCountError) Error() string {
+	if e.Count == 0 {
+		return fmt.Sprintf("css: selector %q matched no elements", e.Selector)
+	}
+	return fmt.Sprintf("css: selector %q matched %d elements, want 1", e.Selector, e.Count)
+}
+
+// Unwrap returns ErrNoMatches if no elements were matched, and
+// ErrMultipleMatches otherwise.
+func (e *MatchCountError) Unwrap() error {
+	if e.Count == 0 {
+		return ErrNoMatches
+	}
+	return ErrMultipleMatches
+}
+
+// SelectOne returns the only element matching the selector, or a
+// *MatchCountError if there are no matches or more than one. Scrapers can use
+// it to fail loudly when the markup of a page changes, rather than silently
+// extracting nothing:
+//
+//	title, err := css.MustParse("h1.title").SelectOne(root)
+//	if errors.Is(err, css.ErrNoMatches) {
+//		// The page layout changed.
+//	}
+//
+// Like SelectChecked, SelectOne returns a *RootError if n is nil or isn't a
+// document or element node.
+func (s *Selector) SelectOne(n *html.Node) (*html.Node, error) {
+	nodes, err := s.SelectChecked(n)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) != 1 {
+		return nil, &MatchCountError{Selector: s.String(), Count: len(nodes)}
+	}
+	return nodes[0], nil
+}
+
+// SelectRequired is like Select, but returns a *MatchCountError wrapping
+// ErrNoMatches if there are no matches, and a *RootError if n is nil or isn't
+// a document or element node.
+func (s *Selector) SelectRequired(n *html.Node) ([]*html.Node, error) {
+	nodes, err := s.SelectChecked(n)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, &MatchCountError{Selector: s.String()}
+	}
+	return nodes, nil
+}
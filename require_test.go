@@ -0,0 +1,76 @@
+package css
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestSelectOne(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<h1 class="title">Title</h1><p>1</p><p>2</p>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	n, err := MustParse("h1.title").SelectOne(root)
+	if err != nil {
+		t.Fatalf("SelectOne() failed: %v", err)
+	}
+	if n.Data != "h1" {
+		t.Errorf("SelectOne() returned <%s>, want <h1>", n.Data)
+	}
+
+	tests := []struct {
+		sel     string
+		wantErr error
+		msg     string
+	}{
+		{"h2", ErrNoMatches, `css: selector "h2" matched no elements`},
+		{"p", ErrMultipleMatches, `css: selector "p" matched 2 elements, want 1`},
+	}
+	for _, test := range tests {
+		n, err := MustParse(test.sel).SelectOne(root)
+		if err == nil {
+			t.Errorf("SelectOne(%q) returned %v, want error", test.sel, n)
+			continue
+		}
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("SelectOne(%q) returned %v, want error wrapping %v", test.sel, err, test.wantErr)
+		}
+		var cerr *MatchCountError
+		if !errors.As(err, &cerr) {
+			t.Errorf("SelectOne(%q) returned %T, want *MatchCountError", test.sel, err)
+		}
+		if err.Error() != test.msg {
+			t.Errorf("SelectOne(%q) returned error %q, want %q", test.sel, err, test.msg)
+		}
+	}
+
+	var rerr *RootError
+	if _, err := MustParse("p").SelectOne(nil); !errors.As(err, &rerr) {
+		t.Errorf("SelectOne(nil) returned %v, want *RootError", err)
+	}
+}
+
+func TestSelectRequired(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<p>1</p><p>2</p>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	nodes, err := MustParse("p").SelectRequired(root)
+	if err != nil {
+		t.Fatalf("SelectRequired() failed: %v", err)
+	}
+	if len(nodes) != 2 {
+		t.Errorf("SelectRequired() returned %d nodes, want 2", len(nodes))
+	}
+	if _, err := MustParse("a").SelectRequired(root); !errors.Is(err, ErrNoMatches) {
+		t.Errorf("SelectRequired() with no matches returned %v, want ErrNoMatches", err)
+	}
+	var rerr *RootError
+	if _, err := MustParse("p").SelectRequired(nil); !errors.As(err, &rerr) {
+		t.Errorf("SelectRequired(nil) returned %v, want *RootError", err)
+	}
+}
@@ -0,0 +1,80 @@
+package css
+
+import (
+	"golang.org/x/net/html"
+)
+
+// SelectLast returns the last element in document order matching the
+// selector, or nil if there are no matches. The tree is searched backwards,
+// so the search stops at the first match found.
+func (s *Selector) SelectLast(n *html.Node) *html.Node {
+	var last *html.Node
+	s.SelectReverse(n, func(n *html.Node) bool {
+		last = n
+		return false
+	})
+	return last
+}
+
+// SelectReverse calls fn for each element matching the selector in reverse
+// document order, stopping if fn returns false. Unlike Select, matches are
+// found lazily by traversing the tree from its last node.
+func (s *Selector) SelectReverse(n *html.Node, fn func(n *html.Node) bool) {
+	if !validRoot(n) {
+		return
+	}
+	if s.pseudo {
+		// Pseudo-elements can represent elements anywhere in the tree, so
+		// results must be computed up front.
+		nodes := s.Select(n)
+		for i := len(nodes) - 1; i >= 0; i-- {
+			if !fn(nodes[i]) {
+				return
+			}
+		}
+		return
+	}
+	st := s.newState(n, &MatchContext{})
+	st.walkReverse(n, func(e *html.Node) bool {
+		if s.match(st, e) {
+			return fn(e)
+		}
+		return true
+	})
+}
+
+// walkReverse calls fn for every element in the tree rooted at n in reverse
+// document order, stopping if fn returns false. It's the reverse of walk.
+func (s *state) walkReverse(n *html.Node, fn func(n *html.Node) bool) bool {
+	if !s.walkTreeReverse(n, fn) {
+		return false
+	}
+	if n == s.root && s.host != nil && !s.ctx.Flatten {
+		return fn(s.host)
+	}
+	return true
+}
+
+func (s *state) walkTreeReverse(n *html.Node, fn func(n *html.Node) bool) bool {
+	if s.pruned(n) {
+		return true
+	}
+	for c := lastElementChild(n); c != nil; c = prevElementSibling(c) {
+		if !s.walkTreeReverse(c, fn) {
+			return false
+		}
+	}
+	if s.ctx.Flatten {
+		if root, ok := s.ctx.ShadowRoots[n]; ok && root != n {
+			for c := lastElementChild(root); c != nil; c = prevElementSibling(c) {
+				if !s.walkTreeReverse(c, fn) {
+					return false
+				}
+			}
+		}
+	}
+	if isElement(n) {
+		return fn(n)
+	}
+	return true
+}
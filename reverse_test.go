@@ -0,0 +1,52 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestSelectReverse(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<table><tr class="row"><td>1</td></tr><tr class="row"><td>2</td></tr><tr><td>3</td></tr></table>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	tests := []string{"tr.row", "td", "*", "tr:nth-child(odd) td", "span"}
+	for _, test := range tests {
+		sel := MustParse(test)
+		all := sel.Select(root)
+
+		var got []*html.Node
+		sel.SelectReverse(root, func(n *html.Node) bool {
+			got = append(got, n)
+			return true
+		})
+		var want []*html.Node
+		for i := len(all) - 1; i >= 0; i-- {
+			want = append(want, all[i])
+		}
+		if diff := cmp.Diff(renderNodes(t, want), renderNodes(t, got)); diff != "" {
+			t.Errorf("SelectReverse(%q) returned diff (-want, +got): %s", test, diff)
+		}
+
+		var wantLast *html.Node
+		if len(all) > 0 {
+			wantLast = all[len(all)-1]
+		}
+		if got := sel.SelectLast(root); got != wantLast {
+			t.Errorf("SelectLast(%q) = %v, want %v", test, got, wantLast)
+		}
+	}
+
+	n := 0
+	MustParse("td").SelectReverse(root, func(*html.Node) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("SelectReverse() called function %d times after it returned false, want 1", n)
+	}
+}
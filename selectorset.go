@@ -0,0 +1,190 @@
+package css
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// SelectorSet is a named collection of selectors loaded from a file, such as
+// the selectors used by a scraper.
+type SelectorSet struct {
+	// Name identifies the set in errors, and is typically the name of the
+	// file it was loaded from.
+	Name    string
+	Entries []SetEntry
+}
+
+// SetEntry is a selector within a SelectorSet.
+type SetEntry struct {
+	// Key names the selector, and is empty if the line didn't provide a key.
+	Key string
+	// Line is the 1-based line the selector was read from.
+	Line     int
+	Selector *Selector
+}
+
+// SetError records a selector within a set that failed to parse.
+type SetError struct {
+	// Name is the name of the set, and Line and Column are the 1-based
+	// position of the error within the set.
+	Name   string
+	Line   int
+	Column int
+	Err    error
+}
+
+// Error returns a formatted version of the error.
+func (e *SetError) Error() string {
+	msg := e.Err.Error()
+	var perr *ParseError
+	if errors.As(e.Err, &perr) {
+		msg = perr.Msg
+	}
+	return fmt.Sprintf("css: %s:%d:%d: %s", e.Name, e.Line, e.Column, msg)
+}
+
+// Unwrap returns the underlying error.
+func (e *SetError) Unwrap() error {
+	return e.Err
+}
+
+// setKey returns the length of the key prefix of line, such as "title = ",
+// and the key itself. Selectors can't start with an identifier followed by
+// "=", so keys are unambiguous.
+func setKey(line string) (n int, key string) {
+	i := 0
+	for i < len(line) {
+		c := line[i]
+		if c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' ||
+			i > 0 && (c == '-' || c == '.' || '0' <= c && c <= '9') {
+			i++
+			continue
+		}
+		break
+	}
+	if i == 0 {
+		return 0, ""
+	}
+	rest := strings.TrimLeft(line[i:], " \t")
+	if !strings.HasPrefix(rest, "=") {
+		return 0, ""
+	}
+	rest = strings.TrimLeft(rest[1:], " \t")
+	return len(line) - len(rest), line[:i]
+}
+
+// LoadSelectorSet reads a set of selectors from r, one per line, so selectors
+// can be kept in a file alongside the code that uses them:
+//
+//	// Selectors for article pages.
+//	title = h1.headline
+//	byline = .author a[rel=author]
+//	article p
+//
+// A line may name its selector with a key, followed by "=". Blank lines and
+// lines starting with "//" are ignored. Options apply to every selector.
+//
+// Every selector is parsed, even if some fail, so a set can be validated in a
+// single call. Errors are joined into a single error, holding a *SetError for
+// each invalid selector or repeated key.
+func LoadSelectorSet(name string, r io.Reader, opts ...Option) (*SelectorSet, error) {
+	var (
+		keys    []string
+		lines   []int
+		columns []int
+		sources []string
+	)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		col := strings.Index(line, trimmed)
+		var key string
+		if size, k := setKey(trimmed); k != "" {
+			key = k
+			col += size
+			trimmed = trimmed[size:]
+		}
+		keys = append(keys, key)
+		lines = append(lines, n)
+		columns = append(columns, col)
+		sources = append(sources, trimmed)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("css: reading %s: %w", name, err)
+	}
+
+	sels, parseErrs := ParseMany(sources, opts...)
+	set := &SelectorSet{Name: name}
+	var errs []error
+	seen := map[string]int{}
+	for i, sel := range sels {
+		if err := parseErrs[i]; err != nil {
+			col := columns[i]
+			var perr *ParseError
+			if errors.As(err, &perr) {
+				col += perr.Pos
+			}
+			errs = append(errs, &SetError{Name: name, Line: lines[i], Column: col + 1, Err: err})
+			continue
+		}
+		if k := keys[i]; k != "" {
+			if prev, ok := seen[k]; ok {
+				err := fmt.Errorf("key %q already defined on line %d", k, prev)
+				errs = append(errs, &SetError{Name: name, Line: lines[i], Column: 1, Err: err})
+				continue
+			}
+			seen[k] = lines[i]
+		}
+		set.Entries = append(set.Entries, SetEntry{Key: keys[i], Line: lines[i], Selector: sel})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return set, nil
+}
+
+// LoadSelectorSetFS is like LoadSelectorSet, but reads the set from the named
+// file in fsys, such as an embed.FS, using the file name as the set's name:
+//
+//	//go:embed selectors.txt
+//	var files embed.FS
+//
+//	var selectors = css.MustLoadSelectorSetFS(files, "selectors.txt")
+func LoadSelectorSetFS(fsys fs.FS, name string, opts ...Option) (*SelectorSet, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("css: %w", err)
+	}
+	defer f.Close()
+	return LoadSelectorSet(name, f, opts...)
+}
+
+// MustLoadSelectorSetFS is like LoadSelectorSetFS, but panics if the set
+// can't be loaded. It's intended for sets embedded in the program, which are
+// validated at startup.
+func MustLoadSelectorSetFS(fsys fs.FS, name string, opts ...Option) *SelectorSet {
+	set, err := LoadSelectorSetFS(fsys, name, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return set
+}
+
+// Get returns the selector with the given key, or nil if the set has no such
+// selector.
+func (s *SelectorSet) Get(key string) *Selector {
+	for _, e := range s.Entries {
+		if e.Key == key && key != "" {
+			return e.Selector
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package css
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadSelectorSet(t *testing.T) {
+	in := `// Selectors for article pages.
+title = h1.headline
+
+byline=.author a[rel=author]
+  article p
+	// Indented comment.
+first_para = article > p:first-of-type
+`
+	set, err := LoadSelectorSet("article.txt", strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("LoadSelectorSet() failed: %v", err)
+	}
+	type entry struct {
+		Key, Selector string
+		Line          int
+	}
+	var got []entry
+	for _, e := range set.Entries {
+		got = append(got, entry{e.Key, e.Selector.String(), e.Line})
+	}
+	want := []entry{
+		{"title", "h1.headline", 2},
+		{"byline", `.author a[rel="author"]`, 4},
+		{"", "article p", 5},
+		{"first_para", "article > p:first-of-type", 7},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("LoadSelectorSet() returned diff (-want, +got): %s", diff)
+	}
+	if set.Name != "article.txt" {
+		t.Errorf("LoadSelectorSet() returned set named %q, want %q", set.Name, "article.txt")
+	}
+	if sel := set.Get("byline"); sel == nil || sel.String() != `.author a[rel="author"]` {
+		t.Errorf("Get(%q) returned %v", "byline", sel)
+	}
+	if sel := set.Get("missing"); sel != nil {
+		t.Errorf("Get(%q) returned %v, want nil", "missing", sel)
+	}
+	if sel := set.Get(""); sel != nil {
+		t.Errorf("Get(%q) returned %v, want nil", "", sel)
+	}
+}
+
+func TestLoadSelectorSetErrors(t *testing.T) {
+	in := `ok = a
+bad = a[
+  b:unknown-pseudo
+ok = c
+d
+`
+	_, err := LoadSelectorSet("set.txt", strings.NewReader(in))
+	if err == nil {
+		t.Fatalf("LoadSelectorSet() succeeded, want errors")
+	}
+	var got []string
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var serr *SetError
+		if !errors.As(err, &serr) {
+			t.Fatalf("LoadSelectorSet() returned %T, want *SetError", err)
+		}
+		got = append(got, err.Error())
+	}
+	want := []string{
+		`css: set.txt:2:9: expected identifier`,
+		`css: set.txt:3:4: unsupported pseudo-class selector: unknown-pseudo`,
+		`css: set.txt:4:1: key "ok" already defined on line 1`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("LoadSelectorSet() returned diff (-want, +got): %s", diff)
+	}
+
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Errorf("LoadSelectorSet() error doesn't wrap a *ParseError")
+	}
+}
+
+func TestLoadSelectorSetFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"selectors.txt": {Data: []byte("links = a[href]\n")},
+		"invalid.txt":   {Data: []byte("a[\n")},
+	}
+	set := MustLoadSelectorSetFS(fsys, "selectors.txt", WithStrict())
+	if set.Name != "selectors.txt" || set.Get("links") == nil {
+		t.Errorf("MustLoadSelectorSetFS() returned unexpected set %+v", set)
+	}
+	if _, err := LoadSelectorSetFS(fsys, "missing.txt"); err == nil {
+		t.Errorf("LoadSelectorSetFS() with missing file succeeded")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("MustLoadSelectorSetFS() with invalid selector didn't panic")
+			}
+		}()
+		MustLoadSelectorSetFS(fsys, "invalid.txt")
+	}()
+}
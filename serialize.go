@@ -0,0 +1,198 @@
+package css
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// String returns the canonical serialization of the selector list. Parsing the
+// returned string produces an equivalent Selector.
+//
+// https://www.w3.org/TR/cssom-1/#serializing-selectors
+func (s *Selector) String() string {
+	var b strings.Builder
+	for i := range s.list {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		writeComplexSelector(&b, &s.list[i])
+	}
+	return b.String()
+}
+
+// Hash returns a stable hash of the selector, computed using FNV-1a over its
+// canonical serialization. Selectors that serialize the same hash the same,
+// regardless of whitespace or escaping in the original strings, allowing
+// selectors to be used as map keys and deduplicated.
+//
+// The order of the selector list and any repeated selectors are ignored, so
+// "a, b" and "b, a, b" produce the same hash. Options passed to Parse aren't
+// included in the hash.
+func (s *Selector) Hash() uint64 {
+	sels := make([]string, len(s.list))
+	for i := range s.list {
+		var b strings.Builder
+		writeComplexSelector(&b, &s.list[i])
+		sels[i] = b.String()
+	}
+	sort.Strings(sels)
+
+	h := fnv.New64a()
+	for i, sel := range sels {
+		if i > 0 && sel == sels[i-1] {
+			continue
+		}
+		// Serialization never produces a NUL byte, so it's safe to use as a
+		// separator.
+		h.Write([]byte(sel))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+func writeComplexSelector(b *strings.Builder, s *complexSelector) {
+	for curr := s; curr != nil; curr = curr.next {
+		writeCompoundSelector(b, &curr.sel)
+		if curr.next == nil {
+			break
+		}
+		if curr.combinator == "" {
+			b.WriteString(" ")
+		} else {
+			b.WriteString(" " + curr.combinator + " ")
+		}
+	}
+}
+
+func writeCompoundSelector(b *strings.Builder, s *compoundSelector) {
+	if t := s.typeSelector; t != nil {
+		if t.hasPrefix {
+			writeNamespacePrefix(b, t.prefix)
+		}
+		if t.value == "*" {
+			b.WriteString("*")
+		} else {
+			writeIdent(b, t.value)
+		}
+	}
+	for _, sc := range s.subClasses {
+		switch {
+		case sc.idSelector != "":
+			b.WriteString("#")
+			writeIdent(b, sc.idSelector)
+		case sc.classSelector != "":
+			b.WriteString(".")
+			writeIdent(b, sc.classSelector)
+		case sc.attributeSelector != nil:
+			writeAttributeSelector(b, sc.attributeSelector)
+		case sc.pseudoClassSelector != nil:
+			writePseudoClassSelector(b, sc.pseudoClassSelector)
+		}
+	}
+	for _, ps := range s.pseudoSelectors {
+		b.WriteString(":")
+		writePseudoClassSelector(b, &ps.element)
+		for i := range ps.classes {
+			writePseudoClassSelector(b, &ps.classes[i])
+		}
+	}
+}
+
+func writeNamespacePrefix(b *strings.Builder, prefix string) {
+	if prefix == "*" {
+		b.WriteString("*")
+	} else if prefix != "" {
+		writeIdent(b, prefix)
+	}
+	b.WriteString("|")
+}
+
+func writeAttributeSelector(b *strings.Builder, s *attributeSelector) {
+	b.WriteString("[")
+	if s.wqName.hasPrefix {
+		writeNamespacePrefix(b, s.wqName.prefix)
+	}
+	writeIdent(b, s.wqName.value)
+	if strings.HasPrefix(s.matcher, ":") {
+		b.WriteString(":")
+		writeIdent(b, s.matcher[1:])
+		b.WriteString("(")
+		b.WriteString(s.val)
+		b.WriteString(")")
+	} else if s.matcher != "" {
+		b.WriteString(s.matcher)
+		writeString(b, s.val)
+	}
+	if s.modifier {
+		b.WriteString(" i")
+	}
+	b.WriteString("]")
+}
+
+func writePseudoClassSelector(b *strings.Builder, s *pseudoClassSelector) {
+	b.WriteString(":")
+	if s.function == "" {
+		writeIdent(b, s.ident)
+		return
+	}
+	writeIdent(b, strings.TrimSuffix(s.function, "("))
+	b.WriteString("(")
+	for _, t := range s.args {
+		b.WriteString(t.raw)
+	}
+	b.WriteString(")")
+}
+
+// writeIdent serializes an identifier, escaping any characters that wouldn't
+// be lexed as part of an <ident-token>.
+//
+// https://www.w3.org/TR/cssom-1/#serialize-an-identifier
+func writeIdent(b *strings.Builder, s string) {
+	for i, r := range s {
+		switch {
+		case r == 0:
+			b.WriteRune('�')
+		case (0x1 <= r && r <= 0x1f) || r == 0x7f:
+			writeCodePoint(b, r)
+		case i == 0 && isDigit(r):
+			writeCodePoint(b, r)
+		case i == 1 && isDigit(r) && s[0] == '-':
+			writeCodePoint(b, r)
+		case i == 0 && r == '-' && len(s) == 1:
+			b.WriteString(`\-`)
+		case isName(r):
+			b.WriteRune(r)
+		default:
+			b.WriteString(`\`)
+			b.WriteRune(r)
+		}
+	}
+}
+
+// writeString serializes a string as a double quoted <string-token>.
+//
+// https://www.w3.org/TR/cssom-1/#serialize-a-string
+func writeString(b *strings.Builder, s string) {
+	b.WriteString(`"`)
+	for _, r := range s {
+		switch {
+		case r == 0:
+			b.WriteRune('�')
+		case (0x1 <= r && r <= 0x1f) || r == 0x7f:
+			writeCodePoint(b, r)
+		case r == '"' || r == '\\':
+			b.WriteString(`\`)
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteString(`"`)
+}
+
+// https://www.w3.org/TR/cssom-1/#serialize-a-character-as-code-point
+func writeCodePoint(b *strings.Builder, r rune) {
+	fmt.Fprintf(b, `\%x `, r)
+}
@@ -0,0 +1,64 @@
+package css
+
+import "testing"
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"a", "a"},
+		{"  a  ,b", "a, b"},
+		{"ns|a", "ns|a"},
+		{"|a", "|a"},
+		{"*|*", "*|*"},
+		{"h1   a", "h1 a"},
+		{"h1>a", "h1 > a"},
+		{"h1~a+b", "h1 ~ a + b"},
+		{"#foo.bar", "#foo.bar"},
+		{`#\31 23`, `#\31 23`},
+		{`.a\.b`, `.a\.b`},
+		{`.\-`, `.\-`},
+		{`.-\31`, `.-\31 `},
+		{"[foo]", "[foo]"},
+		{"[ foo = bar ]", `[foo="bar"]`},
+		{"[*|foo^='bar' i]", `[*|foo^="bar" i]`},
+		{`[title="a \"b\" \\ c"]`, `[title="a \"b\" \\ c"]`},
+		{":first-child", ":first-child"},
+		{":nth-child( 2n + 1 )", ":nth-child( 2n + 1 )"},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.s)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.s, err)
+			continue
+		}
+		if got := s.String(); got != test.want {
+			t.Errorf("Parse(%q).String() got=%q, want=%q", test.s, got, test.want)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"a", "a", true},
+		{"h1>a", "h1 > a", true},
+		{"a, b", "b,a", true},
+		{"a, b, a", "b, a", true},
+		{`#\31 23`, `#\31 23`, true},
+		{"[foo='bar']", `[foo="bar"]`, true},
+		{"a", "b", false},
+		{"a b", "b a", false},
+		{"a, b", "a, c", false},
+		{"a b", "a > b", false},
+	}
+	for _, test := range tests {
+		a, b := MustParse(test.a).Hash(), MustParse(test.b).Hash()
+		if same := a == b; same != test.same {
+			t.Errorf("Hash(%q) = %x, Hash(%q) = %x, want equal=%t", test.a, a, test.b, b, test.same)
+		}
+	}
+}
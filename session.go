@@ -0,0 +1,143 @@
+package css
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Session evaluates selectors against a single document, caching values
+// computed about its nodes, such as sibling indexes and class sets, so they
+// can be reused by every selector run through the session.
+//
+// The cache assumes the document doesn't change. Call Reset after modifying
+// the tree. Session isn't safe for concurrent use.
+type Session struct {
+	root *html.Node
+	ctx  MatchContext
+
+	indexes   map[*html.Node]siblingIndex
+	classSets map[*html.Node]map[string]bool
+	lower     map[*html.Node][]html.Attribute
+}
+
+// NewSession returns a session for the tree rooted at n.
+func NewSession(n *html.Node) *Session {
+	return NewSessionWithContext(n, MatchContext{})
+}
+
+// NewSessionWithContext is like NewSession, but evaluates selectors using
+// the additional document state held by ctx.
+func NewSessionWithContext(n *html.Node, ctx MatchContext) *Session {
+	ss := &Session{root: n, ctx: ctx}
+	ss.Reset()
+	return ss
+}
+
+// Root returns the node the session selects from.
+func (ss *Session) Root() *html.Node {
+	return ss.root
+}
+
+// Select returns the elements matched by sel in the session's document, in
+// the same way as sel.Select.
+func (ss *Session) Select(sel *Selector) []*html.Node {
+	st := sel.newState(ss.root, &ss.ctx)
+	st.session = ss
+	return sel.selectState(st, ss.root)
+}
+
+// Reset discards any cached values. It must be called if the document is
+// modified between selections.
+func (ss *Session) Reset() {
+	ss.indexes = map[*html.Node]siblingIndex{}
+	ss.classSets = map[*html.Node]map[string]bool{}
+	ss.lower = map[*html.Node][]html.Attribute{}
+}
+
+// siblingIndex holds the 1-based position of an element among its sibling
+// elements, as used by :nth-child() and related pseudo-classes.
+type siblingIndex struct {
+	child      int
+	lastChild  int
+	ofType     int
+	lastOfType int
+}
+
+// siblingIndex computes the position of n among its siblings. When running
+// in a session, the positions of all siblings are computed and cached
+// together.
+func (s *state) siblingIndex(n *html.Node) siblingIndex {
+	if s.session == nil || n.Parent == nil {
+		return s.computeSiblingIndex(n)
+	}
+	if idx, ok := s.session.indexes[n]; ok {
+		return idx
+	}
+
+	var children []*html.Node
+	for c := firstElementChild(n.Parent); c != nil; c = nextElementSibling(c) {
+		children = append(children, c)
+	}
+	indexes := make([]siblingIndex, len(children))
+	count := map[elementType]int{}
+	for i, c := range children {
+		count[typeOf(c)]++
+		indexes[i].child = i + 1
+		indexes[i].ofType = count[typeOf(c)]
+	}
+	seen := map[elementType]int{}
+	for i := len(children) - 1; i >= 0; i-- {
+		c := children[i]
+		seen[typeOf(c)]++
+		indexes[i].lastChild = len(children) - i
+		indexes[i].lastOfType = seen[typeOf(c)]
+		s.session.indexes[c] = indexes[i]
+	}
+	return s.session.indexes[n]
+}
+
+func (s *state) computeSiblingIndex(n *html.Node) siblingIndex {
+	idx := siblingIndex{1, 1, 1, 1}
+	for sib := s.prevElementSibling(n); sib != nil; sib = s.prevElementSibling(sib) {
+		idx.child++
+		if sameType(sib, n) {
+			idx.ofType++
+		}
+	}
+	for sib := s.nextElementSibling(n); sib != nil; sib = s.nextElementSibling(sib) {
+		idx.lastChild++
+		if sameType(sib, n) {
+			idx.lastOfType++
+		}
+	}
+	return idx
+}
+
+// classes returns the set of classes held by n's class attribute.
+func (ss *Session) classes(n *html.Node) map[string]bool {
+	if set, ok := ss.classSets[n]; ok {
+		return set
+	}
+	set := map[string]bool{}
+	for _, a := range n.Attr {
+		if a.Key == "class" {
+			for _, val := range strings.Fields(a.Val) {
+				set[val] = true
+			}
+		}
+	}
+	ss.classSets[n] = set
+	return set
+}
+
+// lowerAttrs returns n's attributes with keys and values lowercased, for
+// case-insensitive attribute selectors.
+func (ss *Session) lowerAttrs(n *html.Node) []html.Attribute {
+	if attrs, ok := ss.lower[n]; ok {
+		return attrs
+	}
+	attrs := lowerAttrs(n.Attr)
+	ss.lower[n] = attrs
+	return attrs
+}
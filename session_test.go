@@ -0,0 +1,47 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestSession(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<ul><li class="a b">1</li><li lang="EN">2</li><p>x</p><li class="b">3</li><p>y</p><!-- c --><x-a></x-a>z<x-b></x-b></ul>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	ss := NewSession(root)
+	tests := []string{
+		"li.b",
+		"li.a.b",
+		".c",
+		"li:nth-child(2n+1)",
+		"li:nth-last-child(1)",
+		"p:nth-of-type(2)",
+		"li:nth-last-of-type(odd)",
+		"[lang=en i]",
+		"[LANG=en i]",
+		"ul > li.b:nth-child(4)",
+		"x-b:nth-of-type(1)",
+		"x-a:nth-last-of-type(1)",
+	}
+	for _, test := range tests {
+		sel := MustParse(test)
+		want := sel.Select(root)
+		got := ss.Select(sel)
+		if diff := cmp.Diff(renderNodes(t, want), renderNodes(t, got)); diff != "" {
+			t.Errorf("Session.Select(%q) returned diff (-want, +got): %s", test, diff)
+		}
+	}
+
+	li := MustParse("li.a").Select(root)[0]
+	li.Attr = nil
+	ss.Reset()
+	if got := ss.Select(MustParse("li.a")); len(got) != 0 {
+		t.Errorf("Session.Select() after Reset() returned %d nodes, want 0", len(got))
+	}
+}
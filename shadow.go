@@ -0,0 +1,153 @@
+package css
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:host
+func hostMatcher(s *state, n *html.Node) bool {
+	_, ok := s.ctx.ShadowRoots[n]
+	return ok
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/CSS/:host_function
+func (c *compiler) hostFunc(s *pseudoClassSelector) matchFunc {
+	m := c.compoundArg(s)
+	if m == nil {
+		return nil
+	}
+	// The argument is evaluated against the host, even if it's featureless.
+	m.host = true
+	return func(s *state, n *html.Node) bool {
+		return hostMatcher(s, n) && m.match(s, n)
+	}
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/CSS/::slotted
+func (c *compiler) slotted(s *pseudoClassSelector) pseudoElementMatcher {
+	m := c.compoundArg(s)
+	if m == nil {
+		return nil
+	}
+	return func(s *state, n *html.Node) []*html.Node {
+		var nodes []*html.Node
+		for _, e := range s.assignedElements(n) {
+			if m.match(s, e) {
+				nodes = append(nodes, e)
+			}
+		}
+		return nodes
+	}
+}
+
+// https://developer.mozilla.org/en-US/docs/Web/CSS/::part
+func (c *compiler) part(s *pseudoClassSelector) pseudoElementMatcher {
+	var names []string
+	for _, t := range s.args {
+		switch t.typ {
+		case tokenWhitespace:
+		case tokenIdent:
+			names = append(names, t.s)
+		default:
+			c.errorf(t.pos, "expected part name identifier")
+			return nil
+		}
+	}
+	if len(names) == 0 {
+		c.errorf(s.pos, "expected part name identifier")
+		return nil
+	}
+	return func(s *state, n *html.Node) []*html.Node {
+		root, ok := s.ctx.ShadowRoots[n]
+		if !ok {
+			return nil
+		}
+		var nodes []*html.Node
+		findParts(root, names, &nodes)
+		return nodes
+	}
+}
+
+// findParts appends every element in the tree rooted at n whose "part"
+// attribute contains all the given names. Shadow trees nested within the
+// tree aren't searched.
+func findParts(n *html.Node, names []string, nodes *[]*html.Node) {
+	for c := firstElementChild(n); c != nil; c = nextElementSibling(c) {
+		if v, ok := attr(c, "part"); ok && containsAll(strings.Fields(v), names) {
+			*nodes = append(*nodes, c)
+		}
+		findParts(c, names, nodes)
+	}
+}
+
+func containsAll(list, vals []string) bool {
+	for _, v := range vals {
+		found := false
+		for _, l := range list {
+			if l == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// shadowHost returns the host of the shadow tree containing n, or nil if n
+// isn't in a shadow tree.
+func (s *state) shadowHost(n *html.Node) *html.Node {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if host, ok := s.hosts[p]; ok {
+			return host
+		}
+	}
+	return nil
+}
+
+// assignedElements returns the children of a shadow host that are assigned
+// to the given <slot> element. Elements are assigned to the first slot in the
+// shadow tree with a name matching their "slot" attribute.
+//
+// https://dom.spec.whatwg.org/#find-slottables
+func (s *state) assignedElements(slot *html.Node) []*html.Node {
+	if slot.DataAtom != atom.Slot {
+		return nil
+	}
+	host := s.shadowHost(slot)
+	if host == nil {
+		return nil
+	}
+	name, _ := attr(slot, "name")
+	if first := s.findSlot(s.ctx.ShadowRoots[host], name); first != slot {
+		return nil
+	}
+	var nodes []*html.Node
+	for c := firstElementChild(host); c != nil; c = nextElementSibling(c) {
+		if v, _ := attr(c, "slot"); v == name {
+			nodes = append(nodes, c)
+		}
+	}
+	return nodes
+}
+
+// findSlot returns the first <slot> element with the given name in the tree
+// rooted at n.
+func (s *state) findSlot(n *html.Node, name string) *html.Node {
+	for c := firstElementChild(n); c != nil; c = nextElementSibling(c) {
+		if c.DataAtom == atom.Slot {
+			if v, _ := attr(c, "name"); v == name {
+				return c
+			}
+		}
+		if slot := s.findSlot(c, name); slot != nil {
+			return slot
+		}
+	}
+	return nil
+}
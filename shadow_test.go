@@ -0,0 +1,211 @@
+package css
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// parseShadowTree parses the contents of a shadow tree into a new shadow root.
+func parseShadowTree(t *testing.T, s string) *html.Node {
+	t.Helper()
+	root := &html.Node{Type: html.DocumentNode}
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(s), context)
+	if err != nil {
+		t.Fatalf("html.ParseFragment(%q) failed: %v", s, err)
+	}
+	for _, n := range nodes {
+		root.AppendChild(n)
+	}
+	return root
+}
+
+func renderNodes(t *testing.T, nodes []*html.Node) []string {
+	t.Helper()
+	got := []string{}
+	for _, n := range nodes {
+		b := &bytes.Buffer{}
+		if err := html.Render(b, n); err != nil {
+			t.Fatalf("html.Render() failed: %v", err)
+		}
+		got = append(got, b.String())
+	}
+	return got
+}
+
+func TestShadowDOM(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(
+		`<x-card id="card"><span slot="title">Title</span><p>Body</p></x-card>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	host := MustParse("x-card").Select(doc)[0]
+	shadowRoot := parseShadowTree(t,
+		`<header><slot name="title"></slot></header><div class="content"><slot></slot></div>`)
+	roots := map[*html.Node]*html.Node{host: shadowRoot}
+
+	hostHTML := `<x-card id="card"><span slot="title">Title</span><p>Body</p></x-card>`
+	tests := []struct {
+		sel     string
+		root    *html.Node
+		flatten bool
+		want    []string
+	}{
+		{":host", doc, false, []string{hostHTML}},
+		{":host", shadowRoot, false, []string{hostHTML}},
+		{"x-card", shadowRoot, false, []string{}},
+		{":host(#card)", shadowRoot, false, []string{hostHTML}},
+		{":host(#other)", shadowRoot, false, []string{}},
+		{":host > header", shadowRoot, false, []string{
+			`<header><slot name="title"></slot></header>`,
+		}},
+		{":host(x-card) slot", shadowRoot, false, []string{
+			`<slot name="title"></slot>`,
+			`<slot></slot>`,
+		}},
+		{"x-card slot", shadowRoot, false, []string{}},
+		{"slot::slotted(span)", shadowRoot, false, []string{
+			`<span slot="title">Title</span>`,
+		}},
+		{"slot::slotted(*)", shadowRoot, false, []string{
+			`<span slot="title">Title</span>`,
+			`<p>Body</p>`,
+		}},
+		{".content > slot::slotted(*)", shadowRoot, false, []string{
+			`<p>Body</p>`,
+		}},
+		{"div::slotted(*)", shadowRoot, false, []string{}},
+		{"x-card header", doc, false, []string{}},
+		{"x-card header", doc, true, []string{
+			`<header><slot name="title"></slot></header>`,
+		}},
+		{"x-card > *", doc, true, []string{
+			`<header><slot name="title"></slot></header>`,
+			`<div class="content"><slot></slot></div>`,
+			`<span slot="title">Title</span>`,
+			`<p>Body</p>`,
+		}},
+		{"p, slot::slotted(span)", doc, true, []string{
+			`<span slot="title">Title</span>`,
+			`<p>Body</p>`,
+		}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		ctx := MatchContext{ShadowRoots: roots, Flatten: test.flatten}
+		got := renderNodes(t, s.SelectWithContext(test.root, ctx))
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Selecting %q (flatten=%t) returned diff (-want, +got): %s", test.sel, test.flatten, diff)
+		}
+	}
+}
+
+func TestPart(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(
+		`<x-button id="a"></x-button><x-button id="b"></x-button><p part="label"></p>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	hosts := MustParse("x-button").Select(doc)
+	nested := parseShadowTree(t, `<i part="label"></i>`)
+	roots := map[*html.Node]*html.Node{
+		hosts[0]: parseShadowTree(t, `<span part="label icon">A</span><b part="labels">B</b>`),
+		hosts[1]: parseShadowTree(t, `<div><em part="label">C</em><x-icon></x-icon></div>`),
+	}
+	icon := MustParse("x-icon").Select(roots[hosts[1]])[0]
+	roots[icon] = nested
+
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{"x-button::part(label)", []string{
+			`<span part="label icon">A</span>`,
+			`<em part="label">C</em>`,
+		}},
+		{"#a::part(icon label)", []string{
+			`<span part="label icon">A</span>`,
+		}},
+		{"#b::part(icon label)", []string{}},
+		{"::part(labels)", []string{
+			`<b part="labels">B</b>`,
+		}},
+		{"p::part(label)", []string{}},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		got := renderNodes(t, s.SelectWithContext(doc, MatchContext{ShadowRoots: roots}))
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Selecting %q returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+}
+
+func TestPseudoElementErrors(t *testing.T) {
+	tests := []string{
+		"a::before",
+		"a:before",
+		"a:AFTER",
+		"slot::slotted(span) p",
+		"slot::slotted()",
+		"slot::slotted(a b)",
+		"slot::slotted(span):hover",
+		":host(a > b)",
+		"a::part()",
+		"a::part(1)",
+		"a::part(b, c)",
+	}
+	for _, test := range tests {
+		if _, err := Parse(test); err == nil {
+			t.Errorf("Expected Parse(%q) to fail", test)
+		}
+	}
+}
+
+func TestDefined(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<div id="div"></div>
+<my-card id="card"></my-card><my-menu id="menu"></my-menu>
+<button id="fancy" is="fancy-button"></button><button id="plain" is="plain-button"></button>
+<font-face id="reserved"></font-face>
+<svg><my-shape id="shape"></my-shape></svg>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	defined := map[string]bool{"my-card": true, "fancy-button": true}
+	tests := []struct {
+		sel  string
+		ctx  MatchContext
+		want []string
+	}{
+		{
+			"body :defined",
+			MatchContext{Defined: func(name string) bool { return defined[name] }},
+			[]string{"div", "card", "fancy", "reserved", "", "shape"},
+		},
+		{"body :not(:defined)", MatchContext{}, []string{"card", "menu", "fancy", "plain"}},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, n := range MustParse(test.sel).SelectWithContext(root, test.ctx) {
+			id, _ := attr(n, "id")
+			got = append(got, id)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+}
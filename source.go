@@ -0,0 +1,262 @@
+package css
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Source is a parsed HTML document that records where each element was
+// written in the input, so tools can splice or highlight the original source
+// rather than re-rendering the tree.
+type Source struct {
+	src   []byte
+	root  *html.Node
+	spans map[*html.Node]span
+}
+
+type span struct {
+	start, end int
+}
+
+// SourceMatch is an element selected from a Source.
+type SourceMatch struct {
+	Node *html.Node
+	// Start and End are the byte offsets of the element within the input,
+	// from the start of its start tag to the end of its end tag. Both are -1
+	// if the element wasn't written in the input, such as an implied body
+	// element.
+	Start, End int
+}
+
+// sourceTag is a start or end tag token read from the input.
+type sourceTag struct {
+	start, end int
+	name       string
+	endTag     bool
+	// selfClosing is set for start tags such as "<br/>".
+	selfClosing bool
+	attr        []html.Attribute
+	consumed    bool
+}
+
+// impliedElements are elements the parser commonly creates without a start
+// tag. They're only associated with a start tag that immediately follows the
+// last tag matched.
+var impliedElements = map[atom.Atom]bool{
+	atom.Html:     true,
+	atom.Head:     true,
+	atom.Body:     true,
+	atom.Tbody:    true,
+	atom.Tr:       true,
+	atom.Colgroup: true,
+}
+
+// formattingElements are reopened by the parser when they're misnested, such
+// as a b element spanning several paragraphs. The copies have no start tag,
+// so like implied elements, they're only associated with the next tag.
+//
+// https://html.spec.whatwg.org/multipage/parsing.html#formatting
+var formattingElements = map[atom.Atom]bool{
+	atom.A: true, atom.B: true, atom.Big: true, atom.Code: true, atom.Em: true,
+	atom.Font: true, atom.I: true, atom.Nobr: true, atom.S: true, atom.Small: true,
+	atom.Strike: true, atom.Strong: true, atom.Tt: true, atom.U: true,
+}
+
+// ParseSource parses an HTML document from r, like html.Parse, recording the
+// byte offsets of each element within the input.
+//
+// Offsets are found by tokenizing the input alongside the parser and
+// associating tags with the elements they created. Elements created without
+// a tag, such as an implied tbody, or the copies the parser makes of
+// misnested formatting elements, have no offsets. Elements whose end tag is
+// omitted, such as a p closed by a following div, end where the tag that
+// closed them starts. In malformed documents where the parser moves elements,
+// such as content misplaced within a table, some elements may have no
+// offsets.
+func ParseSource(r io.Reader) (*Source, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	root, err := html.Parse(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	s := &Source{src: src, root: root, spans: map[*html.Node]span{}}
+	s.locate(sourceTags(src))
+	return s, nil
+}
+
+// sourceTags returns the start and end tags of src.
+func sourceTags(src []byte) []sourceTag {
+	var tags []sourceTag
+	z := html.NewTokenizer(bytes.NewReader(src))
+	offset := 0
+	for {
+		tt := z.Next()
+		start := offset
+		offset += len(z.Raw())
+		switch tt {
+		case html.ErrorToken:
+			return tags
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			tags = append(tags, sourceTag{
+				start:       start,
+				end:         offset,
+				name:        tok.Data,
+				selfClosing: tt == html.SelfClosingTagToken,
+				attr:        tok.Attr,
+			})
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tags = append(tags, sourceTag{start: start, end: offset, name: string(name), endTag: true})
+		}
+	}
+}
+
+// locate records the span of each element, first associating start tags
+// with elements in document order, then end tags in post-order, so that an
+// element's end tag is found after those of its descendants.
+func (s *Source) locate(tags []sourceTag) {
+	startTag := map[*html.Node]int{}
+	var (
+		// first is the first unconsumed start tag, and next is the tag following
+		// the last tag associated with an element.
+		first, next int
+		walk        func(n *html.Node)
+	)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			strict := impliedElements[n.DataAtom] || formattingElements[n.DataAtom]
+			from := first
+			if strict {
+				from = next
+			}
+			for i := from; i < len(tags); i++ {
+				t := &tags[i]
+				if t.endTag || t.consumed {
+					continue
+				}
+				if strings.EqualFold(t.name, n.Data) && (strict || sameValues(t.attr, n.Attr)) {
+					t.consumed = true
+					startTag[n] = i
+					next = i + 1
+				}
+				if strict || t.consumed {
+					break
+				}
+			}
+			for first < len(tags) && (tags[first].endTag || tags[first].consumed) {
+				first++
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(s.root)
+
+	// last returns the index of the last tag associated with n or its
+	// descendants, or -1 if there are none.
+	var last func(n *html.Node) int
+	last = func(n *html.Node) int {
+		end := -1
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			end = max(end, last(c))
+		}
+		i, ok := startTag[n]
+		if !ok {
+			return end
+		}
+		t := tags[i]
+		sp := span{t.start, t.end}
+		end = max(end, i)
+		if !t.selfClosing && !voidElements[n.DataAtom] {
+			sp.end = len(s.src)
+			for j := end + 1; j < len(tags); j++ {
+				t := &tags[j]
+				if t.endTag && !t.consumed && strings.EqualFold(t.name, n.Data) {
+					t.consumed = true
+					sp.end = t.end
+					end = j
+					break
+				}
+				if !t.endTag || hasAncestor(n, t.name) {
+					// The element was closed implicitly by this tag.
+					sp.end = t.start
+					break
+				}
+			}
+		}
+		s.spans[n] = sp
+		return end
+	}
+	last(s.root)
+}
+
+// sameValues reports if two lists of attributes have the same values. Keys
+// aren't compared, since the parser adjusts the keys of foreign elements.
+func sameValues(a, b []html.Attribute) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Val != b[i].Val {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAncestor reports if an ancestor of n has the given name.
+func hasAncestor(n *html.Node, name string) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && strings.EqualFold(p.Data, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Root returns the root of the parsed document.
+func (s *Source) Root() *html.Node {
+	return s.root
+}
+
+// Bytes returns the input the document was parsed from.
+func (s *Source) Bytes() []byte {
+	return s.src
+}
+
+// Offsets returns the byte offsets of the element n within the input, or
+// false if n wasn't written in the input.
+func (s *Source) Offsets(n *html.Node) (start, end int, ok bool) {
+	sp, ok := s.spans[n]
+	if !ok {
+		return -1, -1, false
+	}
+	return sp.start, sp.end, true
+}
+
+// Select returns the elements of the document matched by the selector, along
+// with their offsets:
+//
+//	src, err := css.ParseSource(r)
+//	...
+//	for _, m := range src.Select(sel) {
+//		fmt.Printf("%d-%d: %s\n", m.Start, m.End, src.Bytes()[m.Start:m.End])
+//	}
+func (s *Source) Select(sel *Selector) []SourceMatch {
+	nodes := sel.Select(s.root)
+	matches := make([]SourceMatch, len(nodes))
+	for i, n := range nodes {
+		start, end, _ := s.Offsets(n)
+		matches[i] = SourceMatch{Node: n, Start: start, End: end}
+	}
+	return matches
+}
@@ -0,0 +1,83 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		sel  string
+		in   string
+		want []string
+	}{
+		{"p", `<p>a</p><p class="x">b</p>`, []string{`<p>a</p>`, `<p class="x">b</p>`}},
+		{"div", `<div><div>inner</div></div>`, []string{`<div><div>inner</div></div>`, `<div>inner</div>`}},
+		{"div", `<DIV>upper</Div>`, []string{`<DIV>upper</Div>`}},
+		{"li", "<ul><li>a\n<li>b</ul>", []string{"<li>a\n", "<li>b"}},
+		{"p", `<div><p>a</div>`, []string{`<p>a`}},
+		{"p", `<p>a<div>b</div>`, []string{`<p>a`}},
+		{"br, img", `<p>a<br>b<img src="x.png"/></p>`, []string{`<br>`, `<img src="x.png"/>`}},
+		{"script", `<script>if (a < b) { "</p>" }</script>`, []string{`<script>if (a < b) { "</p>" }</script>`}},
+		{"body", `<p>a</p>`, []string{""}},
+		{"body", `<head><title>t</title></head><body><p>a</p></body>`, []string{`<body><p>a</p></body>`}},
+		{"tbody, tr, td", `<table><tr><td>a</td></tr></table>`, []string{"", `<tr><td>a</td></tr>`, `<td>a</td>`}},
+		{"b", `<p><b>a<p>b</b>`, []string{`<b>a`, ""}},
+		{"svg, circle", `<svg viewBox="0 0 1 1"><circle r="1"/></svg>`, []string{`<svg viewBox="0 0 1 1"><circle r="1"/></svg>`, `<circle r="1"/>`}},
+		{"span", `<p>é<span>日本</span></p>`, []string{`<span>日本</span>`}},
+	}
+	for _, test := range tests {
+		src, err := ParseSource(strings.NewReader(test.in))
+		if err != nil {
+			t.Fatalf("ParseSource(%q) failed: %v", test.in, err)
+		}
+		var got []string
+		for _, m := range src.Select(MustParse(test.sel)) {
+			if m.Start < 0 {
+				if m.End >= 0 {
+					t.Errorf("ParseSource(%q) returned start %d and end %d for %s", test.in, m.Start, m.End, describeNode(m.Node))
+				}
+				got = append(got, "")
+				continue
+			}
+			got = append(got, string(src.Bytes()[m.Start:m.End]))
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("ParseSource(%q).Select(%q) returned diff (-want, +got): %s", test.in, test.sel, diff)
+		}
+	}
+}
+
+func TestParseSourceOffsets(t *testing.T) {
+	in := `<p id="a">one</p>`
+	src, err := ParseSource(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseSource() failed: %v", err)
+	}
+	p := MustParse("#a").Select(src.Root())[0]
+	if start, end, ok := src.Offsets(p); !ok || start != 0 || end != len(in) {
+		t.Errorf("Offsets(p) = %d, %d, %t, want 0, %d, true", start, end, ok, len(in))
+	}
+	if _, _, ok := src.Offsets(src.Root()); ok {
+		t.Errorf("Offsets(document) returned offsets for the document node")
+	}
+}
+
+func TestParseSourceSpans(t *testing.T) {
+	for _, test := range selectorTests {
+		src, err := ParseSource(strings.NewReader(test.in))
+		if err != nil {
+			t.Fatalf("ParseSource(%q) failed: %v", test.in, err)
+		}
+		for _, m := range src.Select(MustParse("*")) {
+			if m.Start < 0 {
+				continue
+			}
+			if m.Start > m.End || m.End > len(test.in) || test.in[m.Start] != '<' {
+				t.Errorf("ParseSource(%q) returned invalid offsets %d, %d for %s", test.in, m.Start, m.End, describeNode(m.Node))
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package css
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// StreamMatch is an element found by Grep.
+type StreamMatch struct {
+	// Offset is the byte offset of the element's start tag within the input,
+	// and Line is the 1-based line the start tag begins on.
+	Offset int64
+	Line   int
+	// Raw is the element's start tag, as written in the input.
+	Raw string
+	// Node holds the element's name and attributes. Its ancestors can be
+	// found through Parent, but it has no children or siblings.
+	Node *html.Node
+}
+
+// Grep scans an HTML document from r, calling fn for every element that
+// matches the selector in document order, without parsing the document into
+// a tree. Memory use is proportional to the depth of the document rather
+// than its size, making Grep suitable for searching very large files, such
+// as crawl dumps. Grep stops if fn returns false.
+//
+// Only selectors that can be evaluated from an element's start tag and the
+// start tags of its ancestors are supported: type, id, class and attribute
+// selectors without namespace prefixes, joined by descendant or child
+// combinators. Pseudo-classes, pseudo-elements and sibling combinators are an
+// error. WithExclude isn't applied.
+//
+// Because Grep doesn't implement the HTML tree construction algorithm,
+// ancestors are inferred from start and end tags. Void elements, and common
+// optional end tags such as those of p and li elements, are handled, but
+// malformed documents may produce different ancestors than html.Parse.
+func Grep(r io.Reader, sel *Selector, fn func(m *StreamMatch) bool) error {
+	if err := sel.streamable(); err != nil {
+		return err
+	}
+
+	doc := &html.Node{Type: html.DocumentNode}
+	st := sel.newState(doc, &MatchContext{})
+	curr := doc
+
+	z := html.NewTokenizer(r)
+	var (
+		offset int64
+		line   = 1
+		raw    []byte
+	)
+	for {
+		tt := z.Next()
+		// Raw is only valid until the token is read, so copy it first.
+		raw = append(raw[:0], z.Raw()...)
+		start, startLine := offset, line
+		offset += int64(len(raw))
+		line += bytes.Count(raw, []byte("\n"))
+
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return err
+			}
+			return nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			curr = closeImplied(curr, tok.DataAtom)
+			n := &html.Node{
+				Type:     html.ElementNode,
+				Data:     tok.Data,
+				DataAtom: tok.DataAtom,
+				Attr:     tok.Attr,
+				Parent:   curr,
+			}
+			if sel.matchElement(st, n) {
+				m := &StreamMatch{Offset: start, Line: startLine, Raw: string(raw), Node: n}
+				if !fn(m) {
+					return nil
+				}
+			}
+			if tt == html.StartTagToken && !voidElements[n.DataAtom] {
+				curr = n
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			for e := curr; e != doc; e = e.Parent {
+				if e.Data == string(name) {
+					curr = e.Parent
+					break
+				}
+			}
+		}
+	}
+}
+
+// streamable returns an error if the selector can't be evaluated by Grep.
+func (s *Selector) streamable() error {
+	for i := range s.list {
+		for curr := &s.list[i]; curr != nil; curr = curr.next {
+			if err := streamableCompound(&curr.sel); err != nil {
+				return fmt.Errorf("css: selector can't be evaluated while streaming: %v", err)
+			}
+			if curr.next == nil {
+				continue
+			}
+			if curr.combinator != "" && curr.combinator != ">" {
+				return fmt.Errorf("css: selector can't be evaluated while streaming: %q combinator isn't supported", curr.combinator)
+			}
+		}
+	}
+	return nil
+}
+
+func streamableCompound(c *compoundSelector) error {
+	if c.typeSelector != nil && c.typeSelector.hasPrefix {
+		return fmt.Errorf("namespace prefixes aren't supported")
+	}
+	for _, sc := range c.subClasses {
+		if sc.pseudoClassSelector != nil {
+			return fmt.Errorf("pseudo-classes aren't supported")
+		}
+		if sc.attributeSelector != nil && sc.attributeSelector.wqName.hasPrefix {
+			return fmt.Errorf("namespace prefixes aren't supported")
+		}
+	}
+	if len(c.pseudoSelectors) > 0 {
+		return fmt.Errorf("pseudo-elements aren't supported")
+	}
+	return nil
+}
+
+// voidElements have no end tag.
+//
+// https://html.spec.whatwg.org/multipage/syntax.html#void-elements
+var voidElements = map[atom.Atom]bool{
+	atom.Area:   true,
+	atom.Base:   true,
+	atom.Br:     true,
+	atom.Col:    true,
+	atom.Embed:  true,
+	atom.Hr:     true,
+	atom.Img:    true,
+	atom.Input:  true,
+	atom.Link:   true,
+	atom.Meta:   true,
+	atom.Param:  true,
+	atom.Source: true,
+	atom.Track:  true,
+	atom.Wbr:    true,
+}
+
+// impliedEndTags maps start tags to the open elements they implicitly close,
+// for the most common optional end tags.
+//
+// https://html.spec.whatwg.org/multipage/syntax.html#optional-tags
+var impliedEndTags = map[atom.Atom][]atom.Atom{
+	atom.Li:     {atom.Li},
+	atom.Dt:     {atom.Dt, atom.Dd},
+	atom.Dd:     {atom.Dt, atom.Dd},
+	atom.Option: {atom.Option},
+	atom.Tr:     {atom.Tr, atom.Td, atom.Th},
+	atom.Td:     {atom.Td, atom.Th},
+	atom.Th:     {atom.Td, atom.Th},
+}
+
+// closesP holds start tags that close an open p element.
+var closesP = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Div: true, atom.Dl: true, atom.Fieldset: true, atom.Footer: true,
+	atom.Form: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true,
+	atom.H5: true, atom.H6: true, atom.Header: true, atom.Hr: true, atom.Main: true,
+	atom.Nav: true, atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true,
+	atom.Table: true, atom.Ul: true,
+}
+
+// closeImplied returns the element a start tag should be appended to, after
+// closing any elements with an implied end tag.
+func closeImplied(curr *html.Node, a atom.Atom) *html.Node {
+	if curr.Type != html.ElementNode {
+		return curr
+	}
+	if curr.DataAtom == atom.P && closesP[a] {
+		return curr.Parent
+	}
+	for _, closes := range impliedEndTags[a] {
+		if curr.DataAtom == closes {
+			// Table cells are closed along with their row.
+			if a == atom.Tr && curr.Parent.DataAtom == atom.Tr {
+				return curr.Parent.Parent
+			}
+			return curr.Parent
+		}
+	}
+	return curr
+}
@@ -0,0 +1,82 @@
+package css
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGrep(t *testing.T) {
+	const doc = `<html>
+<body>
+<ul id="list">
+  <li class="item">one
+  <li class="item">two<br><img src="a.png">
+  <li class="other">three</li>
+</ul>
+<p>para <span class="item">four</span>
+<div><span class="item">five</span></div>
+</body>
+</html>`
+	tests := []struct {
+		sel string
+		// want holds a unique prefix of the source of each matched element.
+		want []string
+	}{
+		{"#list > .item", []string{`<li class="item">one`, `<li class="item">two`}},
+		{"ul img[src$='.png']", []string{`<img src="a.png">`}},
+		{"p > span", []string{`<span class="item">four`}},
+		// The div closes the p, and li elements close each other.
+		{"p div, li li", nil},
+		{"body > div > span", []string{`<span class="item">five`}},
+	}
+	for _, test := range tests {
+		var got []*StreamMatch
+		err := Grep(strings.NewReader(doc), MustParse(test.sel), func(m *StreamMatch) bool {
+			got = append(got, m)
+			return true
+		})
+		if err != nil {
+			t.Errorf("%s: Grep returned error: %v", test.sel, err)
+			continue
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%s: Grep returned %d matches, want %d", test.sel, len(got), len(test.want))
+			continue
+		}
+		for i, m := range got {
+			offset := strings.Index(doc, test.want[i])
+			line := 1 + strings.Count(doc[:offset], "\n")
+			if m.Offset != int64(offset) || m.Line != line {
+				t.Errorf("%s: match %d at offset %d line %d, want offset %d line %d", test.sel, i, m.Offset, m.Line, offset, line)
+			}
+			if !strings.HasPrefix(test.want[i], m.Raw) {
+				t.Errorf("%s: match %d has raw start tag %q, want prefix of %q", test.sel, i, m.Raw, test.want[i])
+			}
+		}
+	}
+
+	n := 0
+	Grep(strings.NewReader(doc), MustParse("li"), func(m *StreamMatch) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("Grep called fn %d times after it returned false, want 1", n)
+	}
+}
+
+func TestGrepUnsupported(t *testing.T) {
+	for _, sel := range []string{
+		"li:first-child",
+		"a + b",
+		"a ~ b",
+		"p::part(label)",
+		"svg|rect",
+		"[xlink|href]",
+	} {
+		err := Grep(strings.NewReader("<a></a>"), MustParse(sel), func(m *StreamMatch) bool { return true })
+		if err == nil {
+			t.Errorf("Grep(%q) succeeded, want error", sel)
+		}
+	}
+}
@@ -0,0 +1,857 @@
+// Package syntax implements the tokenizer described by CSS Syntax Module
+// Level 3.
+//
+// https://www.w3.org/TR/css-syntax-3/
+package syntax
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Lexer implements tokenization for CSS. The algorithm follows the spec
+// recommentations.
+//
+// https://www.w3.org/TR/css-syntax-3/#tokenization
+type Lexer struct {
+	s    string
+	last int
+	pos  int
+
+	// base is the offset of s within the full input. Streaming lexers discard
+	// input once it's been tokenized.
+	base int
+	// r, if non-nil, is read from when more input is needed.
+	r io.Reader
+	// closed is set once the end of the input has been reached.
+	closed bool
+	// short is set if the lexer needed more input than was available.
+	short bool
+	// err holds any error returned by r.
+	err error
+}
+
+// NewLexer returns a lexer that tokenizes s.
+func NewLexer(s string) *Lexer {
+	return &Lexer{s: s, closed: true}
+}
+
+// Reset discards the lexer's state so it tokenizes s, allowing a lexer to be
+// reused across many inputs.
+func (l *Lexer) Reset(s string) {
+	*l = Lexer{s: s, closed: true}
+}
+
+// Tokenize splits s into tokens, not including the final <EOF-token>.
+func Tokenize(s string) ([]Token, error) {
+	l := NewLexer(s)
+	var toks []Token
+	for {
+		t, err := l.Next()
+		if err != nil {
+			return nil, err
+		}
+		if t.Type == EOFToken {
+			return toks, nil
+		}
+		toks = append(toks, t)
+	}
+}
+
+const eof = 0
+
+// peek returns the next code point without consuming it. NUL and bytes that
+// aren't valid UTF-8 are returned as U+FFFD, as if the input had been
+// preprocessed, but are kept as written in token values, the same way
+// golang.org/x/net/html keeps invalid UTF-8 in a document.
+//
+// https://www.w3.org/TR/css-syntax-3/#input-byte-stream
+func (l *Lexer) peek() rune {
+	l.fill()
+	if len(l.s) <= l.pos {
+		return eof
+	}
+	r, _ := utf8.DecodeRuneInString(l.s[l.pos:])
+	if r == 0 {
+		return utf8.RuneError
+	}
+	return r
+}
+
+func (l *Lexer) peekN(n int) rune {
+	pos := l.pos
+	var r rune
+	for i := 0; i <= n; i++ {
+		r = l.pop()
+	}
+	l.pos = pos
+	return r
+}
+
+// push is the equivalent of "reconsume the current input code point". r
+// must be the code point returned by the last call to pop.
+func (l *Lexer) push(r rune) {
+	if r == eof {
+		return
+	}
+	// An invalid byte is popped as U+FFFD, which is longer than the byte.
+	_, n := utf8.DecodeLastRuneInString(l.s[:l.pos])
+	l.pos -= n
+}
+
+func (l *Lexer) pop() rune {
+	l.fill()
+	if len(l.s) <= l.pos {
+		return eof
+	}
+	r, n := utf8.DecodeRuneInString(l.s[l.pos:])
+	l.pos += n
+	if r == 0 {
+		return utf8.RuneError
+	}
+	return r
+}
+
+func (l *Lexer) popN(n int) {
+	for i := 0; i < n; i++ {
+		l.pop()
+	}
+}
+
+// TokenType identifies the kind of a Token.
+type TokenType int
+
+// Create a shorter type aliases so links to csswg.org don't wrap.
+type tt = TokenType
+
+const (
+	_                 tt = iota
+	AtKeywordToken       // https://drafts.csswg.org/css-syntax-3/#typedef-at-keyword-token
+	BracketCloseToken    // https://drafts.csswg.org/css-syntax-3/#tokendef-close-square
+	BracketOpenToken     // https://drafts.csswg.org/css-syntax-3/#tokendef-open-square
+	CDCToken             // https://drafts.csswg.org/css-syntax-3/#typedef-cdc-token
+	CDOToken             // https://drafts.csswg.org/css-syntax-3/#typedef-cdo-token
+	ColonToken           // https://drafts.csswg.org/css-syntax-3/#typedef-colon-token
+	CommaToken           // https://drafts.csswg.org/css-syntax-3/#typedef-comma-token
+	CurlyCloseToken      // https://drafts.csswg.org/css-syntax-3/#tokendef-close-curly
+	CurlyOpenToken       // https://drafts.csswg.org/css-syntax-3/#tokendef-open-curly
+	DelimToken           // https://drafts.csswg.org/css-syntax-3/#typedef-delim-token
+	DimensionToken       // https://drafts.csswg.org/css-syntax-3/#typedef-dimension-token
+	EOFToken             // https://drafts.csswg.org/css-syntax-3/#typedef-eof-token
+	FunctionToken        // https://drafts.csswg.org/css-syntax-3/#typedef-function-token
+	HashToken            // https://drafts.csswg.org/css-syntax-3/#typedef-hash-token
+	IdentToken           // https://www.w3.org/TR/css-syntax-3/#typedef-ident-token
+	NumberToken          // https://drafts.csswg.org/css-syntax-3/#typedef-number-token
+	ParenCloseToken      // https://drafts.csswg.org/css-syntax-3/#tokendef-close-paren
+	ParenOpenToken       // https://drafts.csswg.org/css-syntax-3/#tokendef-open-paren
+	PercentToken         // https://drafts.csswg.org/css-syntax-3/#typedef-percentage-token
+	SemicolonToken       // https://drafts.csswg.org/css-syntax-3/#typedef-semicolon-token
+	StringToken          // https://drafts.csswg.org/css-syntax-3/#typedef-string-token
+	URLToken             // https://drafts.csswg.org/css-syntax-3/#typedef-url-token
+	WhitespaceToken      // https://drafts.csswg.org/css-syntax-3/#typedef-whitespace-token
+)
+
+var tokenTypeString = map[TokenType]string{
+	AtKeywordToken:    "<at-keyword-token>",
+	BracketCloseToken: "<]-token>",
+	BracketOpenToken:  "<[-token>",
+	CDCToken:          "<CDC-token>",
+	CDOToken:          "<CDO-token>",
+	ColonToken:        "<colon-token>",
+	CommaToken:        "<comma-token>",
+	CurlyCloseToken:   "<}-token>",
+	CurlyOpenToken:    "<{-token>",
+	DelimToken:        "<delim-token>",
+	DimensionToken:    "<dimension-token>",
+	EOFToken:          "<eof-token>",
+	FunctionToken:     "<function-token>",
+	HashToken:         "<hash-token>",
+	IdentToken:        "<ident-token>",
+	NumberToken:       "<number-token>",
+	ParenCloseToken:   "<)-token>",
+	ParenOpenToken:    "<(-token>",
+	PercentToken:      "<percentage-token>",
+	SemicolonToken:    "<semicolon-token>",
+	StringToken:       "<string-token>",
+	URLToken:          "<url-token>",
+	WhitespaceToken:   "<whitespace-token>",
+}
+
+func (t TokenType) String() string {
+	if s, ok := tokenTypeString[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("<0x%x-token>", int(t))
+}
+
+// Token is a single CSS token.
+type Token struct {
+	Type TokenType
+	// Raw is the source text of the token.
+	Raw string
+	// Value is the token's value with escapes resolved. For example, the value
+	// of the <string-token> "\66oo" is "foo", and the value of a
+	// <function-token> includes the trailing "(". For <dimension-token>
+	// values, Value holds the number and Unit holds the unit.
+	Value string
+	// Pos is the byte offset of the token in the input.
+	Pos  int
+	Flag Flag
+	// Unit is the unit of a <dimension-token>, such as "px".
+	Unit string
+}
+
+func (t Token) withDim(dim string) Token {
+	t.Unit = dim
+	return t
+}
+
+func (t Token) withString(s string) Token {
+	t.Value = s
+	return t
+}
+
+func (t Token) withFlag(flag Flag) Token {
+	t.Flag = flag
+	return t
+}
+
+// Flag holds "type flag" information about the token.
+//
+// https://www.w3.org/TR/css-syntax-3/#tokenization
+type Flag int
+
+const (
+	FlagNone Flag = iota
+	FlagInteger
+	FlagID
+	FlagNumber
+	FlagUnrestricted
+)
+
+var flagString = map[Flag]string{
+	FlagNone:         "(no flag set)",
+	FlagInteger:      "type=integer",
+	FlagID:           "type=id",
+	FlagNumber:       "type=number",
+	FlagUnrestricted: "type=unrestricted",
+}
+
+func (t Flag) String() string {
+	if s, ok := flagString[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("Flag(0x%x)", int(t))
+}
+
+func (t Token) String() string {
+	return fmt.Sprintf("%s %q pos=%d", t.Type, t.Value, t.Pos)
+}
+
+// IsDelim reports if the token is a <delim-token> with the value s.
+func (t Token) IsDelim(s string) bool {
+	return t.Type == DelimToken && t.Value == s
+}
+
+// IsIdent reports if the token is an <ident-token> with the value s.
+func (t Token) IsIdent(s string) bool {
+	return t.Type == IdentToken && t.Value == s
+}
+
+// Error is returned by the Lexer when the input can't be tokenized.
+type Error struct {
+	Msg string
+	// Start is the byte offset of the token being lexed.
+	Start int
+	// Pos is the byte offset the error was encountered at.
+	Pos int
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+func (l *Lexer) errorf(format string, v ...interface{}) error {
+	return &Error{fmt.Sprintf(format, v...), l.base + l.last, l.base + l.pos}
+}
+
+func (l *Lexer) token(typ TokenType) Token {
+	s := l.s[l.last:l.pos]
+	t := Token{typ, s, s, l.base + l.last, 0, ""}
+	l.last = l.pos
+	return t
+}
+
+// Next returns the next token in the input. Once the input is exhausted, Next
+// returns an <EOF-token>.
+//
+// For lexers returned by NewStreamLexer, Next returns ErrIncomplete if the
+// input appended so far ends partway through a token. The token can be read
+// by calling Next again after appending more input, or closing the lexer.
+func (l *Lexer) Next() (Token, error) {
+	if l.err != nil {
+		return Token{}, l.err
+	}
+	start := l.last
+	t, err := l.next()
+	if l.short {
+		// Rewind and try again once more input is available.
+		l.short = false
+		l.last = start
+		l.pos = start
+		if l.err != nil {
+			return Token{}, l.err
+		}
+		return Token{}, ErrIncomplete
+	}
+	if err != nil {
+		return Token{}, err
+	}
+	// Discard input that's been tokenized.
+	l.base += l.last
+	l.s = l.s[l.last:]
+	l.pos -= l.last
+	l.last = 0
+	return t, nil
+}
+
+// https://www.w3.org/TR/css-syntax-3/#consume-token
+func (l *Lexer) next() (Token, error) {
+	r := l.pop()
+
+	if IsWhitespace(r) {
+		for IsWhitespace(l.peek()) {
+			l.pop()
+		}
+		return l.token(WhitespaceToken), nil
+	}
+
+	if IsDigit(r) {
+		l.push(r)
+		return l.numericToken()
+	}
+
+	if IsNameStart(r) {
+		l.push(r)
+		return l.identLikeToken()
+	}
+
+	switch r {
+	case '"', '\'':
+		return l.string(r)
+	case eof:
+		return l.token(EOFToken), nil
+	case '#':
+		if IsName(l.peek()) || isValidEscape(l.peek(), l.peekN(1)) {
+			s, _, err := l.consumeNameFrom(l.last)
+			if err != nil {
+				return Token{}, err
+			}
+			return l.token(HashToken).withString(s).withFlag(FlagID), nil
+		}
+		return l.token(DelimToken), nil
+	case '(':
+		return l.token(ParenOpenToken), nil
+	case ')':
+		return l.token(ParenCloseToken), nil
+	case '+':
+		if isNumStart(r, l.peek(), l.peekN(1)) {
+			l.push(r)
+			return l.numericToken()
+		}
+		return l.token(DelimToken), nil
+	case ',':
+		return l.token(CommaToken), nil
+	case '-':
+		if isNumStart(r, l.peek(), l.peekN(1)) {
+			l.push(r)
+			return l.numericToken()
+		}
+		if l.peek() == '-' && l.peekN(1) == '>' {
+			l.popN(2)
+			return l.token(CDCToken), nil
+		}
+		if isIdentStart(r, l.peek(), l.peekN(1)) {
+			l.push(r)
+			return l.identLikeToken()
+		}
+		return l.token(DelimToken), nil
+	case '.':
+		if isNumStart(r, l.peek(), l.peekN(1)) {
+			l.push(r)
+			return l.numericToken()
+		}
+		return l.token(DelimToken), nil
+	case ':':
+		return l.token(ColonToken), nil
+	case ';':
+		return l.token(SemicolonToken), nil
+	case '<':
+		if l.peek() == '!' && l.peekN(1) == '-' && l.peekN(2) == '-' {
+			l.popN(3)
+			return l.token(CDOToken), nil
+		}
+		return l.token(DelimToken), nil
+	case '@':
+		if isIdentStart(l.peek(), l.peekN(1), l.peekN(2)) {
+			s, _, err := l.consumeNameFrom(l.last)
+			if err != nil {
+				return Token{}, err
+			}
+			return l.token(AtKeywordToken).withString(s), nil
+		}
+		return l.token(DelimToken), nil
+	case '[':
+		return l.token(BracketOpenToken), nil
+	case '\\':
+		if !isValidEscape(r, l.peek()) {
+			return Token{}, l.errorf("invalid escape character")
+		}
+		l.push(r)
+		return l.identLikeToken()
+	case ']':
+		return l.token(BracketCloseToken), nil
+	case '{':
+		return l.token(CurlyOpenToken), nil
+	case '}':
+		return l.token(CurlyCloseToken), nil
+	}
+	return l.token(DelimToken), nil
+}
+
+// https://www.w3.org/TR/css-syntax-3/#consume-a-string-token
+func (l *Lexer) string(quote rune) (Token, error) {
+	// Strings without escapes are sliced from the input, rather than copied.
+	start := l.pos
+	for {
+		switch r := l.pop(); r {
+		case quote:
+			return l.token(StringToken).withString(l.s[start : l.pos-utf8.RuneLen(quote)]), nil
+		case eof:
+			return Token{}, l.errorf("unexpected eof parsing string")
+		case '\n':
+			return Token{}, l.errorf("unexpected newline parsing string")
+		case '\\':
+			l.push(r)
+			var b strings.Builder
+			b.WriteString(l.s[start:l.pos])
+			return l.escapedString(quote, &b)
+		}
+	}
+}
+
+// escapedString consumes the rest of a string token containing escapes,
+// appending its value to b.
+func (l *Lexer) escapedString(quote rune, b *strings.Builder) (Token, error) {
+	for {
+		switch r := l.pop(); r {
+		case quote:
+			return l.token(StringToken).withString(b.String()), nil
+		case eof:
+			return Token{}, l.errorf("unexpected eof parsing string")
+		case '\n':
+			return Token{}, l.errorf("unexpected newline parsing string")
+		case '\\':
+			switch l.peek() {
+			case eof:
+			case '\n':
+				return Token{}, l.errorf("unexpected newline after '\\' parsing string")
+			default:
+				if err := l.consumeEscape(b); err != nil {
+					return Token{}, l.errorf("parsing string: %v", err)
+				}
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+// https://www.w3.org/TR/css-syntax-3/#consume-an-escaped-code-point
+func (l *Lexer) consumeEscape(b *strings.Builder) error {
+	r := l.pop()
+	if r == eof {
+		return l.errorf("unexpected newline after escape sequence")
+	}
+	if !isHex(r) {
+		b.WriteRune(r)
+		return nil
+	}
+
+	var hexRune strings.Builder
+	hexRune.WriteRune(r)
+	for isHex(l.peek()) {
+		if hexRune.Len() == 6 {
+			return l.errorf("too many hex digits consuming escape sequence")
+		}
+		hexRune.WriteRune(l.pop())
+	}
+	// A single whitespace character following the hex digits is consumed as
+	// part of the escape.
+	if IsWhitespace(l.peek()) {
+		l.pop()
+	}
+
+	s := hexRune.String()
+	val, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return l.errorf("failed to parse hex escape sequence %s: %v", s, err)
+	}
+	if val == 0 || (0xd800 <= val && val <= 0xdfff) || val > utf8.MaxRune {
+		val = utf8.RuneError
+	}
+	b.WriteRune(rune(val))
+	return nil
+}
+
+// https://www.w3.org/TR/css-syntax-3/#consume-a-name
+func (l *Lexer) consumeName(b *strings.Builder) error {
+	for {
+		r := l.peek()
+		if IsName(r) {
+			start := l.pos
+			l.pop()
+			b.WriteString(l.s[start:l.pos])
+			continue
+		}
+
+		if isValidEscape(r, l.peekN(1)) {
+			l.pop()
+			if err := l.consumeEscape(b); err != nil {
+				return err
+			}
+			continue
+		}
+		return nil
+	}
+}
+
+// consumeNameFrom consumes a name, returning the input from start, which is
+// at or before the name, through the end of the name. Names without escapes
+// are sliced from the input rather than copied, and escaped reports if any
+// escapes were resolved.
+func (l *Lexer) consumeNameFrom(start int) (s string, escaped bool, err error) {
+	for {
+		r := l.peek()
+		if IsName(r) {
+			l.pop()
+			continue
+		}
+		if isValidEscape(r, l.peekN(1)) {
+			var b strings.Builder
+			b.WriteString(l.s[start:l.pos])
+			if err := l.consumeName(&b); err != nil {
+				return "", false, err
+			}
+			return b.String(), true, nil
+		}
+		return l.s[start:l.pos], false, nil
+	}
+}
+
+// https://www.w3.org/TR/css-syntax-3/#consume-a-numeric-token
+func (l *Lexer) numericToken() (Token, error) {
+	var b strings.Builder
+	f := l.consumeNumber(&b)
+
+	if isIdentStart(l.peek(), l.peekN(1), l.peekN(2)) {
+		dim, _, err := l.consumeNameFrom(l.pos)
+		if err != nil {
+			return Token{}, err
+		}
+		return l.token(DimensionToken).
+			withString(b.String()).
+			withFlag(f).
+			withDim(dim), nil
+	}
+
+	if l.peek() == '%' {
+		b.WriteRune(l.pop())
+		return l.token(PercentToken).withString(b.String()).withFlag(FlagNumber), nil
+	}
+	return l.token(NumberToken).withString(b.String()).withFlag(f), nil
+}
+
+// https://www.w3.org/TR/css-syntax-3/#consume-an-ident-like-token
+func (l *Lexer) identLikeToken() (Token, error) {
+	var b strings.Builder
+	if l.startsURL(&b) {
+		return l.consumeURL(&b)
+	}
+
+	name, escaped, err := l.consumeNameFrom(l.pos)
+	if err != nil {
+		return Token{}, err
+	}
+
+	if l.peek() == '(' {
+		l.pop()
+		if escaped {
+			return l.token(FunctionToken).withString(name + "("), nil
+		}
+		// Without escapes, the token's value is its source text.
+		return l.token(FunctionToken), nil
+	}
+
+	return l.token(IdentToken).withString(name), nil
+}
+
+func (l *Lexer) startsURL(b *strings.Builder) bool {
+	if !(l.peek() == 'u' || l.peek() == 'U') {
+		return false
+	}
+	if !(l.peekN(1) == 'r' || l.peekN(1) == 'R') {
+		return false
+	}
+	if !(l.peekN(2) == 'l' || l.peekN(2) == 'L') {
+		return false
+	}
+	if l.peekN(3) != '(' {
+		return false
+	}
+
+	// Consume up to two characters of whitespace.
+	n := 4
+	for i := 0; i < 2; i++ {
+		if !IsWhitespace(l.peekN(n)) {
+			break
+		}
+		n++
+	}
+
+	r1 := l.peekN(n)
+	r2 := l.peekN(n + 1)
+
+	r := r1
+	if IsWhitespace(r1) {
+		r = r2
+	}
+	if r == '\'' || r == '"' {
+		return false
+	}
+
+	for i := 0; i < 4; i++ {
+		b.WriteRune(l.pop())
+	}
+	return true
+}
+
+// https://www.w3.org/TR/css-syntax-3/#consume-a-url-token
+func (l *Lexer) consumeURL(b *strings.Builder) (Token, error) {
+	for IsWhitespace(l.peek()) {
+		b.WriteRune(l.pop())
+	}
+
+	for {
+		r := l.pop()
+		switch {
+		case r == ')':
+			b.WriteRune(r)
+			return l.token(URLToken).withString(b.String()), nil
+		case r == eof:
+			return Token{}, l.errorf("unexpected eof parsing URL")
+		case IsWhitespace(r):
+			b.WriteRune(r)
+			for IsWhitespace(l.peek()) {
+				b.WriteRune(l.pop())
+			}
+			r := l.pop()
+			b.WriteRune(r)
+			if r == ')' {
+				return l.token(URLToken).withString(b.String()), nil
+			}
+			return Token{}, l.errorf("unexpected character parsing URL: %c", r)
+		case r == '\'', r == '"', r == '(', isNonPrintable(r):
+			return Token{}, l.errorf("invalid character parsing URL: %c", r)
+		case r == '\\':
+			if !isValidEscape(r, l.peek()) {
+				return Token{}, l.errorf("invalid '\\' parsing URL")
+			}
+			if err := l.consumeEscape(b); err != nil {
+				return Token{}, l.errorf("invalid escape parsing URL: %v", err)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+// https://www.w3.org/TR/css-syntax-3/#consume-a-number
+func (l *Lexer) consumeNumber(b *strings.Builder) Flag {
+	// 1. Initially set type to "integer". Let repr be the empty string.
+	f := FlagInteger
+
+	// 2. If the next input code point is U+002B PLUS SIGN (+) or U+002D
+	// HYPHEN-MINUS (-), consume it and append it to repr.
+	if l.peek() == '+' || l.peek() == '-' {
+		b.WriteRune(l.pop())
+	}
+
+	// 3. While the next input code point is a digit, consume it and append
+	// it to repr.
+	for IsDigit(l.peek()) {
+		b.WriteRune(l.pop())
+	}
+
+	// 4. If the next 2 input code points are U+002E FULL STOP (.) followed
+	// by a digit, then:
+	if l.peek() == '.' && IsDigit(l.peekN(1)) {
+		// Consume them.
+		// Append them to repr.
+		b.WriteRune(l.pop())
+		b.WriteRune(l.pop())
+		f = FlagNumber
+
+		// While the next input code point is a digit, consume it and append
+		// it to repr.
+		for IsDigit(l.peek()) {
+			b.WriteRune(l.pop())
+		}
+	}
+
+	r1 := l.peek()
+	r2 := l.peekN(1)
+	r3 := l.peekN(2)
+
+	// 5. If the next 2 or 3 input code points are U+0045 LATIN CAPITAL LETTER
+	// E (E) or U+0065 LATIN SMALL LETTER E (e), optionally followed by U+002D
+	// HYPHEN-MINUS (-) or U+002B PLUS SIGN (+), followed by a digit, then:
+	if r1 == 'E' || r1 == 'e' {
+		// Set type to "number".
+		f = FlagNumber
+		if IsDigit(r2) {
+			b.WriteRune(l.pop())
+			b.WriteRune(l.pop())
+
+			for IsDigit(l.peek()) {
+				b.WriteRune(l.pop())
+			}
+		} else if (r2 == '+' || r2 == '-') && IsDigit(r3) {
+			b.WriteRune(l.pop())
+			b.WriteRune(l.pop())
+			b.WriteRune(l.pop())
+
+			for IsDigit(l.peek()) {
+				b.WriteRune(l.pop())
+			}
+		}
+	}
+	return f
+}
+
+// IsWhitespace reports if r is a whitespace code point.
+//
+// https://www.w3.org/TR/css-syntax-3/#whitespace
+func IsWhitespace(r rune) bool {
+	switch r {
+	case '\n', '\t', ' ':
+		return true
+	default:
+		return false
+	}
+}
+
+// https://www.w3.org/TR/css-syntax-3/#hex-digit
+func isHex(r rune) bool {
+	return IsDigit(r) || ('A' <= r && r <= 'F') || ('a' <= r && r <= 'f')
+}
+
+// IsDigit reports if r is a digit code point.
+//
+// https://www.w3.org/TR/css-syntax-3/#digit
+func IsDigit(r rune) bool {
+	return '0' <= r && r <= '9'
+}
+
+// https://www.w3.org/TR/css-syntax-3/#letter
+func isLetter(r rune) bool {
+	return ('A' <= r && r <= 'Z') || ('a' <= r && r <= 'z')
+}
+
+// https://www.w3.org/TR/css-syntax-3/#non-ascii-code-point
+func isNonASCII(r rune) bool {
+	return r >= 0x80
+}
+
+// IsName reports if r is a name code point, a code point that may appear in an
+// identifier.
+//
+// https://www.w3.org/TR/css-syntax-3/#name-code-point
+func IsName(r rune) bool {
+	return IsNameStart(r) || IsDigit(r) || r == '-'
+}
+
+// IsNameStart reports if r is a name-start code point, a code point that may
+// start an identifier.
+//
+// https://www.w3.org/TR/css-syntax-3/#name-start-code-point
+func IsNameStart(r rune) bool {
+	return isLetter(r) || isNonASCII(r) || r == '_'
+}
+
+// https://www.w3.org/TR/css-syntax-3/#check-if-three-code-points-would-start-a-number
+func isNumStart(r1, r2, r3 rune) bool {
+	if r1 == '+' || r1 == '-' {
+		if IsDigit(r2) {
+			return true
+		}
+		if r2 == '.' && IsDigit(r3) {
+			return true
+		}
+		return false
+	}
+
+	if r1 == '.' {
+		return IsDigit(r2)
+	}
+	return IsDigit(r1)
+}
+
+// https://www.w3.org/TR/css-syntax-3/#check-if-two-code-points-are-a-valid-escape
+func isValidEscape(r1, r2 rune) bool {
+	if r1 != '\\' {
+		return false
+	}
+	if r2 == '\n' || r2 == eof {
+		return false
+	}
+	return true
+}
+
+// https://www.w3.org/TR/css-syntax-3/#check-if-three-code-points-would-start-an-identifier
+func isIdentStart(r1, r2, r3 rune) bool {
+	if r1 == '-' {
+		if IsNameStart(r2) || r2 == '-' {
+			return true
+		}
+		if isValidEscape(r2, r3) {
+			return true
+		}
+	}
+	if IsNameStart(r1) {
+		return true
+	}
+	if r1 == '\\' && isValidEscape(r1, r2) {
+		return true
+	}
+	return false
+}
+
+func isNonPrintable(r rune) bool {
+	if 0x0 <= r && r <= 0x8 {
+		return true
+	}
+	if r == '\t' {
+		return true
+	}
+	if 0xe <= r && r <= 0x1f {
+		return true
+	}
+	if r == 0x7F {
+		return true
+	}
+	return false
+}
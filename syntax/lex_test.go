@@ -0,0 +1,155 @@
+package syntax
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []Token
+	}{
+		{
+			`a.b`,
+			[]Token{
+				{Type: IdentToken, Raw: "a", Value: "a", Pos: 0},
+				{Type: DelimToken, Raw: ".", Value: ".", Pos: 1},
+				{Type: IdentToken, Raw: "b", Value: "b", Pos: 2},
+			},
+		},
+		{
+			`:nth-child(2n+1)`,
+			[]Token{
+				{Type: ColonToken, Raw: ":", Value: ":", Pos: 0},
+				{Type: FunctionToken, Raw: "nth-child(", Value: "nth-child(", Pos: 1},
+				{Type: DimensionToken, Raw: "2n", Value: "2", Pos: 11, Flag: FlagInteger, Unit: "n"},
+				{Type: NumberToken, Raw: "+1", Value: "+1", Pos: 13, Flag: FlagInteger},
+				{Type: ParenCloseToken, Raw: ")", Value: ")", Pos: 15},
+			},
+		},
+		{
+			`#\66oo "b\61r"`,
+			[]Token{
+				{Type: HashToken, Raw: `#\66oo`, Value: "#foo", Pos: 0, Flag: FlagID},
+				{Type: WhitespaceToken, Raw: " ", Value: " ", Pos: 6},
+				{Type: StringToken, Raw: `"b\61r"`, Value: "bar", Pos: 7},
+			},
+		},
+		{
+			`\66oo( a\"b 'c\'' @m\65  1p\78`,
+			[]Token{
+				{Type: FunctionToken, Raw: `\66oo(`, Value: "foo(", Pos: 0},
+				{Type: WhitespaceToken, Raw: " ", Value: " ", Pos: 6},
+				{Type: IdentToken, Raw: `a\"b`, Value: `a"b`, Pos: 7},
+				{Type: WhitespaceToken, Raw: " ", Value: " ", Pos: 11},
+				{Type: StringToken, Raw: `'c\''`, Value: "c'", Pos: 12},
+				{Type: WhitespaceToken, Raw: " ", Value: " ", Pos: 17},
+				// Whitespace following a hex escape is part of the escape.
+				{Type: AtKeywordToken, Raw: `@m\65 `, Value: "@me", Pos: 18},
+				{Type: WhitespaceToken, Raw: " ", Value: " ", Pos: 24},
+				{Type: DimensionToken, Raw: `1p\78`, Value: "1", Pos: 25, Flag: FlagInteger, Unit: "px"},
+			},
+		},
+		{
+			// Invalid UTF-8 and NUL are name code points, rather than the end
+			// of the input.
+			"a\xffb,c\x00",
+			[]Token{
+				{Type: IdentToken, Raw: "a\xffb", Value: "a\xffb", Pos: 0},
+				{Type: CommaToken, Raw: ",", Value: ",", Pos: 3},
+				{Type: IdentToken, Raw: "c\x00", Value: "c\x00", Pos: 4},
+			},
+		},
+	}
+	for _, test := range tests {
+		got, err := Tokenize(test.s)
+		if err != nil {
+			t.Errorf("Tokenize(%q) failed: %v", test.s, err)
+			continue
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Tokenize(%q) returned diff (-want, +got): %s", test.s, diff)
+		}
+	}
+}
+
+func TestTokenizeError(t *testing.T) {
+	_, err := Tokenize(`a "foo`)
+	var serr *Error
+	if !errors.As(err, &serr) {
+		t.Fatalf("Tokenize() returned %v, want *Error", err)
+	}
+	if serr.Start != 2 || serr.Pos != 6 {
+		t.Errorf("Tokenize() returned error at start=%d, pos=%d, want start=2, pos=6", serr.Start, serr.Pos)
+	}
+}
+
+func TestLexerPop(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []rune
+	}{
+		{
+			"hello, world!",
+			[]rune{'h', 'e', 'l', 'l', 'o', ',', ' ', 'w', 'o', 'r', 'l', 'd', '!'},
+		},
+		{
+			"hello, 世界!",
+			[]rune{'h', 'e', 'l', 'l', 'o', ',', ' ', '世', '界', '!'},
+		},
+	}
+
+	for _, test := range tests {
+		var got []rune
+		l := NewLexer(test.s)
+		for l.peek() != eof {
+			got = append(got, l.pop())
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("lexer parsing code points for %q: got=%v, want=%v", test.s, got, test.want)
+		}
+	}
+}
+
+func TestLexerReset(t *testing.T) {
+	l := NewLexer(`a "foo`)
+	for {
+		if _, err := l.Next(); err != nil {
+			break
+		}
+	}
+	l.Reset("b")
+	got, err := l.Next()
+	if err != nil {
+		t.Fatalf("Next() after Reset() failed: %v", err)
+	}
+	want := Token{Type: IdentToken, Raw: "b", Value: "b", Pos: 0}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Next() after Reset() returned diff (-want, +got): %s", diff)
+	}
+}
+
+func TestLexerAllocs(t *testing.T) {
+	// Tokens without escapes or numbers are sliced from the input.
+	s := `div.item#main > a[href="x"]:hover, :is(p) @media 'y'`
+	l := NewLexer(s)
+	allocs := testing.AllocsPerRun(10, func() {
+		l.Reset(s)
+		for {
+			tok, err := l.Next()
+			if err != nil {
+				t.Fatalf("Next() failed: %v", err)
+			}
+			if tok.Type == EOFToken {
+				return
+			}
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("tokenizing %q made %v allocations, want 0", s, allocs)
+	}
+}
@@ -0,0 +1,64 @@
+package syntax
+
+import (
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// ErrIncomplete is returned by a streaming lexer when more input is needed to
+// determine the next token.
+var ErrIncomplete = errors.New("syntax: incomplete input")
+
+// NewReaderLexer returns a lexer that reads its input from r as tokens are
+// requested. Errors returned by r, other than io.EOF, are returned by Next.
+func NewReaderLexer(r io.Reader) *Lexer {
+	return &Lexer{r: r}
+}
+
+// NewStreamLexer returns a lexer whose input is provided incrementally through
+// Append. Once all input has been appended, Close must be called to lex the
+// final token.
+func NewStreamLexer() *Lexer {
+	return &Lexer{}
+}
+
+// Append adds s to the end of the lexer's input. It panics if the lexer
+// wasn't returned by NewStreamLexer, or has been closed.
+func (l *Lexer) Append(s string) {
+	if l.closed || l.r != nil {
+		panic("syntax: Append called on a lexer that isn't accepting input")
+	}
+	l.s += s
+}
+
+// Close indicates that no more input will be appended to a lexer returned by
+// NewStreamLexer.
+func (l *Lexer) Close() {
+	l.closed = true
+}
+
+// readSize is the number of bytes requested from a reader at a time.
+const readSize = 512
+
+// fill attempts to buffer at least one full code point after the current
+// position, reading from the lexer's reader if necessary. If the input isn't
+// available, short is set.
+func (l *Lexer) fill() {
+	for !l.closed && !utf8.FullRuneInString(l.s[l.pos:]) {
+		if l.r == nil {
+			l.short = true
+			return
+		}
+		var buf [readSize]byte
+		n, err := l.r.Read(buf[:])
+		l.s += string(buf[:n])
+		if err == io.EOF {
+			l.closed = true
+		} else if err != nil {
+			l.closed = true
+			l.err = err
+			l.short = true
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package syntax
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var streamTests = []string{
+	`div > a.foo[href^="https://"]`,
+	`:nth-child(2n+1 of .a), #\66oo`,
+	`url( foo ) 1.5e3px 50% <!-- -->`,
+	"héllo, 世界!",
+}
+
+func TestReaderLexer(t *testing.T) {
+	for _, s := range streamTests {
+		want, err := Tokenize(s)
+		if err != nil {
+			t.Fatalf("Tokenize(%q) failed: %v", s, err)
+		}
+
+		l := NewReaderLexer(iotest.OneByteReader(strings.NewReader(s)))
+		var got []Token
+		for {
+			tok, err := l.Next()
+			if err != nil {
+				t.Fatalf("Next() for %q failed: %v", s, err)
+			}
+			if tok.Type == EOFToken {
+				break
+			}
+			got = append(got, tok)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("reading %q returned diff (-want, +got): %s", s, diff)
+		}
+	}
+}
+
+func TestReaderLexerError(t *testing.T) {
+	errRead := errors.New("read failed")
+	l := NewReaderLexer(&errReader{strings.NewReader("a b"), errRead})
+	for i := 0; i < 10; i++ {
+		if _, err := l.Next(); err != nil {
+			if !errors.Is(err, errRead) {
+				t.Fatalf("Next() returned unexpected error: %v", err)
+			}
+			return
+		}
+	}
+	t.Fatalf("Next() didn't return read error")
+}
+
+// errReader returns err instead of io.EOF.
+type errReader struct {
+	r   *strings.Reader
+	err error
+}
+
+func (e *errReader) Read(p []byte) (int, error) {
+	n, _ := e.r.Read(p)
+	if e.r.Len() == 0 {
+		return n, e.err
+	}
+	return n, nil
+}
+
+func TestStreamLexer(t *testing.T) {
+	for _, s := range streamTests {
+		want, err := Tokenize(s)
+		if err != nil {
+			t.Fatalf("Tokenize(%q) failed: %v", s, err)
+		}
+
+		// Append the input one byte at a time, reading tokens as they become
+		// available.
+		l := NewStreamLexer()
+		var got []Token
+		next := func() bool {
+			tok, err := l.Next()
+			if errors.Is(err, ErrIncomplete) {
+				return false
+			}
+			if err != nil {
+				t.Fatalf("Next() for %q failed: %v", s, err)
+			}
+			if tok.Type == EOFToken {
+				return false
+			}
+			got = append(got, tok)
+			return true
+		}
+		for i := 0; i < len(s); i++ {
+			l.Append(s[i : i+1])
+			for next() {
+			}
+		}
+		l.Close()
+		for next() {
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("streaming %q returned diff (-want, +got): %s", s, diff)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package css
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// TextRange is a run of text within a text node, represented by a
+// pseudo-element such as ::first-letter.
+type TextRange struct {
+	// Element is the element the pseudo-element originates from.
+	Element *html.Node
+	// Node is a text node within Element. Start and End are byte offsets
+	// into Node.Data.
+	Node       *html.Node
+	Start, End int
+}
+
+// Text returns the text held by the range.
+func (r TextRange) Text() string {
+	return r.Node.Data[r.Start:r.End]
+}
+
+// textPseudoElements maps pseudo-elements that represent text, rather than
+// elements, to functions returning the text ranges they represent for an
+// originating element. They're supported as an extension, since matching
+// them in a browser depends on layout.
+var textPseudoElements = map[string]func(n *html.Node) []TextRange{
+	"first-letter": firstLetter,
+	"first-line":   firstLine,
+}
+
+// textPseudoElement returns the name of the text pseudo-element s, or an
+// empty string if s isn't one or extensions aren't enabled.
+func (c *compiler) textPseudoElement(s *pseudoClassSelector) string {
+	if !c.opts.extensions || s.function != "" {
+		return ""
+	}
+	name := strings.ToLower(s.ident)
+	if _, ok := textPseudoElements[name]; !ok {
+		return ""
+	}
+	return name
+}
+
+// textRanges returns a pseudo-element matcher for a text pseudo-element,
+// which selects the originating element if it holds any of the text the
+// pseudo-element represents.
+func textRanges(name string) pseudoElementMatcher {
+	ranges := textPseudoElements[name]
+	return func(s *state, n *html.Node) []*html.Node {
+		if len(ranges(n)) == 0 {
+			return nil
+		}
+		return []*html.Node{n}
+	}
+}
+
+// SelectRanges returns the text ranges represented by the ::first-letter and
+// ::first-line pseudo-elements of the selector, for tools such as
+// highlighters that need to locate the text within a static tree:
+//
+//	sel, err := css.Parse("p::first-letter", css.WithExtensions())
+//	...
+//	for _, r := range sel.SelectRanges(root) {
+//		fmt.Println(r.Text())
+//	}
+//
+// Both pseudo-elements require WithExtensions, without which they're handled
+// like any other unsupported pseudo-element. Select returns the originating
+// elements of the ranges.
+//
+// Without a layout, lines are approximated. The first line of an element
+// starts at its first non-whitespace text, and ends at the first br element,
+// at the start or end of a block-level element such as a div or p, or at a
+// newline within preformatted text. The first letter is the first letter,
+// digit or symbol of the first line, along with any punctuation preceding
+// or immediately following it, and may span several text nodes.
+//
+// Ranges are returned in the document order of their originating elements.
+// Selectors in the list without a text pseudo-element are ignored.
+func (s *Selector) SelectRanges(n *html.Node) []TextRange {
+	var ranges []TextRange
+	if !validRoot(n) {
+		return ranges
+	}
+	type key struct {
+		n    *html.Node
+		name string
+	}
+	seen := map[key]bool{}
+	st := s.newState(n, &MatchContext{})
+	st.walk(n, func(e *html.Node) {
+		for _, sel := range s.s {
+			if sel.text == "" || seen[key{e, sel.text}] || !sel.match(st, e) {
+				continue
+			}
+			seen[key{e, sel.text}] = true
+			ranges = append(ranges, textPseudoElements[sel.text](e)...)
+		}
+	})
+	return ranges
+}
+
+// lineBreakingElements are block-level elements that end the first line of
+// their containing element.
+var lineBreakingElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Dd: true, atom.Details: true, atom.Dialog: true, atom.Div: true, atom.Dl: true,
+	atom.Dt: true, atom.Fieldset: true, atom.Figcaption: true, atom.Figure: true,
+	atom.Footer: true, atom.Form: true, atom.H1: true, atom.H2: true, atom.H3: true,
+	atom.H4: true, atom.H5: true, atom.H6: true, atom.Header: true, atom.Hgroup: true,
+	atom.Hr: true, atom.Li: true, atom.Main: true, atom.Nav: true, atom.Ol: true,
+	atom.P: true, atom.Pre: true, atom.Section: true, atom.Summary: true,
+	atom.Table: true, atom.Tr: true, atom.Ul: true,
+}
+
+// unrenderedText holds elements whose text isn't rendered as content.
+var unrenderedText = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Template: true,
+}
+
+// preformatted reports if newlines within n's text are preserved.
+func preformatted(n *html.Node) bool {
+	for ; n != nil; n = n.Parent {
+		if n.Type == html.ElementNode && (n.DataAtom == atom.Pre || n.DataAtom == atom.Textarea) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstLine approximates the ::first-line pseudo-element of n.
+//
+// https://www.w3.org/TR/selectors-4/#first-line-pseudo
+func firstLine(n *html.Node) []TextRange {
+	var (
+		ranges  []TextRange
+		started bool
+	)
+	// walk appends the text of n's children to ranges, returning false once
+	// the end of the line is found.
+	var walk func(n *html.Node, pre bool) bool
+	walk = func(n *html.Node, pre bool) bool {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			switch c.Type {
+			case html.TextNode:
+				start, end := 0, len(c.Data)
+				if !started {
+					start = end - len(strings.TrimLeftFunc(c.Data, unicode.IsSpace))
+					if start == end {
+						continue
+					}
+					started = true
+				}
+				if pre {
+					if i := strings.IndexByte(c.Data[start:], '\n'); i >= 0 {
+						if i > 0 {
+							ranges = append(ranges, TextRange{Node: c, Start: start, End: start + i})
+						}
+						return false
+					}
+				}
+				ranges = append(ranges, TextRange{Node: c, Start: start, End: end})
+			case html.ElementNode:
+				if c.DataAtom == atom.Br {
+					return false
+				}
+				if unrenderedText[c.DataAtom] {
+					continue
+				}
+				block := lineBreakingElements[c.DataAtom]
+				if block && started {
+					return false
+				}
+				if !walk(c, pre || c.DataAtom == atom.Pre || c.DataAtom == atom.Textarea) {
+					return false
+				}
+				if block && started {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	walk(n, preformatted(n))
+	for i := range ranges {
+		ranges[i].Element = n
+	}
+	return ranges
+}
+
+// firstLetter returns the ::first-letter pseudo-element of n: the first
+// typographic letter unit of its first line, with surrounding punctuation.
+//
+// https://www.w3.org/TR/selectors-4/#first-letter-pseudo
+func firstLetter(n *html.Node) []TextRange {
+	line := firstLine(n)
+	var b strings.Builder
+	for _, r := range line {
+		b.WriteString(r.Text())
+	}
+	text := b.String()
+
+	// Leading whitespace is already trimmed from the line, so any preceding
+	// punctuation starts at the first rune.
+	i := 0
+	next := func() rune {
+		r, _ := utf8.DecodeRuneInString(text[i:])
+		return r
+	}
+	advance := func() {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+	}
+	for i < len(text) && (unicode.IsPunct(next()) || unicode.IsSpace(next())) {
+		advance()
+	}
+	if i == len(text) {
+		return nil
+	}
+	advance()
+	for i < len(text) && unicode.In(next(), unicode.Mn, unicode.Mc, unicode.Me) {
+		advance()
+	}
+	for i < len(text) && unicode.IsPunct(next()) {
+		advance()
+	}
+
+	// Map the letter back to the ranges of the line it spans.
+	var ranges []TextRange
+	offset := 0
+	for _, r := range line {
+		if offset >= i {
+			break
+		}
+		if end := r.Start + i - offset; end < r.End {
+			r.End = end
+		}
+		ranges = append(ranges, r)
+		offset += r.End - r.Start
+	}
+	return ranges
+}
@@ -0,0 +1,114 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestSelectRanges(t *testing.T) {
+	tests := []struct {
+		sel  string
+		in   string
+		want []string
+	}{
+		{"p::first-letter", `<p>Hello world</p>`, []string{"H"}},
+		{"p::first-letter", `<p>  "Hello," she said</p>`, []string{`"H`}},
+		{"p::first-letter", `<p>(a) is true</p>`, []string{"(a)"}},
+		{"p::first-letter", `<p>« Bonjour »</p>`, []string{"« B"}},
+		{"p::first-letter", `<p><b>"</b><i>Hi</i></p>`, []string{`"`, "H"}},
+		{"p::first-letter", `<p>e&#x301;t&#xe9;</p>`, []string{"é"}},
+		{"p::first-letter", `<p>日本語</p>`, []string{"日"}},
+		{"p::first-letter", `<p>...</p>`, nil},
+		{"p::first-letter", `<p>   </p>`, nil},
+		{"p::first-letter", `<p><br>Hello</p>`, nil},
+		{"p::first-letter", `<p><script>x</script>Hi</p>`, []string{"H"}},
+		{"div::first-letter", `<div><p>Nested</p></div>`, []string{"N"}},
+		{"p:first-letter", `<p>Legacy</p>`, []string{"L"}},
+		{"p::FIRST-LETTER", `<p>Case</p>`, []string{"C"}},
+
+		{"p::first-line", `<p>Hello world</p>`, []string{"Hello world"}},
+		{"p::first-line", `<p>  Hello <b>bold</b> world<br>next line</p>`, []string{"Hello ", "bold", " world"}},
+		{"body > div::first-line", `<div>One<div>Two</div>Three</div>`, []string{"One"}},
+		{"body > div::first-line", `<div><div>One</div>Two</div>`, []string{"One"}},
+		{"div::first-line", "<div>\n  <p>\n  Indented</p></div>", []string{"Indented"}},
+		{"pre::first-line", "<pre>line one\nline two</pre>", []string{"line one"}},
+		{"pre::first-line", "<pre>\n\nafter blank</pre>", []string{"after blank"}},
+		{"p::first-line", "<p>not\npreformatted</p>", []string{"not\npreformatted"}},
+
+		{"p::first-letter, p::first-line", `<p>Hi there</p>`, []string{"H", "Hi there"}},
+		{"p::first-letter, p::first-letter", `<p>Hi</p>`, []string{"H"}},
+		{"p::first-letter, a", `<p>Hi</p><a>link</a>`, []string{"H"}},
+		{"p::first-letter", `<p>One</p><p>Two</p>`, []string{"O", "T"}},
+		{"a", `<a>link</a>`, nil},
+	}
+	for _, test := range tests {
+		root, err := html.Parse(strings.NewReader(test.in))
+		if err != nil {
+			t.Fatalf("html.Parse(%q) failed: %v", test.in, err)
+		}
+		sel, err := Parse(test.sel, WithExtensions())
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		var got []string
+		for _, r := range sel.SelectRanges(root) {
+			if r.Element == nil || r.Node.Type != html.TextNode {
+				t.Errorf("SelectRanges(%q) returned invalid range %+v", test.in, r)
+			}
+			got = append(got, r.Text())
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q).SelectRanges(%q) returned diff (-want, +got): %s", test.sel, test.in, diff)
+		}
+	}
+}
+
+func TestSelectRangesSelect(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<p id="a">Text</p><p id="b"> </p>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	sel, err := Parse("p::first-letter", WithExtensions())
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	nodes := sel.Select(root)
+	got := renderNodes(t, nodes)
+	want := []string{`<p id="a">Text</p>`}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Select() returned diff (-want, +got): %s", diff)
+	}
+
+	ranges := sel.SelectRanges(root)
+	if len(ranges) != 1 {
+		t.Fatalf("SelectRanges() returned %d ranges, want 1", len(ranges))
+	}
+	r := ranges[0]
+	if r.Element != nodes[0] || r.Node != r.Element.FirstChild || r.Start != 0 || r.End != 1 {
+		t.Errorf("SelectRanges() returned unexpected range %+v", r)
+	}
+}
+
+func TestSelectRangesRequiresExtensions(t *testing.T) {
+	for _, s := range []string{"p::first-letter", "p::first-line", "p:first-line"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) succeeded without extensions", s)
+		}
+		sel, err := Parse(s, WithUnknownPseudo(UnknownPseudoNeverMatch))
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", s, err)
+			continue
+		}
+		root, err := html.Parse(strings.NewReader(`<p>Hello</p>`))
+		if err != nil {
+			t.Fatalf("html.Parse() failed: %v", err)
+		}
+		if got := sel.SelectRanges(root); len(got) != 0 {
+			t.Errorf("Parse(%q).SelectRanges() returned %d ranges without extensions, want 0", s, len(got))
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package css
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Trace records how a selector was evaluated against an element.
+type Trace struct {
+	// Node is the element the selector was evaluated against.
+	Node *html.Node
+	// Matched reports if any selector in the list matched.
+	Matched bool
+	// Selectors holds a trace for each selector in the list.
+	Selectors []SelectorTrace
+}
+
+// SelectorTrace records the evaluation of a single complex selector.
+type SelectorTrace struct {
+	// Selector is the serialized complex selector.
+	Selector string
+	Matched  bool
+	// Steps lists every compound selector evaluated, in order. Selectors are
+	// matched right to left, so the first step is always the subject of the
+	// selector evaluated against the traced element. Combinators that search
+	// through ancestors or siblings may produce several steps for a single
+	// compound selector, including ones that fail and are backtracked.
+	Steps []TraceStep
+}
+
+// TraceStep records the evaluation of a compound selector against a single
+// element.
+type TraceStep struct {
+	// Compound is the serialized compound selector.
+	Compound string
+	// Combinator relates the element to the element matched by the previous
+	// step: " ", ">", "+" or "~". It's empty for the subject of the selector.
+	Combinator string
+	Node       *html.Node
+	Matched    bool
+}
+
+func (s *state) record(m *compoundSelectorMatcher, combinator string, n *html.Node, ok bool) {
+	var b strings.Builder
+	writeCompoundSelector(&b, m.src)
+	*s.trace = append(*s.trace, TraceStep{
+		Compound:   b.String(),
+		Combinator: combinator,
+		Node:       n,
+		Matched:    ok,
+	})
+}
+
+// ExplainMatch evaluates the selector against n, recording each step of the
+// evaluation. It's intended for debugging why an element did or didn't match.
+// Combinators are evaluated against the entire tree containing n.
+//
+// Tracing only happens when calling ExplainMatch, and doesn't affect the
+// performance of Select.
+func (s *Selector) ExplainMatch(n *html.Node) *Trace {
+	root := treeRoot(n)
+	t := &Trace{Node: n}
+	for i, sel := range s.s {
+		var steps []TraceStep
+		st := newState(root, &MatchContext{})
+		st.trace = &steps
+		ok := sel.match(st, n)
+
+		var b strings.Builder
+		writeComplexSelector(&b, &s.list[i])
+		t.Selectors = append(t.Selectors, SelectorTrace{
+			Selector: b.String(),
+			Matched:  ok,
+			Steps:    steps,
+		})
+		if ok {
+			t.Matched = true
+		}
+	}
+	return t
+}
+
+// String formats the trace for display, with one line per step.
+func (t *Trace) String() string {
+	var b strings.Builder
+	for _, sel := range t.Selectors {
+		fmt.Fprintf(&b, "%s: %s\n", sel.Selector, matchedString(sel.Matched))
+		for _, step := range sel.Steps {
+			comb := step.Combinator
+			if comb == " " {
+				comb = "descendant"
+			}
+			if comb != "" {
+				comb += " "
+			}
+			fmt.Fprintf(&b, "\t%s%s against <%s>: %s\n", comb, step.Compound, step.Node.Data, matchedString(step.Matched))
+		}
+	}
+	return b.String()
+}
+
+func matchedString(ok bool) string {
+	if ok {
+		return "matched"
+	}
+	return "no match"
+}
@@ -0,0 +1,56 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestExplainMatch(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<div class="a"><section><p><a href="/">link</a></p></section></div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	a := MustParse("a").Select(root)[0]
+
+	tests := []struct {
+		sel  string
+		want string
+	}{
+		{
+			"div.a a, p > a",
+			"div.a a: matched\n" +
+				"\ta against <a>: matched\n" +
+				"\tdescendant div.a against <p>: no match\n" +
+				"\tdescendant div.a against <section>: no match\n" +
+				"\tdescendant div.a against <div>: matched\n" +
+				"p > a: matched\n" +
+				"\ta against <a>: matched\n" +
+				"\t> p against <p>: matched\n",
+		},
+		{
+			"section > a",
+			"section > a: no match\n" +
+				"\ta against <a>: matched\n" +
+				"\t> section against <p>: no match\n",
+		},
+		{
+			"span",
+			"span: no match\n" +
+				"\tspan against <a>: no match\n",
+		},
+	}
+	for _, test := range tests {
+		tr := MustParse(test.sel).ExplainMatch(a)
+		if diff := cmp.Diff(test.want, tr.String()); diff != "" {
+			t.Errorf("ExplainMatch(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+
+	tr := MustParse("span, a").ExplainMatch(a)
+	if !tr.Matched {
+		t.Errorf("ExplainMatch(%q) didn't report a match", "span, a")
+	}
+}
@@ -0,0 +1,79 @@
+package css
+
+import (
+	"sort"
+
+	"golang.org/x/net/html"
+)
+
+// Update returns the results of selecting from root after a mutation, given
+// prev, the results of the selector before the mutation was made. Update only
+// re-evaluates elements the mutation can affect: the children of the
+// mutation's target parent, the siblings that follow them, and their
+// descendants. Elements outside that subtree keep their previous result.
+//
+// prev must be the result of calling Select or Update on root, and the
+// mutation must already have been applied. The returned slice doesn't share
+// memory with prev.
+func (s *Selector) Update(root *html.Node, prev []*html.Node, m *Mutation) []*html.Node {
+	if s.pseudo || s.unbounded() || m == nil || m.Target == nil {
+		// Pseudo-elements, :has(), :contains(), and non-standard combinators
+		// may relate elements anywhere in the tree.
+		return s.Select(root)
+	}
+
+	// Attribute changes can affect the target, its descendants, and any
+	// following siblings through sibling combinators. Child list changes can
+	// affect the target itself (:empty), and so its following siblings too.
+	// Both cases are covered by the subtree of the target's parent.
+	scope := m.Target
+	if scope != root && scope.Parent != nil {
+		scope = scope.Parent
+	}
+	if !contains(root, scope) || scope == root {
+		return s.Select(root)
+	}
+
+	st := s.newState(root, &MatchContext{})
+	kept := make([]*html.Node, 0, len(prev))
+	for _, n := range prev {
+		// Drop elements within the scope, which are re-evaluated below, and
+		// elements that are no longer connected to root.
+		p := n
+		for p != nil && p != scope && p != root {
+			p = p.Parent
+		}
+		if p == root && n != scope {
+			kept = append(kept, n)
+		}
+	}
+
+	var matched []*html.Node
+	st.walk(scope, func(e *html.Node) {
+		if s.match(st, e) {
+			matched = append(matched, e)
+		}
+	})
+
+	// The scope is a contiguous range of the document, so insert the new
+	// matches after any kept element ordered before it.
+	scopePath := st.path(scope)
+	i := sort.Search(len(kept), func(i int) bool {
+		return comparePaths(st.path(kept[i]), scopePath) > 0
+	})
+	next := make([]*html.Node, 0, len(kept)+len(matched))
+	next = append(next, kept[:i]...)
+	next = append(next, matched...)
+	next = append(next, kept[i:]...)
+	return next
+}
+
+// contains reports if n is an inclusive descendant of root.
+func contains(root, n *html.Node) bool {
+	for ; n != nil; n = n.Parent {
+		if n == root {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestUpdate(t *testing.T) {
+	selectors := []string{
+		"li",
+		"li.a",
+		"ul > li:first-child",
+		"li:nth-child(2n+1)",
+		"li:last-child",
+		"li.a + li",
+		"li.a ~ li",
+		".a li",
+		"ul:empty",
+		"ul:empty ~ p",
+		":root",
+	}
+
+	s := `<div><ul><li class="a">1</li><li>2</li><li>3</li></ul><ul></ul><p></p></div>`
+	root, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	var results [][]*html.Node
+	var sels []*Selector
+	for _, s := range selectors {
+		sel := MustParse(s)
+		sels = append(sels, sel)
+		results = append(results, sel.Select(root))
+	}
+
+	lists := MustParse("ul").Select(root)
+	items := MustParse("li").Select(root)
+	li := func() *html.Node {
+		return &html.Node{Type: html.ElementNode, Data: "li", DataAtom: atom.Li}
+	}
+	mutations := []struct {
+		name string
+		fn   func() *Mutation
+	}{
+		{"set class", func() *Mutation {
+			lists[0].Attr = []html.Attribute{{Key: "class", Val: "a"}}
+			return &Mutation{Type: MutationAttributes, Target: lists[0], Attr: "class"}
+		}},
+		{"remove class", func() *Mutation {
+			items[0].Attr = nil
+			return &Mutation{Type: MutationAttributes, Target: items[0], Attr: "class"}
+		}},
+		{"insert first", func() *Mutation {
+			n := li()
+			lists[0].InsertBefore(n, lists[0].FirstChild)
+			return &Mutation{Type: MutationChildList, Target: lists[0], Added: []*html.Node{n}}
+		}},
+		{"append to empty", func() *Mutation {
+			n := li()
+			lists[1].AppendChild(n)
+			return &Mutation{Type: MutationChildList, Target: lists[1], Added: []*html.Node{n}}
+		}},
+		{"remove", func() *Mutation {
+			n := items[1]
+			lists[0].RemoveChild(n)
+			return &Mutation{Type: MutationChildList, Target: lists[0], Removed: []*html.Node{n}}
+		}},
+		{"remove list", func() *Mutation {
+			n := lists[0]
+			p := n.Parent
+			p.RemoveChild(n)
+			return &Mutation{Type: MutationChildList, Target: p, Removed: []*html.Node{n}}
+		}},
+	}
+
+	for _, m := range mutations {
+		mutation := m.fn()
+		for i, sel := range sels {
+			got := sel.Update(root, results[i], mutation)
+			want := sel.Select(root)
+			if diff := cmp.Diff(renderNodes(t, want), renderNodes(t, got)); diff != "" {
+				t.Errorf("%s: Update(%q) returned diff (-want, +got): %s", m.name, selectors[i], diff)
+			}
+			results[i] = got
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package css
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// The :hidden and :visible extensions approximate whether an element is
+// rendered using only the document, without a layout or stylesheets. They
+// consider the hidden attribute, hidden inputs, elements that are never
+// rendered such as script, and display and visibility properties declared in
+// style attributes.
+
+// unrenderedElements are elements that are never rendered, along with their
+// contents.
+var unrenderedElements = map[atom.Atom]bool{
+	atom.Base:     true,
+	atom.Head:     true,
+	atom.Link:     true,
+	atom.Meta:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Template: true,
+	atom.Title:    true,
+}
+
+// hiddenMatcher matches elements that aren't displayed because they, or one
+// of their ancestors, are hidden by the hidden attribute, have "display:
+// none" in their style attribute, or are never rendered, as well as hidden
+// inputs. It also matches elements whose visibility is hidden or collapse,
+// which is inherited unless a descendant sets it back to visible.
+func hiddenMatcher(n *html.Node) bool {
+	visibility := ""
+	for e := n; e != nil && e.Type == html.ElementNode; e = e.Parent {
+		if undisplayed(e) {
+			return true
+		}
+		if visibility == "" {
+			visibility = inlineStyle(e, "visibility")
+		}
+	}
+	return visibility == "hidden" || visibility == "collapse"
+}
+
+// visibleMatcher matches elements that aren't matched by hiddenMatcher.
+func visibleMatcher(n *html.Node) bool {
+	return !hiddenMatcher(n)
+}
+
+// undisplayed reports if n and its contents aren't displayed, regardless of
+// the styles of its ancestors and descendants.
+func undisplayed(n *html.Node) bool {
+	if n.Namespace == "" {
+		if unrenderedElements[n.DataAtom] {
+			return true
+		}
+		if _, ok := attr(n, "hidden"); ok {
+			return true
+		}
+		if n.DataAtom == atom.Input {
+			if typ, _ := attr(n, "type"); strings.EqualFold(typ, "hidden") {
+				return true
+			}
+		}
+	}
+	return inlineStyle(n, "display") == "none"
+}
+
+// inlineStyle returns the lowercased value of a property declared in an
+// element's style attribute, or an empty string if it isn't declared. If the
+// property is declared more than once, the last declaration is used, unless
+// an earlier one is marked !important.
+func inlineStyle(n *html.Node, property string) string {
+	style, ok := attr(n, "style")
+	if !ok {
+		return ""
+	}
+	var val string
+	important := false
+	for _, decl := range strings.Split(style, ";") {
+		name, v, ok := strings.Cut(decl, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), property) {
+			continue
+		}
+		v = strings.ToLower(strings.TrimSpace(v))
+		isImportant := false
+		if i := strings.Index(v, "!"); i >= 0 && strings.TrimSpace(v[i+1:]) == "important" {
+			v = strings.TrimSpace(v[:i])
+			isImportant = true
+		}
+		if important && !isImportant {
+			continue
+		}
+		val, important = v, isImportant
+	}
+	return val
+}
@@ -0,0 +1,67 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestHiddenVisible(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`
+<head><title id="title">Title</title><script id="script"></script></head>
+<div id="shown"><span id="child"></span></div>
+<div id="attr" hidden><span id="attr-child"></span></div>
+<div id="until-found" hidden="until-found"></div>
+<input id="text"><input id="input" type="HIDDEN">
+<div id="none" style="color: red; DISPLAY:none"><p id="none-child"></p></div>
+<div id="important" style="display: none !important; display: block"></div>
+<div id="overridden" style="display: none; display: block"></div>
+<div id="invisible" style="visibility: hidden"><p id="invisible-child"></p><p id="revealed" style="visibility:visible"></p></div>
+<div id="collapse" style="visibility: collapse"></div>
+<template id="template"><p id="template-child"></p></template>
+<svg id="svg" hidden></svg>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+
+	tests := []struct {
+		sel  string
+		want []string
+	}{
+		{
+			"[id]:hidden",
+			[]string{
+				"title", "script", "attr", "attr-child", "until-found", "input",
+				"none", "none-child", "important", "invisible", "invisible-child",
+				"collapse", "template", "template-child",
+			},
+		},
+		{
+			"body [id]:visible",
+			[]string{"shown", "child", "text", "overridden", "revealed", "svg"},
+		},
+	}
+	for _, test := range tests {
+		s, err := Parse(test.sel, WithExtensions())
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.sel, err)
+			continue
+		}
+		var got []string
+		for _, n := range s.Select(root) {
+			id, _ := attr(n, "id")
+			got = append(got, id)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("Parse(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+
+	for _, sel := range []string{":hidden", ":visible"} {
+		if _, err := Parse(sel); err == nil {
+			t.Errorf("Parse(%q) without WithExtensions() didn't return an error", sel)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package css
+
+import (
+	"golang.org/x/net/html"
+)
+
+// TreeWalker iterates over the elements in a subtree that match a selector,
+// modeled after the DOM's TreeWalker. Elements are visited in document order,
+// and the walker can move in either direction from its current node:
+//
+//	w := css.NewTreeWalker(root, css.MustParse("h1, h2, h3"))
+//	for n := w.NextNode(); n != nil; n = w.NextNode() {
+//		// ...
+//	}
+//
+// Matching is scoped to the root in the same way as Select, and a walker with
+// a nil or non-element root has no matches. Selectors with pseudo-elements
+// never match, and elements excluded by WithExclude are skipped along with
+// their descendants.
+//
+// https://dom.spec.whatwg.org/#interface-treewalker
+type TreeWalker struct {
+	root    *html.Node
+	current *html.Node
+	sel     *Selector
+	st      *state
+	// skip is set by SkipChildren, and causes the next call to NextNode to
+	// skip the descendants of the current node.
+	skip bool
+}
+
+// NewTreeWalker returns a walker over the subtree rooted at root that visits
+// elements matching sel. The walker's current node is initially root.
+func NewTreeWalker(root *html.Node, sel *Selector) *TreeWalker {
+	return &TreeWalker{
+		root:    root,
+		current: root,
+		sel:     sel,
+		st:      sel.newState(root, &MatchContext{}),
+	}
+}
+
+// Root returns the root of the subtree being walked.
+func (w *TreeWalker) Root() *html.Node {
+	return w.root
+}
+
+// CurrentNode returns the node the walker is positioned at.
+func (w *TreeWalker) CurrentNode() *html.Node {
+	return w.current
+}
+
+// SetCurrentNode moves the walker to n, which must be within the walker's
+// subtree. n doesn't need to match the selector.
+func (w *TreeWalker) SetCurrentNode(n *html.Node) {
+	w.current = n
+	w.skip = false
+}
+
+// SkipChildren causes the next call to NextNode to skip the descendants of
+// the current node.
+func (w *TreeWalker) SkipChildren() {
+	w.skip = true
+}
+
+// NextNode moves the walker to the next matching element in document order
+// and returns it. If there are no more matches, NextNode returns nil and the
+// walker isn't moved.
+func (w *TreeWalker) NextNode() *html.Node {
+	if !validRoot(w.root) || w.current == nil {
+		return nil
+	}
+	n := w.current
+	skip := w.skip || w.st.pruned(n)
+	w.skip = false
+	for {
+		n = w.next(n, skip)
+		if n == nil {
+			return nil
+		}
+		skip = w.st.pruned(n)
+		if !skip && w.accept(n) {
+			w.current = n
+			return n
+		}
+	}
+}
+
+// PreviousNode moves the walker to the previous matching element in document
+// order and returns it. If there are no previous matches, PreviousNode returns
+// nil and the walker isn't moved.
+func (w *TreeWalker) PreviousNode() *html.Node {
+	w.skip = false
+	if !validRoot(w.root) || w.current == nil {
+		return nil
+	}
+	for n := w.prev(w.current); n != nil; n = w.prev(n) {
+		if !w.st.pruned(n) && w.accept(n) {
+			w.current = n
+			return n
+		}
+	}
+	return nil
+}
+
+// ParentNode moves the walker to the closest matching ancestor of the current
+// node within the walker's subtree and returns it. If there is no such
+// ancestor, ParentNode returns nil and the walker isn't moved.
+func (w *TreeWalker) ParentNode() *html.Node {
+	for n := w.current; n != nil && n != w.root && n.Parent != nil; {
+		n = n.Parent
+		if w.accept(n) {
+			w.current = n
+			w.skip = false
+			return n
+		}
+	}
+	return nil
+}
+
+func (w *TreeWalker) accept(n *html.Node) bool {
+	// The tree may be modified while walking, so values cached by previous
+	// matches can't be reused.
+	w.st.resetCaches()
+	return isElement(n) && w.sel.matchElement(w.st, n)
+}
+
+// next returns the node following n in document order, or nil if n is the
+// last node in the walker's subtree. If skipChildren is set, n's descendants
+// aren't visited.
+func (w *TreeWalker) next(n *html.Node, skipChildren bool) *html.Node {
+	if !skipChildren && n.FirstChild != nil {
+		return n.FirstChild
+	}
+	for ; n != w.root && n != nil; n = n.Parent {
+		if n.NextSibling != nil {
+			return n.NextSibling
+		}
+	}
+	return nil
+}
+
+// prev returns the node preceding n in document order, or nil if n is the
+// walker's root. Descendants of pruned nodes aren't visited.
+func (w *TreeWalker) prev(n *html.Node) *html.Node {
+	if n == w.root {
+		return nil
+	}
+	if n.PrevSibling == nil {
+		return n.Parent
+	}
+	n = n.PrevSibling
+	for n.LastChild != nil && !w.st.pruned(n) {
+		n = n.LastChild
+	}
+	return n
+}
@@ -0,0 +1,116 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestTreeWalker(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<h1 id="a">a</h1><div id="b"><h2 id="c">c</h2><nav><h2 id="d">d</h2></nav></div><h3 id="e">e</h3>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	ids := func(nodes []*html.Node) []string {
+		var ids []string
+		for _, n := range nodes {
+			id, _ := attr(n, "id")
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	w := NewTreeWalker(doc, MustParse("h1, h2, h3, div"))
+	var forward []*html.Node
+	for n := w.NextNode(); n != nil; n = w.NextNode() {
+		forward = append(forward, n)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c", "d", "e"}, ids(forward)); diff != "" {
+		t.Errorf("NextNode() returned diff (-want, +got): %s", diff)
+	}
+	if w.CurrentNode() != forward[len(forward)-1] {
+		t.Errorf("walker moved after NextNode() returned nil")
+	}
+
+	var backward []*html.Node
+	for n := w.PreviousNode(); n != nil; n = w.PreviousNode() {
+		backward = append(backward, n)
+	}
+	if diff := cmp.Diff([]string{"d", "c", "b", "a"}, ids(backward)); diff != "" {
+		t.Errorf("PreviousNode() returned diff (-want, +got): %s", diff)
+	}
+
+	// Skip the children of the div.
+	w = NewTreeWalker(doc, MustParse("h1, h2, h3, div"))
+	var skipped []*html.Node
+	for n := w.NextNode(); n != nil; n = w.NextNode() {
+		skipped = append(skipped, n)
+		if n.Data == "div" {
+			w.SkipChildren()
+		}
+	}
+	if diff := cmp.Diff([]string{"a", "b", "e"}, ids(skipped)); diff != "" {
+		t.Errorf("NextNode() with SkipChildren() returned diff (-want, +got): %s", diff)
+	}
+
+	// Excluded subtrees aren't visited in either direction.
+	w = NewTreeWalker(doc, MustParse("h2, h3", WithExclude(MustParse("nav"))))
+	var excluded []*html.Node
+	for n := w.NextNode(); n != nil; n = w.NextNode() {
+		excluded = append(excluded, n)
+	}
+	for n := w.PreviousNode(); n != nil; n = w.PreviousNode() {
+		excluded = append(excluded, n)
+	}
+	if diff := cmp.Diff([]string{"c", "e", "c"}, ids(excluded)); diff != "" {
+		t.Errorf("walking with excluded subtrees returned diff (-want, +got): %s", diff)
+	}
+
+	// Walking up from an element.
+	div, err := QuerySelector(doc, "div")
+	if err != nil {
+		t.Fatalf("QuerySelector() failed: %v", err)
+	}
+	d, err := QuerySelector(doc, "#d")
+	if err != nil {
+		t.Fatalf("QuerySelector() failed: %v", err)
+	}
+	w = NewTreeWalker(div, MustParse("div, nav"))
+	w.SetCurrentNode(d)
+	if got := w.ParentNode(); got == nil || got.Data != "nav" {
+		t.Errorf("ParentNode() = %v, want nav element", got)
+	}
+	if got := w.ParentNode(); got != div {
+		t.Errorf("ParentNode() = %v, want root", got)
+	}
+	if got := w.ParentNode(); got != nil {
+		t.Errorf("ParentNode() = %v, want nil past the root", got)
+	}
+}
+
+func TestTreeWalkerModified(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`
+		<input type="radio" name="a" id="r1" checked>
+		<input type="radio" name="a" id="r2">`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	r1 := MustParse("#r1").Select(doc)[0]
+	r2 := MustParse("#r2").Select(doc)[0]
+
+	w := NewTreeWalker(doc, MustParse(":checked"))
+	if got := w.NextNode(); got != r1 {
+		t.Fatalf("NextNode() = %v, want #r1", got)
+	}
+
+	// Values cached while walking reflect the tree before it was modified.
+	// Remove the checked attribute, which is last.
+	r1.Attr = r1.Attr[:len(r1.Attr)-1]
+	r2.Attr = append(r2.Attr, html.Attribute{Key: "checked"})
+	w.SetCurrentNode(doc)
+	if got := w.NextNode(); got != r2 {
+		t.Errorf("NextNode() after moving checked attribute = %v, want #r2", got)
+	}
+}
@@ -0,0 +1,148 @@
+package css
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// WhyNot explains why n doesn't match the selector, reporting the first
+// component of each selector in the list that failed to match. For example:
+//
+//	a[href^="https"]: attribute selector [href^="https"] failed: value is "http://example.com"
+//
+// WhyNot returns an empty string if n matches the selector. Combinators are
+// evaluated against the entire tree containing n.
+func (s *Selector) WhyNot(n *html.Node) string {
+	root := treeRoot(n)
+	st := newState(root, &MatchContext{})
+	if s.match(st, n) {
+		return ""
+	}
+
+	var b strings.Builder
+	for i, sel := range s.s {
+		writeComplexSelector(&b, &s.list[i])
+		b.WriteString(": ")
+		b.WriteString(sel.whyNot(st, n))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// whyNot follows the first candidate that satisfies each combinator, and
+// reports the first compound selector that couldn't be satisfied.
+func (s *selector) whyNot(st *state, n *html.Node) string {
+	if reason := s.m.whyNot(st, n); reason != "" {
+		return reason
+	}
+	for _, c := range s.combinators {
+		var (
+			m    *compoundSelectorMatcher
+			next func(n *html.Node) *html.Node
+			all  bool
+			rel  string
+		)
+		switch c := c.(type) {
+		case *descendantCombinator:
+			m, next, all, rel = c.m, st.parent, true, "ancestor"
+		case *childCombinator:
+			m, next, rel = c.m, st.parent, "parent"
+		case *adjacentCombinator:
+			m, next, rel = c.m, st.prevSibling, "previous sibling"
+		case *siblingCombinator:
+			m, next, all, rel = c.m, st.prevSibling, true, "previous sibling"
+		default:
+			// Non-standard combinators can relate an element to any number of
+			// others, and aren't explained further.
+			return fmt.Sprintf("no element related to %s satisfies the rest of the selector", describeNode(n))
+		}
+
+		p := next(n)
+		if p == nil {
+			return fmt.Sprintf("%s has no %s element", describeNode(n), rel)
+		}
+		if !all {
+			if reason := m.whyNot(st, p); reason != "" {
+				return fmt.Sprintf("%s %s: %s", rel, describeNode(p), reason)
+			}
+			n = p
+			continue
+		}
+		for ; p != nil; p = next(p) {
+			if m.match(st, p) {
+				break
+			}
+		}
+		if p == nil {
+			var b strings.Builder
+			writeCompoundSelector(&b, m.src)
+			return fmt.Sprintf("no %s of %s matches %s", rel, describeNode(n), b.String())
+		}
+		n = p
+	}
+	// Not reached for elements that fail to match, since the candidates
+	// followed above would form a match.
+	return "no combination of elements satisfies the selector"
+}
+
+// whyNot reports the first component of the compound selector that doesn't
+// match n, or an empty string if n matches.
+func (c *compoundSelectorMatcher) whyNot(s *state, n *html.Node) string {
+	if !c.host && s.featureless(n) {
+		return "shadow hosts can only be matched by :host"
+	}
+	if c.m != nil && !c.m.match(n) {
+		var b strings.Builder
+		writeCompoundSelector(&b, &compoundSelector{typeSelector: c.src.typeSelector})
+		return fmt.Sprintf("type selector %s failed: element is %s", b.String(), describeNode(n))
+	}
+
+	// Subclass selectors may have been reordered for performance, report the
+	// first failure as written.
+	var failed *subclassSelectorMatcher
+	for i := range c.scm {
+		m := &c.scm[i]
+		if m.match(s, n) {
+			continue
+		}
+		if failed == nil || m.src.pos < failed.src.pos {
+			failed = m
+		}
+	}
+	if failed == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	writeCompoundSelector(&b, &compoundSelector{subClasses: []subclassSelector{*failed.src}})
+	sel := b.String()
+	switch {
+	case failed.idSelector != "":
+		if id, ok := attr(n, "id"); ok {
+			return fmt.Sprintf("id selector %s failed: id is %q", sel, id)
+		}
+		return fmt.Sprintf("id selector %s failed: element has no id", sel)
+	case failed.classSelector != "":
+		if class, ok := attr(n, "class"); ok {
+			return fmt.Sprintf("class selector %s failed: class is %q", sel, class)
+		}
+		return fmt.Sprintf("class selector %s failed: element has no class", sel)
+	case failed.attributeSelector != nil:
+		key := failed.src.attributeSelector.wqName.value
+		for _, a := range n.Attr {
+			if strings.EqualFold(a.Key, key) {
+				return fmt.Sprintf("attribute selector %s failed: value is %q", sel, a.Val)
+			}
+		}
+		return fmt.Sprintf("attribute selector %s failed: element has no %s attribute", sel, key)
+	default:
+		return fmt.Sprintf("pseudo-class %s failed", sel)
+	}
+}
+
+// describeNode formats an element's start tag name, such as "<div>".
+func describeNode(n *html.Node) string {
+	return "<" + n.Data + ">"
+}
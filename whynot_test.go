@@ -0,0 +1,46 @@
+package css
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestWhyNot(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(`<div class="a b"><p id="x"><a href="http://example.com">link</a></p></div>`))
+	if err != nil {
+		t.Fatalf("html.Parse() failed: %v", err)
+	}
+	a := MustParse("a").Select(root)[0]
+
+	tests := []struct {
+		sel  string
+		want string
+	}{
+		{"p > a", ""},
+		{"span", "span: type selector span failed: element is <a>\n"},
+		{
+			`a[href^="https"]`,
+			`a[href^="https"]: attribute selector [href^="https"] failed: value is "http://example.com"` + "\n",
+		},
+		{`a[title]`, "a[title]: attribute selector [title] failed: element has no title attribute\n"},
+		{"a#y", "a#y: id selector #y failed: element has no id\n"},
+		{"a:first-child.c", "a:first-child.c: class selector .c failed: element has no class\n"},
+		{"#y > a", `#y > a: parent <p>: id selector #y failed: id is "x"` + "\n"},
+		{".c a", ".c a: no ancestor of <a> matches .c\n"},
+		{"span + a", "span + a: <a> has no previous sibling element\n"},
+		{
+			"span, div.c a",
+			"span: type selector span failed: element is <a>\n" +
+				"div.c a: no ancestor of <a> matches div.c\n",
+		},
+	}
+	for _, test := range tests {
+		got := MustParse(test.sel).WhyNot(a)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("WhyNot(%q) returned diff (-want, +got): %s", test.sel, diff)
+		}
+	}
+}